@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AkismetClient spricht das Akismet-Protokoll (comment-check, submit-spam, submit-ham).
+// Funktioniert mit Akismet selbst und kompatiblen Diensten.
+type AkismetClient struct {
+	baseURL string
+	key     string
+	blog    string
+	isTest  bool
+	client  *http.Client
+}
+
+// NewAkismetClient liest die Konfiguration aus der Umgebung.
+// Liefert nil, wenn kein AKISMET_KEY gesetzt ist.
+func NewAkismetClient() *AkismetClient {
+	key := getEnv("AKISMET_KEY", "")
+	if key == "" {
+		return nil
+	}
+
+	blog := getEnv("AKISMET_BLOG", "")
+	if blog == "" {
+		blog = "http://localhost"
+		if domain := getEnv("DOMAIN", ""); domain != "" {
+			blog = "https://" + domain
+		}
+	}
+
+	return &AkismetClient{
+		baseURL: strings.TrimRight(getEnv("AKISMET_URL", "https://rest.akismet.com/1.1"), "/"),
+		key:     key,
+		blog:    blog,
+		isTest:  getEnvAsBool("AKISMET_TEST", false),
+		client:  &http.Client{Timeout: getEnvAsDuration("AKISMET_TIMEOUT", 3*time.Second)},
+	}
+}
+
+// post ruft eine Akismet-Methode auf und liefert den Antworttext
+func (a *AkismetClient) post(method string, values url.Values) (string, http.Header, error) {
+	values.Set("api_key", a.key)
+	values.Set("blog", a.blog)
+	if a.isTest {
+		values.Set("is_test", "1")
+	}
+
+	resp, err := a.client.PostForm(a.baseURL+"/"+method, values)
+	if err != nil {
+		return "", nil, fmt.Errorf("akismet %s fehlgeschlagen: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", nil, fmt.Errorf("akismet %s fehlgeschlagen: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("akismet %s: HTTP %d", method, resp.StatusCode)
+	}
+
+	text := strings.TrimSpace(string(body))
+	if help := resp.Header.Get("X-akismet-debug-help"); help != "" || text == "invalid" {
+		return "", nil, fmt.Errorf("akismet %s: %s %s", method, text, help)
+	}
+
+	return text, resp.Header, nil
+}
+
+// commentValues liefert die Protokollfelder eines Kommentars
+func (a *AkismetClient) commentValues(c *Comment) url.Values {
+	commentType := "comment"
+	if c.ParentID != 0 {
+		commentType = "reply"
+	}
+
+	values := url.Values{}
+	values.Set("user_ip", c.IP)
+	values.Set("user_agent", c.UserAgent)
+	values.Set("comment_type", commentType)
+	values.Set("comment_author", c.Username)
+	values.Set("comment_author_email", c.MailAddress)
+	values.Set("comment_content", c.Text)
+	values.Set("blog_charset", "UTF-8")
+	if t := commentTime(c); !t.IsZero() {
+		values.Set("comment_date_gmt", t.UTC().Format(time.RFC3339))
+	}
+	return values
+}
+
+// VerifyKey prüft den API-Key
+func (a *AkismetClient) VerifyKey() error {
+	text, _, err := a.post("verify-key", url.Values{"key": {a.key}})
+	if err != nil {
+		return err
+	}
+	if text != "valid" {
+		return fmt.Errorf("akismet-key ungültig: %s", text)
+	}
+	return nil
+}
+
+// CommentCheck fragt, ob ein Kommentar Spam ist. discard meldet eindeutigen Spam
+// (X-akismet-pro-tip: discard).
+func (a *AkismetClient) CommentCheck(c *Comment) (spam bool, discard bool, err error) {
+	text, header, err := a.post("comment-check", a.commentValues(c))
+	if err != nil {
+		return false, false, err
+	}
+
+	switch text {
+	case "true":
+		return true, header.Get("X-akismet-pro-tip") == "discard", nil
+	case "false":
+		return false, false, nil
+	default:
+		return false, false, fmt.Errorf("akismet comment-check: unerwartete Antwort %q", text)
+	}
+}
+
+// SubmitSpam meldet einen nicht erkannten Spam-Kommentar
+func (a *AkismetClient) SubmitSpam(c *Comment) error {
+	_, _, err := a.post("submit-spam", a.commentValues(c))
+	return err
+}
+
+// SubmitHam meldet einen fälschlich als Spam erkannten Kommentar
+func (a *AkismetClient) SubmitHam(c *Comment) error {
+	_, _, err := a.post("submit-ham", a.commentValues(c))
+	return err
+}
+
+// ReportModeration meldet Moderationsentscheidungen im Hintergrund, damit der
+// Dienst lernt: -> spam als submit-spam, spam -> approved als submit-ham
+func (a *AkismetClient) ReportModeration(c *Comment, from, to CommentStatus) {
+	if a == nil {
+		return
+	}
+
+	var submit func(*Comment) error
+	var kind string
+	switch {
+	case to == StatusSpam && from != StatusSpam:
+		submit, kind = a.SubmitSpam, "submit-spam"
+	case from == StatusSpam && to == StatusApproved:
+		submit, kind = a.SubmitHam, "submit-ham"
+	default:
+		return
+	}
+
+	go func() {
+		if err := submit(c); err != nil {
+			log.Printf("⚠️  Akismet %s für Kommentar %d fehlgeschlagen: %v", kind, c.ID, err)
+			return
+		}
+		log.Printf("🛡️  Akismet %s für Kommentar %d gesendet", kind, c.ID)
+	}()
+}
+
+// akismetCheck bindet Akismet als Check in die Spam-Pipeline ein.
+// Fail open: ist der Dienst nicht erreichbar, trägt der Check nichts zum Score bei.
+type akismetCheck struct {
+	client    *AkismetClient
+	spamScore float64
+}
+
+func (c *akismetCheck) Name() string { return "akismet" }
+
+func (c *akismetCheck) Check(comment *Comment) (float64, string) {
+	spam, discard, err := c.client.CommentCheck(comment)
+	if err != nil {
+		log.Printf("⚠️  Akismet nicht erreichbar, Kommentar wird ohne Akismet bewertet: %v", err)
+		return 0, ""
+	}
+	if discard {
+		return 2 * c.spamScore, "eindeutiger Spam"
+	}
+	if spam {
+		return c.spamScore, "als Spam erkannt"
+	}
+	return 0, ""
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// akismetRequest ist ein beim Testserver eingegangener Aufruf
+type akismetRequest struct {
+	method string
+	form   url.Values
+}
+
+// newAkismetServer startet einen Akismet-Testserver; respond beantwortet comment-check,
+// alle Aufrufe landen in requests
+func newAkismetServer(t *testing.T, respond func(w http.ResponseWriter)) (*AkismetClient, chan akismetRequest) {
+	t.Helper()
+
+	requests := make(chan akismetRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ungültiges Formular: %v", err)
+		}
+		requests <- akismetRequest{method: r.URL.Path, form: r.PostForm}
+
+		switch r.URL.Path {
+		case "/1.1/comment-check":
+			respond(w)
+		case "/1.1/submit-spam", "/1.1/submit-ham":
+			w.Write([]byte("Thanks for making the web a better place."))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := &AkismetClient{
+		baseURL: server.URL + "/1.1",
+		key:     "testkey",
+		blog:    "https://blog.example",
+		client:  &http.Client{Timeout: time.Second},
+	}
+	return client, requests
+}
+
+// nextAkismetRequest wartet auf den nächsten Aufruf (ReportModeration sendet im Hintergrund)
+func nextAkismetRequest(t *testing.T, requests chan akismetRequest) akismetRequest {
+	t.Helper()
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(2 * time.Second):
+		t.Fatal("kein Aufruf beim Akismet-Testserver")
+		return akismetRequest{}
+	}
+}
+
+func newAkismetComment() *Comment {
+	return &Comment{
+		ID:          7,
+		PostID:      "post-1",
+		ParentID:    3,
+		Username:    "Anna",
+		MailAddress: "anna@example.com",
+		Text:        "Hallo Welt",
+		IP:          "203.0.113.5",
+		UserAgent:   "Mozilla/5.0",
+		Status:      StatusPending,
+	}
+}
+
+func TestAkismetCommentCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		proTip      string
+		wantSpam    bool
+		wantDiscard bool
+	}{
+		{name: "kein Spam", body: "false"},
+		{name: "Spam", body: "true", wantSpam: true},
+		{name: "eindeutiger Spam", body: "true", proTip: "discard", wantSpam: true, wantDiscard: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newAkismetServer(t, func(w http.ResponseWriter) {
+				if tt.proTip != "" {
+					w.Header().Set("X-akismet-pro-tip", tt.proTip)
+				}
+				w.Write([]byte(tt.body))
+			})
+
+			spam, discard, err := client.CommentCheck(newAkismetComment())
+			if err != nil {
+				t.Fatalf("CommentCheck: %v", err)
+			}
+			if spam != tt.wantSpam || discard != tt.wantDiscard {
+				t.Fatalf("spam=%v discard=%v, erwartet spam=%v discard=%v", spam, discard, tt.wantSpam, tt.wantDiscard)
+			}
+
+			req := nextAkismetRequest(t, requests)
+			want := map[string]string{
+				"api_key":              "testkey",
+				"blog":                 "https://blog.example",
+				"user_ip":              "203.0.113.5",
+				"user_agent":           "Mozilla/5.0",
+				"comment_type":         "reply",
+				"comment_author":       "Anna",
+				"comment_author_email": "anna@example.com",
+				"comment_content":      "Hallo Welt",
+			}
+			for field, value := range want {
+				if got := req.form.Get(field); got != value {
+					t.Errorf("%s = %q, erwartet %q", field, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestAkismetCommentCheckErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter)
+	}{
+		{name: "ungültiger Key", respond: func(w http.ResponseWriter) {
+			w.Header().Set("X-akismet-debug-help", "Empty \"api_key\" value")
+			w.Write([]byte("invalid"))
+		}},
+		{name: "unerwartete Antwort", respond: func(w http.ResponseWriter) { w.Write([]byte("vielleicht")) }},
+		{name: "HTTP-Fehler", respond: func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newAkismetServer(t, tt.respond)
+			if _, _, err := client.CommentCheck(newAkismetComment()); err == nil {
+				t.Fatal("Fehler erwartet")
+			}
+		})
+	}
+}
+
+func TestAkismetReportModeration(t *testing.T) {
+	tests := []struct {
+		name       string
+		from, to   CommentStatus
+		wantMethod string
+	}{
+		{name: "als Spam markiert", from: StatusPending, to: StatusSpam, wantMethod: "/1.1/submit-spam"},
+		{name: "freigegebener Kommentar als Spam", from: StatusApproved, to: StatusSpam, wantMethod: "/1.1/submit-spam"},
+		{name: "Spam freigegeben", from: StatusSpam, to: StatusApproved, wantMethod: "/1.1/submit-ham"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newAkismetServer(t, func(w http.ResponseWriter) { w.Write([]byte("false")) })
+
+			client.ReportModeration(newAkismetComment(), tt.from, tt.to)
+
+			req := nextAkismetRequest(t, requests)
+			if req.method != tt.wantMethod {
+				t.Fatalf("Aufruf %s, erwartet %s", req.method, tt.wantMethod)
+			}
+			if got := req.form.Get("comment_content"); got != "Hallo Welt" {
+				t.Fatalf("comment_content = %q", got)
+			}
+		})
+	}
+
+	t.Run("ohne Lerneffekt", func(t *testing.T) {
+		client, requests := newAkismetServer(t, func(w http.ResponseWriter) { w.Write([]byte("false")) })
+
+		client.ReportModeration(newAkismetComment(), StatusPending, StatusApproved)
+		client.ReportModeration(newAkismetComment(), StatusSpam, StatusSpam)
+		client.ReportModeration(newAkismetComment(), StatusSpam, StatusRejected)
+
+		select {
+		case req := <-requests:
+			t.Fatalf("unerwarteter Aufruf %s", req.method)
+		case <-time.After(200 * time.Millisecond):
+		}
+	})
+
+	t.Run("ohne Akismet", func(t *testing.T) {
+		var client *AkismetClient
+		client.ReportModeration(newAkismetComment(), StatusPending, StatusSpam)
+	})
+}
+
+func TestAkismetCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		proTip     string
+		wantScore  float64
+		wantReason string
+	}{
+		{name: "kein Spam", body: "false"},
+		{name: "Spam", body: "true", wantScore: 5, wantReason: "als Spam erkannt"},
+		{name: "eindeutiger Spam", body: "true", proTip: "discard", wantScore: 10, wantReason: "eindeutiger Spam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newAkismetServer(t, func(w http.ResponseWriter) {
+				if tt.proTip != "" {
+					w.Header().Set("X-akismet-pro-tip", tt.proTip)
+				}
+				w.Write([]byte(tt.body))
+			})
+
+			check := &akismetCheck{client: client, spamScore: 5}
+			score, reason := check.Check(newAkismetComment())
+			if score != tt.wantScore || reason != tt.wantReason {
+				t.Fatalf("Check = (%v, %q), erwartet (%v, %q)", score, reason, tt.wantScore, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestAkismetCheckFailOpen(t *testing.T) {
+	t.Run("Timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		client, _ := newAkismetServer(t, func(w http.ResponseWriter) {
+			<-release
+			w.Write([]byte("true"))
+		})
+		defer close(release)
+		client.client.Timeout = 50 * time.Millisecond
+
+		check := &akismetCheck{client: client, spamScore: 5}
+		start := time.Now()
+		score, reason := check.Check(newAkismetComment())
+		if score != 0 || reason != "" {
+			t.Fatalf("Check = (%v, %q), erwartet (0, \"\")", score, reason)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Check hat %v gewartet, Timeout greift nicht", elapsed)
+		}
+	})
+
+	t.Run("nicht erreichbar", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		baseURL := server.URL
+		server.Close()
+
+		client := &AkismetClient{baseURL: baseURL, key: "testkey", blog: "https://blog.example", client: &http.Client{Timeout: time.Second}}
+		check := &akismetCheck{client: client, spamScore: 5}
+		if score, reason := check.Check(newAkismetComment()); score != 0 || reason != "" {
+			t.Fatalf("Check = (%v, %q), erwartet (0, \"\")", score, reason)
+		}
+	})
+
+	t.Run("Serverfehler", func(t *testing.T) {
+		client, _ := newAkismetServer(t, func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) })
+		check := &akismetCheck{client: client, spamScore: 5}
+		if score, reason := check.Check(newAkismetComment()); score != 0 || reason != "" {
+			t.Fatalf("Check = (%v, %q), erwartet (0, \"\")", score, reason)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ErrAuditUnsupported: das Storage-Backend kann kein Audit-Log speichern
+var ErrAuditUnsupported = errors.New("audit-log wird vom storage-backend nicht unterstützt")
+
+// AuditAction beschreibt die Art eines Audit-Eintrags
+type AuditAction string
+
+const (
+	AuditStatus  AuditAction = "status"  // Statuswechsel (einzeln, Bulk oder automatisch, auch in den Papierkorb)
+	AuditRestore AuditAction = "restore" // aus dem Papierkorb wiederhergestellt
+	AuditDelete  AuditAction = "delete"  // endgültiges Löschen aus dem Papierkorb
+)
+
+// AuditEntry protokolliert eine Moderationsaktion. Einträge werden nur
+// angehängt, nie geändert oder gelöscht – auch nicht mit dem Kommentar.
+type AuditEntry struct {
+	ID        int           `json:"id"`
+	CommentID int           `json:"comment_id"`
+	PostID    string        `json:"post_id"`
+	Action    AuditAction   `json:"action"`
+	Actor     string        `json:"actor"`
+	OldStatus CommentStatus `json:"old_status"`
+	NewStatus CommentStatus `json:"new_status,omitempty"` // leer beim Löschen
+	IP        string        `json:"ip,omitempty"`
+	CreatedAt string        `json:"created_at"`
+}
+
+// AuditFilter schränkt die Abfrage des Audit-Logs ein (leere Felder = alle)
+type AuditFilter struct {
+	CommentID int
+	PostID    string
+	Actor     string
+	Limit     int
+	Offset    int
+}
+
+// matches prüft einen Eintrag gegen den Filter (für Backends ohne Abfragesprache)
+func (f AuditFilter) matches(entry *AuditEntry) bool {
+	return (f.CommentID == 0 || entry.CommentID == f.CommentID) &&
+		(f.PostID == "" || entry.PostID == f.PostID) &&
+		(f.Actor == "" || entry.Actor == f.Actor)
+}
+
+// AuditStore speichert das Audit-Log im gewählten Storage-Backend
+type AuditStore interface {
+	// AppendAudit vergibt eine neue ID und hängt den Eintrag an
+	AppendAudit(entry *AuditEntry) error
+	// ListAudit liefert die passenden Einträge (neueste zuerst) und deren Gesamtzahl
+	ListAudit(filter AuditFilter) ([]*AuditEntry, int, error)
+}
+
+// auditStore liefert den AuditStore des Backends oder nil
+func auditStore(store CommentStore) AuditStore {
+	audit, _ := store.(AuditStore)
+	return audit
+}
+
+// Moderator ist der Auslöser einer Moderationsaktion
+type Moderator struct {
+	Name string // Name des Admin-Tokens oder "system"
+	IP   string
+}
+
+// systemModerator steht für automatische Aktionen ohne Request
+var systemModerator = Moderator{Name: "system"}
+
+// actorContextKey speichert den Namen des Admin-Tokens im Request-Kontext
+type actorContextKey struct{}
+
+// withActor hängt den Namen des geprüften Tokens an den Request
+func withActor(r *http.Request, name string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), actorContextKey{}, name))
+}
+
+// moderatorFromRequest liefert Token-Name und Client-IP eines Admin-Requests
+func (h *CommentHandler) moderatorFromRequest(r *http.Request) Moderator {
+	name, _ := r.Context().Value(actorContextKey{}).(string)
+	if name == "" {
+		// AUTH_ENABLED=false
+		name = "anonymous"
+	}
+	return Moderator{Name: name, IP: clientIP(r, h.trustedProxies)}
+}
+
+// recordAudit hängt einen Eintrag an. Fehler werden nur geloggt, die
+// Moderationsaktion selbst ist zu diesem Zeitpunkt bereits gespeichert.
+func (cs *CommentService) recordAudit(action AuditAction, comment *Comment, previous, status CommentStatus, by Moderator) {
+	if cs.audit == nil {
+		return
+	}
+	entry := &AuditEntry{
+		CommentID: comment.ID,
+		PostID:    comment.PostID,
+		Action:    action,
+		Actor:     by.Name,
+		OldStatus: previous,
+		NewStatus: status,
+		IP:        by.IP,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := cs.audit.AppendAudit(entry); err != nil {
+		log.Printf("⚠️  Audit-Eintrag für Kommentar #%d nicht gespeichert: %v", comment.ID, err)
+	}
+}
+
+// ListAudit liefert eine Seite des Audit-Logs
+func (cs *CommentService) ListAudit(filter AuditFilter) ([]*AuditEntry, int, error) {
+	if cs.audit == nil {
+		return nil, 0, ErrAuditUnsupported
+	}
+	return cs.audit.ListAudit(filter)
+}
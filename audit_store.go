@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Das Audit-Log liegt im selben Backend wie die Kommentare und wird nur
+// angehängt. In Valkey:
+//
+//	audit:{id}             String  Eintrag als JSON
+//	audit:index            ZSet    alle IDs, Score = ID
+//	audit:comment:{id}     ZSet    IDs je Kommentar
+//	audit:post:{post_id}   ZSet    IDs je Post
+//	audit:actor:{actor}    ZSet    IDs je Moderator
+
+// auditKey liefert den Key eines Audit-Eintrags
+func auditKey(id int) string {
+	return fmt.Sprintf("audit:%d", id)
+}
+
+// auditIndexKeys liefert die Index-Keys für einen Filter (audit:index ohne Filter)
+func auditIndexKeys(commentID int, postID, actor string) []string {
+	var keys []string
+	if commentID != 0 {
+		keys = append(keys, fmt.Sprintf("audit:comment:%d", commentID))
+	}
+	if postID != "" {
+		keys = append(keys, "audit:post:"+postID)
+	}
+	if actor != "" {
+		keys = append(keys, "audit:actor:"+actor)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, "audit:index")
+	}
+	return keys
+}
+
+// writeAudit speichert einen Eintrag samt Indizes
+func (s *ValkeyStore) writeAudit(pipe redis.Pipeliner, entry *AuditEntry, data []byte) {
+	member := redis.Z{Score: float64(entry.ID), Member: entry.ID}
+	pipe.Set(s.ctx, auditKey(entry.ID), data, 0)
+	pipe.ZAdd(s.ctx, "audit:index", member)
+	for _, key := range auditIndexKeys(entry.CommentID, entry.PostID, entry.Actor) {
+		pipe.ZAdd(s.ctx, key, member)
+	}
+}
+
+// AppendAudit vergibt eine ID (audit_counter) und speichert den Eintrag samt Indizes
+func (s *ValkeyStore) AppendAudit(entry *AuditEntry) error {
+	id, err := s.client.Incr(s.ctx, "audit_counter").Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Generieren der ID: %w", err)
+	}
+	entry.ID = int(id)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("fehler beim Serialisieren des Audit-Eintrags: %w", err)
+	}
+	pipe := s.client.TxPipeline()
+	s.writeAudit(pipe, entry, data)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern des Audit-Eintrags: %w", err)
+	}
+	return nil
+}
+
+// ListAudit blättert im passenden Index (bei mehreren Filtern in der
+// Schnittmenge) und lädt nur die Einträge der Seite
+func (s *ValkeyStore) ListAudit(filter AuditFilter) ([]*AuditEntry, int, error) {
+	keys := auditIndexKeys(filter.CommentID, filter.PostID, filter.Actor)
+	key := keys[0]
+	if len(keys) > 1 {
+		// Kurzlebige Schnittmenge, damit auch kombinierte Filter per Offset blättern
+		key = fmt.Sprintf("audit:tmp:%s", strings.Join(keys, "|"))
+		pipe := s.client.TxPipeline()
+		pipe.ZInterStore(s.ctx, key, &redis.ZStore{Keys: keys, Aggregate: "MAX"})
+		pipe.Expire(s.ctx, key, 30*time.Second)
+		if _, err := pipe.Exec(s.ctx); err != nil {
+			return nil, 0, fmt.Errorf("fehler beim Filtern des Audit-Logs: %w", err)
+		}
+	}
+
+	total, err := s.client.ZCard(s.ctx, key).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Zählen des Audit-Logs: %w", err)
+	}
+	stop := int64(-1)
+	if filter.Limit > 0 {
+		stop = int64(filter.Offset + filter.Limit - 1)
+	}
+	ids, err := s.client.ZRevRange(s.ctx, key, int64(filter.Offset), stop).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Laden des Audit-Logs: %w", err)
+	}
+	if len(ids) == 0 {
+		return []*AuditEntry{}, int(total), nil
+	}
+
+	entryKeys := make([]string, len(ids))
+	for i, id := range ids {
+		entryKeys[i] = "audit:" + id
+	}
+	values, err := s.client.MGet(s.ctx, entryKeys...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Laden des Audit-Logs: %w", err)
+	}
+
+	entries := make([]*AuditEntry, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(data), &entry); err == nil {
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, int(total), nil
+}
+
+// pageAudit schneidet Offset und Limit aus einer gefilterten Liste
+func pageAudit(entries []*AuditEntry, filter AuditFilter) []*AuditEntry {
+	if filter.Offset >= len(entries) {
+		return []*AuditEntry{}
+	}
+	entries = entries[filter.Offset:]
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries
+}
+
+// auditColumns ist die Spaltenliste für ListAudit
+const auditColumns = "id, comment_id, post_id, action, actor, old_status, new_status, ip, created_at"
+
+// AppendAudit hängt einen Eintrag an moderation_audit an
+func (s *SQLStore) AppendAudit(entry *AuditEntry) error {
+	err := s.db.QueryRow(s.rebind(`INSERT INTO moderation_audit
+		(comment_id, post_id, action, actor, old_status, new_status, ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		entry.CommentID, entry.PostID, string(entry.Action), entry.Actor,
+		string(entry.OldStatus), string(entry.NewStatus), entry.IP, entry.CreatedAt,
+	).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Audit-Eintrags: %w", err)
+	}
+	return nil
+}
+
+// ListAudit liefert die passenden Einträge, neueste zuerst
+func (s *SQLStore) ListAudit(filter AuditFilter) ([]*AuditEntry, int, error) {
+	var where []string
+	var args []interface{}
+	if filter.CommentID != 0 {
+		where = append(where, "comment_id = ?")
+		args = append(args, filter.CommentID)
+	}
+	if filter.PostID != "" {
+		where = append(where, "post_id = ?")
+		args = append(args, filter.PostID)
+	}
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	condition := ""
+	if len(where) > 0 {
+		condition = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow(s.rebind("SELECT COUNT(*) FROM moderation_audit"+condition), args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Zählen des Audit-Logs: %w", err)
+	}
+
+	query := "SELECT " + auditColumns + " FROM moderation_audit" + condition + " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Laden des Audit-Logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var action, oldStatus, newStatus string
+		if err := rows.Scan(&entry.ID, &entry.CommentID, &entry.PostID, &action, &entry.Actor,
+			&oldStatus, &newStatus, &entry.IP, &entry.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("fehler beim Lesen des Audit-Logs: %w", err)
+		}
+		entry.Action = AuditAction(action)
+		entry.OldStatus = CommentStatus(oldStatus)
+		entry.NewStatus = CommentStatus(newStatus)
+		entries = append(entries, &entry)
+	}
+	return entries, total, rows.Err()
+}
+
+// AppendAudit hängt einen Eintrag an
+func (s *MemoryStore) AppendAudit(entry *AuditEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry.ID = len(s.audit) + 1
+	clone := *entry
+	s.audit = append(s.audit, &clone)
+	return nil
+}
+
+// ListAudit liefert die passenden Einträge, neueste zuerst
+func (s *MemoryStore) ListAudit(filter AuditFilter) ([]*AuditEntry, int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	matched := make([]*AuditEntry, 0)
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		if filter.matches(s.audit[i]) {
+			clone := *s.audit[i]
+			matched = append(matched, &clone)
+		}
+	}
+	return pageAudit(matched, filter), len(matched), nil
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BanType bestimmt, worauf eine Sperrregel angewendet wird
+type BanType string
+
+// Sperrregel-Typen
+const (
+	BanIP          BanType = "ip"           // exakte IP-Adresse
+	BanCIDR        BanType = "cidr"         // Netz, z.B. 203.0.113.0/24
+	BanEmail       BanType = "email"        // exakte E-Mail-Adresse
+	BanEmailDomain BanType = "email_domain" // Domain der E-Mail-Adresse inkl. Subdomains
+	BanUsername    BanType = "username"     // regulärer Ausdruck auf den Namen
+)
+
+// BanAction bestimmt, was mit Kommentaren gesperrter Absender passiert
+type BanAction string
+
+// Sperr-Aktionen
+const (
+	BanReject BanAction = "reject" // mit 403 ablehnen
+	BanSpam   BanAction = "spam"   // scheinbar annehmen, aber als Spam speichern
+	BanShadow BanAction = "shadow" // als Spam speichern, für den Absender aber sichtbar (Shadow-Ban)
+)
+
+// banPriority legt fest, welche Aktion bei mehreren Treffern greift
+var banPriority = map[BanAction]int{BanReject: 3, BanShadow: 2, BanSpam: 1}
+
+// Fehler bei Sperrregeln
+var (
+	ErrBanNotFound = errors.New("sperrregel nicht gefunden")
+	ErrInvalidBan  = errors.New("ungültige sperrregel")
+	// ErrBansUnsupported: das Storage-Backend kann keine Sperrregeln speichern
+	ErrBansUnsupported = errors.New("sperrlisten werden vom storage-backend nicht unterstützt")
+)
+
+// BanRule ist eine Sperrregel. ExpiresAt leer = unbefristet.
+type BanRule struct {
+	ID        int       `json:"id"`
+	Type      BanType   `json:"type"`
+	Value     string    `json:"value"`
+	Action    BanAction `json:"action"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt string    `json:"created_at"`
+	ExpiresAt string    `json:"expires_at,omitempty"`
+	Expired   bool      `json:"expired"` // nur in API-Antworten, nicht gespeichert
+}
+
+// BanStore speichert Sperrregeln im gewählten Storage-Backend
+type BanStore interface {
+	ListBans() ([]*BanRule, error)
+	GetBan(id int) (*BanRule, error)
+	// CreateBan vergibt eine neue ID und speichert die Regel
+	CreateBan(rule *BanRule) error
+	UpdateBan(rule *BanRule) error
+	DeleteBan(id int) error
+}
+
+// expiredAt prüft, ob die Regel zum Zeitpunkt now abgelaufen ist
+func (b *BanRule) expiredAt(now time.Time) bool {
+	if b.ExpiresAt == "" {
+		return false
+	}
+	expires, err := time.Parse(time.RFC3339, b.ExpiresAt)
+	return err == nil && !now.Before(expires)
+}
+
+// normalize prüft die Regel und bringt den Wert in eine vergleichbare Form
+func (b *BanRule) normalize() error {
+	b.Value = strings.TrimSpace(b.Value)
+	if b.Value == "" {
+		return fmt.Errorf("%w: value fehlt", ErrInvalidBan)
+	}
+
+	switch b.Type {
+	case BanIP:
+		ip := net.ParseIP(b.Value)
+		if ip == nil {
+			return fmt.Errorf("%w: %q ist keine IP-Adresse", ErrInvalidBan, b.Value)
+		}
+		b.Value = ip.String()
+	case BanCIDR:
+		_, ipNet, err := net.ParseCIDR(b.Value)
+		if err != nil {
+			return fmt.Errorf("%w: %q ist kein CIDR-Netz", ErrInvalidBan, b.Value)
+		}
+		b.Value = ipNet.String()
+	case BanEmail:
+		b.Value = strings.ToLower(b.Value)
+		if !strings.Contains(b.Value, "@") {
+			return fmt.Errorf("%w: %q ist keine E-Mail-Adresse", ErrInvalidBan, b.Value)
+		}
+	case BanEmailDomain:
+		b.Value = strings.TrimPrefix(strings.ToLower(b.Value), "@")
+	case BanUsername:
+		if len(b.Value) > 200 {
+			return fmt.Errorf("%w: ausdruck zu lang", ErrInvalidBan)
+		}
+		if _, err := regexp.Compile(b.Value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBan, err)
+		}
+	default:
+		return fmt.Errorf("%w: unbekannter typ %q", ErrInvalidBan, b.Type)
+	}
+
+	if b.Action == "" {
+		b.Action = BanReject
+	}
+	if _, ok := banPriority[b.Action]; !ok {
+		return fmt.Errorf("%w: unbekannte aktion %q", ErrInvalidBan, b.Action)
+	}
+
+	if b.ExpiresAt != "" {
+		expires, err := time.Parse(time.RFC3339, b.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("%w: expires_at muss RFC3339 sein", ErrInvalidBan)
+		}
+		b.ExpiresAt = expires.UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+// BanList prüft neue Kommentare gegen die gespeicherten Sperrregeln
+type BanList struct {
+	store BanStore
+
+	mutex   sync.Mutex
+	regexps map[string]*regexp.Regexp // kompilierte Benutzernamen-Ausdrücke
+}
+
+// NewBanList erstellt eine BanList auf Basis des Comment-Stores.
+// Liefert nil, wenn das Backend keine Sperrregeln speichern kann.
+func NewBanList(store CommentStore) *BanList {
+	banStore, ok := store.(BanStore)
+	if !ok {
+		return nil
+	}
+	return &BanList{store: banStore, regexps: make(map[string]*regexp.Regexp)}
+}
+
+// List liefert alle Regeln inkl. abgelaufener (Expired gesetzt)
+func (l *BanList) List() ([]*BanRule, error) {
+	if l == nil {
+		return nil, ErrBansUnsupported
+	}
+	rules, err := l.store.ListBans()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		rule.Expired = rule.expiredAt(now)
+	}
+	return rules, nil
+}
+
+// Get liefert eine Regel
+func (l *BanList) Get(id int) (*BanRule, error) {
+	if l == nil {
+		return nil, ErrBansUnsupported
+	}
+	rule, err := l.store.GetBan(id)
+	if err != nil {
+		return nil, err
+	}
+	rule.Expired = rule.expiredAt(time.Now())
+	return rule, nil
+}
+
+// Create prüft und speichert eine neue Regel
+func (l *BanList) Create(rule *BanRule) error {
+	if l == nil {
+		return ErrBansUnsupported
+	}
+	if err := rule.normalize(); err != nil {
+		return err
+	}
+	rule.CreatedAt = time.Now().Format(time.RFC3339)
+	if err := l.store.CreateBan(rule); err != nil {
+		return err
+	}
+	rule.Expired = rule.expiredAt(time.Now())
+	log.Printf("🚫 Sperrregel #%d angelegt: %s %s (%s)", rule.ID, rule.Type, rule.Value, rule.Action)
+	return nil
+}
+
+// Update prüft und überschreibt eine bestehende Regel (created_at bleibt erhalten)
+func (l *BanList) Update(rule *BanRule) error {
+	if l == nil {
+		return ErrBansUnsupported
+	}
+	existing, err := l.store.GetBan(rule.ID)
+	if err != nil {
+		return err
+	}
+	if err := rule.normalize(); err != nil {
+		return err
+	}
+	rule.CreatedAt = existing.CreatedAt
+	if err := l.store.UpdateBan(rule); err != nil {
+		return err
+	}
+	rule.Expired = rule.expiredAt(time.Now())
+	return nil
+}
+
+// Delete entfernt eine Regel
+func (l *BanList) Delete(id int) error {
+	if l == nil {
+		return ErrBansUnsupported
+	}
+	return l.store.DeleteBan(id)
+}
+
+// usernamePattern liefert den kompilierten Ausdruck (gecacht)
+func (l *BanList) usernamePattern(pattern string) *regexp.Regexp {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	re, ok := l.regexps[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		l.regexps[pattern] = re
+	}
+	return re
+}
+
+// matches prüft eine einzelne Regel
+func (l *BanList) matches(rule *BanRule, ip net.IP, mail, username string) bool {
+	switch rule.Type {
+	case BanIP:
+		return ip != nil && ip.Equal(net.ParseIP(rule.Value))
+	case BanCIDR:
+		_, ipNet, err := net.ParseCIDR(rule.Value)
+		return err == nil && ip != nil && ipNet.Contains(ip)
+	case BanEmail:
+		return mail == rule.Value
+	case BanEmailDomain:
+		at := strings.LastIndex(mail, "@")
+		if at < 0 {
+			return false
+		}
+		domain := mail[at+1:]
+		return domain == rule.Value || strings.HasSuffix(domain, "."+rule.Value)
+	case BanUsername:
+		re := l.usernamePattern(rule.Value)
+		return re != nil && re.MatchString(username)
+	}
+	return false
+}
+
+// Match liefert die greifende Sperrregel (reject vor shadow vor spam) oder nil.
+// Ist die Sperrliste nicht lesbar, wird der Kommentar durchgelassen (fail open).
+func (l *BanList) Match(ip, mailAddress, username string) *BanRule {
+	if l == nil {
+		return nil
+	}
+
+	rules, err := l.store.ListBans()
+	if err != nil {
+		log.Printf("⚠️  Sperrliste nicht lesbar: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	parsedIP := net.ParseIP(ip)
+	mail := strings.ToLower(strings.TrimSpace(mailAddress))
+
+	var match *BanRule
+	for _, rule := range rules {
+		if rule.expiredAt(now) || !l.matches(rule, parsedIP, mail, username) {
+			continue
+		}
+		if match == nil || banPriority[rule.Action] > banPriority[match.Action] {
+			match = rule
+		}
+	}
+	return match
+}
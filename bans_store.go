@@ -0,0 +1,266 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Sperrregeln werden im selben Backend wie die Kommentare gespeichert
+
+// banKey liefert den Hash-Key einer Sperrregel
+func banKey(id int) string {
+	return fmt.Sprintf("ban:%d", id)
+}
+
+// banToHash wandelt eine Sperrregel in Hash-Felder um
+func banToHash(b *BanRule) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       string(b.Type),
+		"value":      b.Value,
+		"action":     string(b.Action),
+		"reason":     b.Reason,
+		"created_at": b.CreatedAt,
+		"expires_at": b.ExpiresAt,
+	}
+}
+
+// ListBans liefert alle Sperrregeln aus dem Set bans:index
+func (s *ValkeyStore) ListBans() ([]*BanRule, error) {
+	ids, err := s.client.SMembers(s.ctx, "bans:index").Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Sperrliste: %w", err)
+	}
+
+	rules := make([]*BanRule, 0, len(ids))
+	for _, value := range ids {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		rule, err := s.GetBan(id)
+		if errors.Is(err, ErrBanNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+// GetBan holt eine Sperrregel anhand der ID
+func (s *ValkeyStore) GetBan(id int) (*BanRule, error) {
+	fields, err := s.client.HGetAll(s.ctx, banKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Sperrregel %d: %w", id, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("sperrregel %d: %w", id, ErrBanNotFound)
+	}
+
+	return &BanRule{
+		ID:        id,
+		Type:      BanType(fields["type"]),
+		Value:     fields["value"],
+		Action:    BanAction(fields["action"]),
+		Reason:    fields["reason"],
+		CreatedAt: fields["created_at"],
+		ExpiresAt: fields["expires_at"],
+	}, nil
+}
+
+// CreateBan vergibt eine ID (ban_counter) und speichert die Regel
+func (s *ValkeyStore) CreateBan(rule *BanRule) error {
+	id, err := s.client.Incr(s.ctx, "ban_counter").Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Generieren der ID: %w", err)
+	}
+	rule.ID = int(id)
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.ctx, banKey(rule.ID), banToHash(rule))
+	pipe.SAdd(s.ctx, "bans:index", rule.ID)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern der Sperrregel: %w", err)
+	}
+	return nil
+}
+
+// UpdateBan überschreibt eine bestehende Regel
+func (s *ValkeyStore) UpdateBan(rule *BanRule) error {
+	if _, err := s.GetBan(rule.ID); err != nil {
+		return err
+	}
+	if err := s.client.HSet(s.ctx, banKey(rule.ID), banToHash(rule)).Err(); err != nil {
+		return fmt.Errorf("fehler beim Speichern der Sperrregel: %w", err)
+	}
+	return nil
+}
+
+// DeleteBan entfernt eine Regel
+func (s *ValkeyStore) DeleteBan(id int) error {
+	deleted, err := s.client.Del(s.ctx, banKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen der Sperrregel: %w", err)
+	}
+	s.client.SRem(s.ctx, "bans:index", id)
+	if deleted == 0 {
+		return fmt.Errorf("sperrregel %d: %w", id, ErrBanNotFound)
+	}
+	return nil
+}
+
+// banColumns ist die Spaltenliste für scanBan
+const banColumns = "id, type, value, action, reason, created_at, expires_at"
+
+// scanBan liest eine Zeile mit banColumns
+func scanBan(row rowScanner) (*BanRule, error) {
+	var rule BanRule
+	var banType, action string
+	if err := row.Scan(&rule.ID, &banType, &rule.Value, &action, &rule.Reason, &rule.CreatedAt, &rule.ExpiresAt); err != nil {
+		return nil, err
+	}
+	rule.Type = BanType(banType)
+	rule.Action = BanAction(action)
+	return &rule, nil
+}
+
+// ListBans liefert alle Sperrregeln
+func (s *SQLStore) ListBans() ([]*BanRule, error) {
+	rows, err := s.db.Query("SELECT " + banColumns + " FROM ban_rules ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Sperrliste: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*BanRule
+	for rows.Next() {
+		rule, err := scanBan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen der Sperrliste: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetBan holt eine Sperrregel anhand der ID
+func (s *SQLStore) GetBan(id int) (*BanRule, error) {
+	rule, err := scanBan(s.db.QueryRow(s.rebind("SELECT "+banColumns+" FROM ban_rules WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("sperrregel %d: %w", id, ErrBanNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Sperrregel %d: %w", id, err)
+	}
+	return rule, nil
+}
+
+// CreateBan speichert eine neue Regel
+func (s *SQLStore) CreateBan(rule *BanRule) error {
+	err := s.db.QueryRow(s.rebind(`INSERT INTO ban_rules
+		(type, value, action, reason, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING id`),
+		string(rule.Type), rule.Value, string(rule.Action), rule.Reason, rule.CreatedAt, rule.ExpiresAt,
+	).Scan(&rule.ID)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern der Sperrregel: %w", err)
+	}
+	return nil
+}
+
+// UpdateBan überschreibt eine bestehende Regel
+func (s *SQLStore) UpdateBan(rule *BanRule) error {
+	result, err := s.db.Exec(s.rebind(`UPDATE ban_rules
+		SET type = ?, value = ?, action = ?, reason = ?, expires_at = ? WHERE id = ?`),
+		string(rule.Type), rule.Value, string(rule.Action), rule.Reason, rule.ExpiresAt, rule.ID)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern der Sperrregel: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("sperrregel %d: %w", rule.ID, ErrBanNotFound)
+	}
+	return nil
+}
+
+// DeleteBan entfernt eine Regel
+func (s *SQLStore) DeleteBan(id int) error {
+	result, err := s.db.Exec(s.rebind("DELETE FROM ban_rules WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen der Sperrregel: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("sperrregel %d: %w", id, ErrBanNotFound)
+	}
+	return nil
+}
+
+// ListBans liefert alle Sperrregeln
+func (s *MemoryStore) ListBans() ([]*BanRule, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rules := make([]*BanRule, 0, len(s.bans))
+	for _, rule := range s.bans {
+		clone := *rule
+		rules = append(rules, &clone)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+// GetBan holt eine Sperrregel anhand der ID
+func (s *MemoryStore) GetBan(id int) (*BanRule, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rule, ok := s.bans[id]
+	if !ok {
+		return nil, fmt.Errorf("sperrregel %d: %w", id, ErrBanNotFound)
+	}
+	clone := *rule
+	return &clone, nil
+}
+
+// CreateBan vergibt eine neue ID und speichert die Regel
+func (s *MemoryStore) CreateBan(rule *BanRule) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextBanID++
+	rule.ID = s.nextBanID
+	clone := *rule
+	s.bans[rule.ID] = &clone
+	return nil
+}
+
+// UpdateBan überschreibt eine bestehende Regel
+func (s *MemoryStore) UpdateBan(rule *BanRule) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.bans[rule.ID]; !ok {
+		return fmt.Errorf("sperrregel %d: %w", rule.ID, ErrBanNotFound)
+	}
+	clone := *rule
+	s.bans[rule.ID] = &clone
+	return nil
+}
+
+// DeleteBan entfernt eine Regel
+func (s *MemoryStore) DeleteBan(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.bans[id]; !ok {
+		return fmt.Errorf("sperrregel %d: %w", id, ErrBanNotFound)
+	}
+	delete(s.bans, id)
+	return nil
+}
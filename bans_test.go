@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBanList legt die Regeln in einem MemoryStore an
+func newTestBanList(t *testing.T, rules ...*BanRule) *BanList {
+	t.Helper()
+	bans := NewBanList(NewMemoryStore())
+	if bans == nil {
+		t.Fatal("MemoryStore unterstützt keine Sperrregeln")
+	}
+	for _, rule := range rules {
+		if err := bans.Create(rule); err != nil {
+			t.Fatalf("Create(%s %s): %v", rule.Type, rule.Value, err)
+		}
+	}
+	return bans
+}
+
+func TestBanListMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     BanRule
+		ip       string
+		mail     string
+		username string
+		want     bool
+	}{
+		{"IP gleich", BanRule{Type: BanIP, Value: "203.0.113.7"}, "203.0.113.7", "", "", true},
+		{"IP andere", BanRule{Type: BanIP, Value: "203.0.113.7"}, "203.0.113.8", "", "", false},
+		{"IPv6 andere Schreibweise", BanRule{Type: BanIP, Value: "2001:db8::1"}, "2001:0db8:0:0::1", "", "", true},
+		{"IP ohne Client-IP", BanRule{Type: BanIP, Value: "203.0.113.7"}, "", "", "", false},
+		{"CIDR im Netz", BanRule{Type: BanCIDR, Value: "203.0.113.0/24"}, "203.0.113.200", "", "", true},
+		{"CIDR außerhalb", BanRule{Type: BanCIDR, Value: "203.0.113.0/24"}, "203.0.114.1", "", "", false},
+		{"CIDR nicht normalisiert", BanRule{Type: BanCIDR, Value: "198.51.100.77/16"}, "198.51.3.4", "", "", true},
+		{"E-Mail Groß-/Kleinschreibung", BanRule{Type: BanEmail, Value: "Troll@Example.com"}, "", " troll@EXAMPLE.com ", "", true},
+		{"E-Mail andere", BanRule{Type: BanEmail, Value: "troll@example.com"}, "", "anna@example.com", "", false},
+		{"E-Mail-Domain", BanRule{Type: BanEmailDomain, Value: "@spam.example"}, "", "bot@spam.example", "", true},
+		{"E-Mail-Domain Subdomain", BanRule{Type: BanEmailDomain, Value: "spam.example"}, "", "bot@eu.spam.example", "", true},
+		{"E-Mail-Domain nur Endung", BanRule{Type: BanEmailDomain, Value: "spam.example"}, "", "bot@nospam.example", "", false},
+		{"Name per Ausdruck", BanRule{Type: BanUsername, Value: "(?i)^casino"}, "", "", "CASINO-Bot", true},
+		{"Name ohne Treffer", BanRule{Type: BanUsername, Value: "(?i)^casino"}, "", "", "Anna", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			bans := newTestBanList(t, &rule)
+			if got := bans.Match(tt.ip, tt.mail, tt.username); (got != nil) != tt.want {
+				t.Errorf("Match = %+v, erwartet Treffer: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBanListMatchPriorityAndExpiry(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	bans := newTestBanList(t,
+		&BanRule{Type: BanEmailDomain, Value: "example.com", Action: BanSpam},
+		&BanRule{Type: BanUsername, Value: "Troll", Action: BanShadow},
+		&BanRule{Type: BanIP, Value: "203.0.113.7", Action: BanReject, ExpiresAt: past},
+		&BanRule{Type: BanCIDR, Value: "198.51.100.0/24", ExpiresAt: future},
+	)
+
+	tests := []struct {
+		name     string
+		ip       string
+		username string
+		want     BanAction
+	}{
+		{"nur Domain", "192.0.2.1", "Anna", BanSpam},
+		{"shadow vor spam", "192.0.2.1", "Troll", BanShadow},
+		{"abgelaufene Regel greift nicht", "203.0.113.7", "Troll", BanShadow},
+		{"reject vor shadow (Default-Aktion)", "198.51.100.9", "Troll", BanReject},
+	}
+	for _, tt := range tests {
+		got := bans.Match(tt.ip, "anna@example.com", tt.username)
+		if got == nil || got.Action != tt.want {
+			t.Errorf("%s: Match = %+v, erwartet %s", tt.name, got, tt.want)
+		}
+	}
+	if got := bans.Match("192.0.2.1", "anna@other.example", "Anna"); got != nil {
+		t.Errorf("Match ohne Treffer = %+v", got)
+	}
+}
+
+func TestBanRuleNormalize(t *testing.T) {
+	invalid := []BanRule{
+		{Type: BanIP, Value: "203.0.113"},
+		{Type: BanCIDR, Value: "203.0.113.0/33"},
+		{Type: BanEmail, Value: "kein-at"},
+		{Type: BanUsername, Value: "("},
+		{Type: "host", Value: "example.com"},
+		{Type: BanIP, Value: " "},
+		{Type: BanIP, Value: "203.0.113.7", Action: "delete"},
+		{Type: BanIP, Value: "203.0.113.7", ExpiresAt: "morgen"},
+	}
+	for _, rule := range invalid {
+		if err := rule.normalize(); !errors.Is(err, ErrInvalidBan) {
+			t.Errorf("normalize(%+v) = %v, erwartet ErrInvalidBan", rule, err)
+		}
+	}
+
+	var disabled *BanList
+	if disabled.Match("203.0.113.7", "", "") != nil {
+		t.Error("ohne Sperrliste ein Treffer")
+	}
+	if err := disabled.Create(&BanRule{Type: BanIP, Value: "203.0.113.7"}); err != ErrBansUnsupported {
+		t.Errorf("Create ohne Sperrliste: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// akismet-fake ist ein lokaler Ersatz für den Akismet-Dienst zum Testen.
+//
+// Spam ist, was die Testwerte von Akismet verwenden: Autor "viagra-test-123",
+// E-Mail "akismet-guaranteed-spam@example.com" oder ein Text mit
+// "akismet-guaranteed-spam". Der API-Key "invalid" gilt als ungültig.
+//
+//	go run ./cmd/akismet-fake -addr :8091
+//	AKISMET_KEY=test AKISMET_URL=http://localhost:8091/1.1 ./comment-system
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	spamAuthor  = "viagra-test-123"
+	spamEmail   = "akismet-guaranteed-spam@example.com"
+	spamContent = "akismet-guaranteed-spam"
+)
+
+// fakeAkismet zählt die Aufrufe pro Methode
+type fakeAkismet struct {
+	mutex sync.Mutex
+	calls map[string]int
+	delay time.Duration
+}
+
+// handle prüft den Key und ruft die eigentliche Methode auf
+func (f *fakeAkismet) handle(method string, fn func(r *http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "nur POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		f.mutex.Lock()
+		f.calls[method]++
+		count := f.calls[method]
+		f.mutex.Unlock()
+
+		if f.delay > 0 {
+			time.Sleep(f.delay)
+		}
+
+		key := r.PostForm.Get("api_key")
+		if key == "" {
+			key = r.PostForm.Get("key")
+		}
+
+		var answer string
+		switch {
+		case key == "":
+			w.Header().Set("X-akismet-debug-help", "Empty \"api_key\" value")
+			answer = "invalid"
+		case key == "invalid" && method != "verify-key":
+			w.Header().Set("X-akismet-debug-help", "Invalid API key")
+			answer = "invalid"
+		default:
+			answer = fn(r)
+		}
+
+		log.Printf("%s #%d autor=%q ip=%q -> %s", method, count,
+			r.PostForm.Get("comment_author"), r.PostForm.Get("user_ip"), answer)
+		fmt.Fprint(w, answer)
+	}
+}
+
+func (f *fakeAkismet) verifyKey(r *http.Request) string {
+	if r.PostForm.Get("key") == "invalid" {
+		return "invalid"
+	}
+	return "valid"
+}
+
+func (f *fakeAkismet) commentCheck(w http.ResponseWriter) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		form := r.PostForm
+		if form.Get("user_role") == "administrator" {
+			return "false"
+		}
+		if form.Get("comment_author") == spamAuthor {
+			w.Header().Set("X-akismet-pro-tip", "discard")
+			return "true"
+		}
+		if strings.EqualFold(form.Get("comment_author_email"), spamEmail) ||
+			strings.Contains(form.Get("comment_content"), spamContent) {
+			return "true"
+		}
+		return "false"
+	}
+}
+
+func (f *fakeAkismet) submit(r *http.Request) string {
+	return "Thanks for making the web a better place."
+}
+
+func main() {
+	addr := flag.String("addr", ":8091", "Listen-Adresse")
+	delay := flag.Duration("delay", 0, "künstliche Verzögerung pro Antwort (zum Testen von Timeouts)")
+	flag.Parse()
+
+	fake := &fakeAkismet{calls: make(map[string]int), delay: *delay}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/1.1/verify-key", fake.handle("verify-key", fake.verifyKey))
+	mux.HandleFunc("/1.1/comment-check", func(w http.ResponseWriter, r *http.Request) {
+		fake.handle("comment-check", fake.commentCheck(w))(w, r)
+	})
+	mux.HandleFunc("/1.1/submit-spam", fake.handle("submit-spam", fake.submit))
+	mux.HandleFunc("/1.1/submit-ham", fake.handle("submit-ham", fake.submit))
+
+	log.Printf("🧪 Akismet-Fake läuft auf %s/1.1", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
@@ -0,0 +1,183 @@
+// smtp-sink ist ein lokaler SMTP-Server, der alle E-Mails annimmt und ausgibt,
+// statt sie zuzustellen. Zum Testen der Moderator-Benachrichtigungen.
+//
+// Kein TLS: der Server bietet kein STARTTLS an, daher SMTP_STARTTLS=false.
+// AUTH PLAIN wird mit beliebigen Zugangsdaten akzeptiert.
+//
+//	go run ./cmd/smtp-sink -addr :2525 -dir /tmp/mails
+//	SMTP_HOST=localhost SMTP_PORT=2525 SMTP_STARTTLS=false \
+//	  SMTP_FROM=comments@example.com SMTP_TO=mod@example.com ./comment-system
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sink nimmt Verbindungen an und zählt die empfangenen E-Mails
+type sink struct {
+	dir   string // optional: jede E-Mail als .eml speichern
+	count atomic.Int64
+}
+
+// session verarbeitet eine SMTP-Verbindung
+func (s *sink) session(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Minute))
+
+	text := textproto.NewConn(conn)
+	reply := func(code int, msg string) {
+		text.PrintfLine("%d %s", code, msg)
+	}
+
+	reply(220, "smtp-sink bereit")
+	var from string
+	var to []string
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			text.PrintfLine("250-smtp-sink")
+			text.PrintfLine("250-8BITMIME")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "HELO":
+			reply(250, "smtp-sink")
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			if !strings.EqualFold(mechanism, "PLAIN") {
+				reply(504, "nur AUTH PLAIN")
+				continue
+			}
+			if initial == "" {
+				reply(334, "")
+				if initial, err = text.ReadLine(); err != nil {
+					return
+				}
+			}
+			// authzid \0 user \0 passwort
+			credentials, err := base64.StdEncoding.DecodeString(initial)
+			parts := bytes.Split(credentials, []byte{0})
+			if err != nil || len(parts) != 3 {
+				reply(501, "ungültige Zugangsdaten")
+				continue
+			}
+			log.Printf("🔑 AUTH PLAIN als %q", parts[1])
+			reply(235, "angemeldet")
+		case "MAIL":
+			// Parameter wie BODY=8BITMIME ignorieren
+			from, _, _ = strings.Cut(strings.TrimPrefix(arg, "FROM:"), " ")
+			to = nil
+			reply(250, "OK")
+		case "RCPT":
+			to = append(to, strings.TrimPrefix(arg, "TO:"))
+			reply(250, "OK")
+		case "DATA":
+			if from == "" || len(to) == 0 {
+				reply(503, "MAIL und RCPT zuerst")
+				continue
+			}
+			reply(354, "Ende mit <CRLF>.<CRLF>")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			s.receive(from, to, data)
+			from, to = "", nil
+			reply(250, "angenommen")
+		case "RSET":
+			from, to = "", nil
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "tschüss")
+			return
+		default:
+			reply(502, "nicht unterstützt")
+		}
+	}
+}
+
+// receive gibt eine E-Mail lesbar aus und speichert sie optional
+func (s *sink) receive(from string, to []string, data []byte) {
+	n := s.count.Add(1)
+	log.Printf("🧪 E-Mail #%d von %s an %s (%d Bytes)", n, from, strings.Join(to, ", "), len(data))
+
+	if s.dir != "" {
+		path := filepath.Join(s.dir, fmt.Sprintf("%s-%03d.eml", time.Now().Format("20060102-150405"), n))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			log.Printf("⚠️  %v", err)
+		} else {
+			log.Printf("💾 %s", path)
+		}
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("⚠️  E-Mail nicht lesbar: %v", err)
+		return
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	// net/mail dekodiert quoted-printable nicht selbst
+	var body io.Reader = msg.Body
+	if strings.EqualFold(msg.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(msg.Body)
+	}
+	text, err := io.ReadAll(body)
+	if err != nil {
+		log.Printf("⚠️  E-Mail-Text nicht lesbar: %v", err)
+	}
+
+	fmt.Printf("──── Betreff: %s\n%s\n────\n", subject, strings.ReplaceAll(string(text), "\r\n", "\n"))
+}
+
+func main() {
+	addr := flag.String("addr", ":2525", "Listen-Adresse")
+	dir := flag.String("dir", "", "Verzeichnis für empfangene E-Mails (.eml), leer = nur ausgeben")
+	flag.Parse()
+
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("🧪 smtp-sink auf %s", *addr)
+
+	s := &sink{dir: *dir}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+			continue
+		}
+		go s.session(conn)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Gründe, aus denen ein Formular verworfen wird
+var (
+	ErrFormHoneypot     = errors.New("honeypot ausgefüllt")
+	ErrFormTokenInvalid = errors.New("formular-token ungültig")
+	ErrFormTokenExpired = errors.New("formular-token abgelaufen")
+	ErrFormTooFast      = errors.New("formular zu schnell abgeschickt")
+	ErrFormTokenReused  = errors.New("formular-token bereits verwendet")
+)
+
+// FormGuard stellt signierte Formular-Tokens mit Zeitstempel aus und prüft
+// eingehende Kommentare auf Honeypot, Mindestdauer und Wiederverwendung
+type FormGuard struct {
+	secret []byte
+	minAge time.Duration
+	maxAge time.Duration
+	store  NonceStore
+}
+
+// NewFormGuard liest die Konfiguration aus der Umgebung.
+// Liefert nil, wenn FORM_GUARD_ENABLED=false.
+func NewFormGuard(store NonceStore) (*FormGuard, error) {
+	if !getEnvAsBool("FORM_GUARD_ENABLED", true) {
+		return nil, nil
+	}
+
+	secret := []byte(getEnv("FORM_TOKEN_SECRET", ""))
+	if len(secret) == 0 {
+		// Ohne festes Secret gelten Tokens nur für diese Instanz und bis zum Neustart
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("fehler beim Erzeugen des Formular-Secrets: %w", err)
+		}
+		log.Println("⚠️  FORM_TOKEN_SECRET nicht gesetzt, verwende zufälliges Secret (nur Einzelinstanz)")
+	}
+
+	return &FormGuard{
+		secret: secret,
+		minAge: getEnvAsDuration("FORM_MIN_AGE", 3*time.Second),
+		maxAge: getEnvAsDuration("FORM_TOKEN_MAX_AGE", 24*time.Hour),
+		store:  store,
+	}, nil
+}
+
+// derivedSecret liest das Secret key aus der Umgebung. Fehlt es, wird ein eigener
+// Schlüssel aus FORM_TOKEN_SECRET abgeleitet (HMAC mit purpose), damit Signaturen
+// eines Zwecks nie für einen anderen gelten; ohne beide ein zufälliger.
+func derivedSecret(key, purpose string) ([]byte, error) {
+	if secret := getEnv(key, ""); secret != "" {
+		return []byte(secret), nil
+	}
+
+	if base := getEnv("FORM_TOKEN_SECRET", ""); base != "" {
+		log.Printf("⚠️  %s nicht gesetzt, leite es aus FORM_TOKEN_SECRET ab", key)
+		mac := hmac.New(sha256.New, []byte(base))
+		mac.Write([]byte(purpose))
+		return mac.Sum(nil), nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("fehler beim Erzeugen von %s: %w", key, err)
+	}
+	log.Printf("⚠️  %s nicht gesetzt, verwende zufälliges Secret (nur Einzelinstanz)", key)
+	return secret, nil
+}
+
+// sign liefert die HMAC-Signatur über den Token-Inhalt
+func (g *FormGuard) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Issue erstellt ein neues Token: base64(nonce | ausgestellt) "." base64(signatur)
+func (g *FormGuard) Issue() (string, error) {
+	payload := make([]byte, 24)
+	if _, err := rand.Read(payload[:16]); err != nil {
+		return "", fmt.Errorf("fehler beim Erzeugen des Formular-Tokens: %w", err)
+	}
+	binary.BigEndian.PutUint64(payload[16:], uint64(time.Now().Unix()))
+
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(g.sign(payload)), nil
+}
+
+// Check prüft Honeypot und Token. Das Token wird dabei verbraucht.
+func (g *FormGuard) Check(token, honeypot string) error {
+	if g == nil {
+		return nil
+	}
+	if strings.TrimSpace(honeypot) != "" {
+		return ErrFormHoneypot
+	}
+
+	encoding := base64.RawURLEncoding
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return ErrFormTokenInvalid
+	}
+	payload, err := encoding.DecodeString(parts[0])
+	if err != nil || len(payload) != 24 {
+		return ErrFormTokenInvalid
+	}
+	signature, err := encoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, g.sign(payload)) {
+		return ErrFormTokenInvalid
+	}
+
+	age := time.Since(time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0))
+	if age > g.maxAge {
+		return ErrFormTokenExpired
+	}
+	if age < g.minAge {
+		return ErrFormTooFast
+	}
+
+	fresh, err := g.store.MarkUsed(encoding.EncodeToString(payload[:16]), g.maxAge)
+	if err != nil {
+		// Fail open wie beim Rate-Limiting, die übrigen Prüfungen greifen weiterhin
+		log.Printf("⚠️  Formular-Token nicht prüfbar: %v", err)
+		return nil
+	}
+	if !fresh {
+		return ErrFormTokenReused
+	}
+	return nil
+}
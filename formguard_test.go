@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingNonceStore simuliert einen nicht erreichbaren Store
+type failingNonceStore struct{}
+
+func (failingNonceStore) MarkUsed(string, time.Duration) (bool, error) {
+	return false, errors.New("store nicht erreichbar")
+}
+
+// newTestFormGuard baut einen FormGuard ohne Mindestdauer
+func newTestFormGuard(t *testing.T, store NonceStore) *FormGuard {
+	t.Helper()
+	t.Setenv("FORM_TOKEN_SECRET", "test-secret")
+	t.Setenv("FORM_MIN_AGE", "0s")
+	t.Setenv("FORM_TOKEN_MAX_AGE", "1h")
+	g, err := NewFormGuard(store)
+	if err != nil || g == nil {
+		t.Fatalf("NewFormGuard: %v, %v", g, err)
+	}
+	return g
+}
+
+// tokenIssuedAt signiert ein Token mit vorgegebenem Ausstellungszeitpunkt
+func tokenIssuedAt(t *testing.T, g *FormGuard, issued time.Time) string {
+	t.Helper()
+	payload := make([]byte, 24)
+	if _, err := rand.Read(payload[:16]); err != nil {
+		t.Fatal(err)
+	}
+	binary.BigEndian.PutUint64(payload[16:], uint64(issued.Unix()))
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(g.sign(payload))
+}
+
+func TestFormGuardCheck(t *testing.T) {
+	g := newTestFormGuard(t, NewMemoryNonceStore())
+	g.minAge = 3 * time.Second
+	valid := tokenIssuedAt(t, g, time.Now().Add(-time.Minute))
+	tampered := []byte(tokenIssuedAt(t, g, time.Now().Add(-time.Minute)))
+	tampered[3] ^= 1
+
+	other := newTestFormGuard(t, NewMemoryNonceStore())
+	other.secret = []byte("anderes-secret")
+
+	tests := []struct {
+		name     string
+		token    string
+		honeypot string
+		want     error
+	}{
+		{"gültig", valid, "", nil},
+		{"Honeypot ausgefüllt", tokenIssuedAt(t, g, time.Now().Add(-time.Minute)), "https://spam.example", ErrFormHoneypot},
+		{"Honeypot nur Leerzeichen", tokenIssuedAt(t, g, time.Now().Add(-time.Minute)), "  ", nil},
+		{"ohne Token", "", "", ErrFormTokenInvalid},
+		{"ohne Signatur", strings.SplitN(valid, ".", 2)[0], "", ErrFormTokenInvalid},
+		{"verändert", string(tampered), "", ErrFormTokenInvalid},
+		{"fremdes Secret", tokenIssuedAt(t, other, time.Now().Add(-time.Minute)), "", ErrFormTokenInvalid},
+		{"abgelaufen", tokenIssuedAt(t, g, time.Now().Add(-2*time.Hour)), "", ErrFormTokenExpired},
+		{"zu schnell", tokenIssuedAt(t, g, time.Now()), "", ErrFormTooFast},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := g.Check(tt.token, tt.honeypot); err != tt.want {
+				t.Errorf("Check = %v, erwartet %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormGuardReplay(t *testing.T) {
+	g := newTestFormGuard(t, NewMemoryNonceStore())
+	token, err := g.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(token, ""); err != nil {
+		t.Fatalf("erste Verwendung: %v", err)
+	}
+	if err := g.Check(token, ""); err != ErrFormTokenReused {
+		t.Fatalf("zweite Verwendung: %v, erwartet ErrFormTokenReused", err)
+	}
+
+	// Ein ausgefüllter Honeypot verbraucht das Token nicht
+	token, _ = g.Issue()
+	if err := g.Check(token, "x"); err != ErrFormHoneypot {
+		t.Fatalf("Honeypot: %v", err)
+	}
+	if err := g.Check(token, ""); err != nil {
+		t.Fatalf("nach Honeypot: %v", err)
+	}
+
+	// Ist der Store nicht erreichbar, wird nicht blockiert
+	g = newTestFormGuard(t, failingNonceStore{})
+	token, _ = g.Issue()
+	if err := g.Check(token, ""); err != nil {
+		t.Fatalf("Store-Fehler: %v, erwartet fail open", err)
+	}
+
+	var disabled *FormGuard
+	if err := disabled.Check("", "x"); err != nil {
+		t.Errorf("ohne FormGuard: %v", err)
+	}
+}
+
+func TestDerivedSecret(t *testing.T) {
+	t.Setenv("FORM_TOKEN_SECRET", "basis")
+
+	pow, err := derivedSecret("POW_SECRET", "pow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := derivedSecret("POW_SECRET", "pow")
+	if !bytes.Equal(pow, again) {
+		t.Error("abgeleiteter Schlüssel nicht stabil (Replicas müssen ihn teilen)")
+	}
+	if bytes.Equal(pow, []byte("basis")) {
+		t.Error("FORM_TOKEN_SECRET wird unverändert übernommen")
+	}
+	if other, _ := derivedSecret("COOKIE_SECRET", "own-comments"); bytes.Equal(pow, other) {
+		t.Error("gleicher Schlüssel für verschiedene Zwecke")
+	}
+
+	t.Setenv("POW_SECRET", "eigenes")
+	if explicit, _ := derivedSecret("POW_SECRET", "pow"); string(explicit) != "eigenes" {
+		t.Errorf("gesetztes Secret ignoriert: %q", explicit)
+	}
+
+	t.Setenv("POW_SECRET", "")
+	t.Setenv("FORM_TOKEN_SECRET", "")
+	first, _ := derivedSecret("POW_SECRET", "pow")
+	second, _ := derivedSecret("POW_SECRET", "pow")
+	if len(first) != 32 || bytes.Equal(first, second) {
+		t.Error("ohne Secrets kein zufälliger Schlüssel")
+	}
+}
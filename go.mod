@@ -4,6 +4,8 @@ go 1.25
 
 require (
 	github.com/gorilla/mux v1.8.0
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.33
 	github.com/redis/go-redis/v9 v9.17.3
 	github.com/rs/cors v1.11.1
 )
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OutboundMail ist eine E-Mail an einen Leser in der Warteschlange
+type OutboundMail struct {
+	To       string            `json:"to"`
+	Subject  string            `json:"subject"`
+	Body     string            `json:"body"`
+	Headers  map[string]string `json:"headers,omitempty"` // z.B. List-Unsubscribe
+	Attempts int               `json:"attempts,omitempty"`
+}
+
+// MailQueue ist die Warteschlange für ausgehende E-Mails (FIFO)
+type MailQueue interface {
+	Enqueue(mail *OutboundMail) error
+	// Requeue stellt eine E-Mail wieder an den Anfang (Rate-Limit, SMTP-Fehler)
+	Requeue(mail *OutboundMail) error
+	// Dequeue entnimmt die nächste E-Mail (nil = Warteschlange leer)
+	Dequeue() (*OutboundMail, error)
+}
+
+// ValkeyMailQueue hält die Warteschlange in der Liste mail:queue, damit
+// mehrere Replicas sie gemeinsam abarbeiten
+type ValkeyMailQueue struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewValkeyMailQueue erstellt eine ValkeyMailQueue
+func NewValkeyMailQueue(client *redis.Client) *ValkeyMailQueue {
+	return &ValkeyMailQueue{client: client, ctx: context.Background()}
+}
+
+// push hängt eine E-Mail hinten (RPUSH) bzw. vorne (LPUSH) an
+func (q *ValkeyMailQueue) push(mail *OutboundMail, front bool) error {
+	data, err := json.Marshal(mail)
+	if err != nil {
+		return fmt.Errorf("fehler beim Kodieren der E-Mail: %w", err)
+	}
+	if front {
+		err = q.client.LPush(q.ctx, "mail:queue", data).Err()
+	} else {
+		err = q.client.RPush(q.ctx, "mail:queue", data).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("fehler beim Einreihen der E-Mail: %w", err)
+	}
+	return nil
+}
+
+// Enqueue hängt eine E-Mail hinten an
+func (q *ValkeyMailQueue) Enqueue(mail *OutboundMail) error {
+	return q.push(mail, false)
+}
+
+// Requeue stellt eine E-Mail wieder an den Anfang
+func (q *ValkeyMailQueue) Requeue(mail *OutboundMail) error {
+	return q.push(mail, true)
+}
+
+// Dequeue entnimmt die nächste E-Mail per LPOP
+func (q *ValkeyMailQueue) Dequeue() (*OutboundMail, error) {
+	data, err := q.client.LPop(q.ctx, "mail:queue").Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Warteschlange: %w", err)
+	}
+
+	var mail OutboundMail
+	if err := json.Unmarshal(data, &mail); err != nil {
+		return nil, fmt.Errorf("fehler beim Dekodieren der E-Mail: %w", err)
+	}
+	return &mail, nil
+}
+
+// MemoryMailQueue hält die Warteschlange im Prozessspeicher (nur Einzelinstanz,
+// nicht versendete E-Mails gehen beim Neustart verloren)
+type MemoryMailQueue struct {
+	mutex sync.Mutex
+	mails []*OutboundMail
+}
+
+// NewMemoryMailQueue erstellt eine leere MemoryMailQueue
+func NewMemoryMailQueue() *MemoryMailQueue {
+	return &MemoryMailQueue{}
+}
+
+// Enqueue hängt eine E-Mail hinten an
+func (q *MemoryMailQueue) Enqueue(mail *OutboundMail) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.mails = append(q.mails, mail)
+	return nil
+}
+
+// Requeue stellt eine E-Mail wieder an den Anfang
+func (q *MemoryMailQueue) Requeue(mail *OutboundMail) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.mails = append([]*OutboundMail{mail}, q.mails...)
+	return nil
+}
+
+// Dequeue entnimmt die nächste E-Mail
+func (q *MemoryMailQueue) Dequeue() (*OutboundMail, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.mails) == 0 {
+		return nil, nil
+	}
+	mail := q.mails[0]
+	q.mails = q.mails[1:]
+	return mail, nil
+}
+
+// MailWorker verschickt die E-Mails aus der Warteschlange im Hintergrund,
+// begrenzt insgesamt und pro Empfänger
+type MailWorker struct {
+	mailer      *SMTPMailer
+	queue       MailQueue
+	limits      RateLimitStore
+	global      *RateLimitRule // nil = unbegrenzt
+	recipient   *RateLimitRule // nil = unbegrenzt, sonst werden überzählige E-Mails verworfen
+	interval    time.Duration
+	retryDelay  time.Duration
+	maxAttempts int
+}
+
+// NewMailWorker liest die Konfiguration aus der Umgebung
+func NewMailWorker(mailer *SMTPMailer, queue MailQueue, limits RateLimitStore) (*MailWorker, error) {
+	worker := &MailWorker{
+		mailer:      mailer,
+		queue:       queue,
+		limits:      limits,
+		interval:    getEnvAsDuration("MAIL_QUEUE_INTERVAL", 5*time.Second),
+		retryDelay:  getEnvAsDuration("MAIL_RETRY_DELAY", time.Minute),
+		maxAttempts: getEnvAsInt("MAIL_MAX_ATTEMPTS", 5),
+	}
+
+	var err error
+	if worker.global, err = parseRateLimitRule("mail", getEnv("MAIL_RATE_LIMIT", "30/1m")); err != nil {
+		return nil, err
+	}
+	if worker.recipient, err = parseRateLimitRule("mail-to", getEnv("MAIL_RATE_LIMIT_RECIPIENT", "10/1h")); err != nil {
+		return nil, err
+	}
+	return worker, nil
+}
+
+// Start arbeitet die Warteschlange im Intervall ab
+func (w *MailWorker) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if pause := w.processQueue(); pause > 0 {
+				time.Sleep(pause)
+			}
+		}
+	}()
+
+	log.Printf("📬 Mail-Warteschlange: Prüfung alle %v", w.interval)
+}
+
+// processQueue verschickt E-Mails, bis die Warteschlange leer oder das Limit
+// erreicht ist. Liefert eine Pause, wenn der SMTP-Server nicht erreichbar ist.
+func (w *MailWorker) processQueue() time.Duration {
+	for {
+		mail, err := w.queue.Dequeue()
+		if err != nil {
+			log.Printf("❌ Mail-Warteschlange: %v", err)
+			return 0
+		}
+		if mail == nil {
+			return 0
+		}
+
+		if w.global != nil {
+			allowed, _, err := w.limits.Take("mail:global", w.global.Capacity, w.global.Per)
+			if err != nil || !allowed {
+				w.requeue(mail)
+				return 0
+			}
+		}
+		if w.recipient != nil {
+			allowed, _, err := w.limits.Take("mail:to:"+hashRateLimitValue(normalizeEmail(mail.To)), w.recipient.Capacity, w.recipient.Per)
+			if err == nil && !allowed {
+				log.Printf("⚠️  E-Mail an Empfänger verworfen: Limit %d/%v erreicht", w.recipient.Capacity, w.recipient.Per)
+				continue
+			}
+		}
+
+		if err := w.mailer.Send([]string{mail.To}, mail.Subject, mail.Body, mail.Headers); err != nil {
+			mail.Attempts++
+			if mail.Attempts >= w.maxAttempts {
+				log.Printf("❌ E-Mail nach %d Versuchen verworfen: %v", mail.Attempts, err)
+				continue
+			}
+			log.Printf("⚠️  E-Mail nicht versendet (Versuch %d, neuer Versuch in %v): %v", mail.Attempts, w.retryDelay, err)
+			w.requeue(mail)
+			return w.retryDelay
+		}
+		log.Printf("📧 E-Mail versendet: %s", mail.Subject)
+	}
+}
+
+// requeue stellt eine E-Mail zurück, Fehler werden nur geloggt
+func (w *MailWorker) requeue(mail *OutboundMail) {
+	if err := w.queue.Requeue(mail); err != nil {
+		log.Printf("❌ E-Mail verloren: %v", err)
+	}
+}
@@ -1,57 +1,103 @@
 package main
 
 import (
-	"context"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"text/template"
 	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 )
 
 // Comment stellt einen Kommentar dar
 type Comment struct {
-	ID          int    `json:"id"`
-	PostID      string `json:"post_id"`
-	Username    string `json:"username"`
-	MailAddress string `json:"mailaddress"`
-	Text        string `json:"text"`
-	Active      bool   `json:"active"`
-	CreatedAt   string `json:"created_at"`
+	ID             int           `json:"id"`
+	PostID         string        `json:"post_id"`
+	ParentID       int           `json:"parent_id,omitempty"`
+	Username       string        `json:"username"`
+	MailAddress    string        `json:"mailaddress"`
+	Text           string        `json:"text"`
+	Active         bool          `json:"active"` // Kompatibilität: Status approved
+	Status         CommentStatus `json:"status"`
+	SpamScore      float64       `json:"spam_score"`
+	SpamReasons    []string      `json:"spam_reasons,omitempty"`
+	DecisionReason string        `json:"decision_reason,omitempty"` // Begründung einer automatischen Freigabe (Vertrauen)
+	EmailVerified  bool          `json:"email_verified,omitempty"`  // Absender hat die Adresse per Double-Opt-In nachgewiesen
+	IP             string        `json:"ip,omitempty"`
+	UserAgent      string        `json:"user_agent,omitempty"`
+	Shadowed       bool          `json:"shadowed,omitempty"` // Shadow-Ban: nur für den Absender sichtbar
+	CreatedAt      string        `json:"created_at"`
+	DeletedAt      string        `json:"deleted_at,omitempty"`   // Zeitpunkt des Verschiebens in den Papierkorb
+	DeletedFrom    CommentStatus `json:"deleted_from,omitempty"` // Status vor dem Papierkorb (für Wiederherstellen)
+	Children       []*Comment    `json:"children,omitempty"`
 }
 
-// CommentService verwaltet Kommentare in ValKey
+// CommentService enthält die Geschäftslogik für Kommentare, die Speicherung
+// übernimmt ein CommentStore (Valkey, Memory, ...)
 type CommentService struct {
-	client *redis.Client
-	ctx    context.Context
+	store         CommentStore
+	backend       string
+	maxReplyDepth int
+	// publicAvatarHash gibt in der öffentlichen API einen Avatar-Hash der E-Mail aus
+	publicAvatarHash bool
+	spam             *SpamPipeline  // nil = keine Spam-Prüfung
+	akismet          *AkismetClient // nil = ohne Akismet
+	bans             *BanList       // nil = Backend ohne Sperrlisten
+	reports          ReportStore    // nil = Backend ohne Meldungen
+	// reportThreshold blendet Kommentare ab so vielen Meldungen aus (0 = nie)
+	reportThreshold int
+	audit           AuditStore // nil = Backend ohne Audit-Log
+	trust           TrustStore // nil = Backend ohne Vertrauensdaten
+	// trustThreshold gibt Autoren ab so vielen Freigaben ohne Ablehnung direkt frei (0 = nur per Override)
+	trustThreshold int
+	subscriptions  *Subscriptions     // nil = keine Abos (ohne SMTP oder Backend ohne Abos)
+	webhooks       *WebhookDispatcher // nil = Backend ohne Webhooks
 }
 
+// Fehler bei der Validierung von Antworten
+var (
+	ErrParentNotFound   = errors.New("übergeordneter Kommentar nicht gefunden")
+	ErrParentOtherPost  = errors.New("übergeordneter Kommentar gehört zu einem anderen Post")
+	ErrParentInactive   = errors.New("übergeordneter Kommentar ist nicht aktiv")
+	ErrMaxDepthExceeded = errors.New("maximale Verschachtelungstiefe erreicht")
+)
+
 // AuthConfig hält die Authentifizierungskonfiguration
 type AuthConfig struct {
 	AdminToken string
-	Enabled    bool
+	// NamedTokens ordnet weitere Tokens einem Namen zu (ADMIN_TOKENS), der im Audit-Log erscheint
+	NamedTokens map[string]string
+	Enabled     bool
 }
 
 // Template-Daten Struktur
 type JSWidgetTemplateData struct {
-	ApiUrl  string
-	Version string
-	Stage   string
+	ApiUrl        string
+	Version       string
+	Stage         string
+	MaxReplyDepth int
+	FormToken     string // leer = Formular-Schutz deaktiviert
+	PowEnabled    bool
+	// SubscriptionsEnabled zeigt die Auswahl für E-Mail-Benachrichtigungen
+	SubscriptionsEnabled bool
+	// Credentials: Cookies cross-origin mitsenden (nur mit CORS_ALLOWED_ORIGINS)
+	Credentials bool
 }
 
 // Template Cache für bessere Performance
@@ -86,6 +132,24 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		switch strings.ToLower(value) {
@@ -111,9 +175,20 @@ func NewTokenAuth() *AuthConfig {
 
 	enabled := getEnv("AUTH_ENABLED", "true") == "true"
 
+	// ADMIN_TOKENS="anna:token1,ben:token2" – ein Token pro Moderator
+	namedTokens := make(map[string]string)
+	for _, pair := range strings.Split(getEnv("ADMIN_TOKENS", ""), ",") {
+		name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || name == "" || token == "" {
+			continue
+		}
+		namedTokens[name] = token
+	}
+
 	return &AuthConfig{
-		AdminToken: adminToken,
-		Enabled:    enabled,
+		AdminToken:  adminToken,
+		NamedTokens: namedTokens,
+		Enabled:     enabled,
 	}
 }
 
@@ -144,13 +219,14 @@ func (auth *AuthConfig) AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Token validieren (constant-time comparison gegen timing attacks)
-		if !auth.validateToken(token) {
+		name, ok := auth.validateToken(token)
+		if !ok {
 			respondWithError(w, http.StatusUnauthorized, "Invalid authentication token")
 			return
 		}
 
-		// Request durchlassen
-		next.ServeHTTP(w, r)
+		// Request mit Token-Namen für das Audit-Log durchlassen
+		next.ServeHTTP(w, withActor(r, name))
 	})
 }
 
@@ -178,9 +254,20 @@ func extractToken(r *http.Request) string {
 	return ""
 }
 
-// validateToken prüft den Token sicher
-func (auth *AuthConfig) validateToken(token string) bool {
-	return subtle.ConstantTimeCompare([]byte(token), []byte(auth.AdminToken)) == 1
+// validateToken prüft den Token sicher und liefert den Namen des Tokens
+// ("admin" für ADMIN_TOKEN)
+func (auth *AuthConfig) validateToken(token string) (string, bool) {
+	name, valid := "", false
+	if subtle.ConstantTimeCompare([]byte(token), []byte(auth.AdminToken)) == 1 {
+		name, valid = "admin", true
+	}
+	// Alle Tokens vergleichen, damit die Laufzeit nicht vom Treffer abhängt
+	for tokenName, named := range auth.NamedTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(named)) == 1 && !valid {
+			name, valid = tokenName, true
+		}
+	}
+	return name, valid
 }
 
 // respondWithError sendet eine JSON-Fehlerantwort
@@ -196,235 +283,456 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// NewCommentService erstellt einen neuen CommentService
-func NewCommentService(redisAddr, redisPassword string, redisDB int) *CommentService {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       redisDB,
-	})
+// NewCommentService erstellt einen neuen CommentService auf Basis eines Stores
+func NewCommentService(store CommentStore, backend string) *CommentService {
+	cs := &CommentService{
+		store:         store,
+		backend:       backend,
+		maxReplyDepth: getEnvAsInt("MAX_REPLY_DEPTH", 3),
+
+		publicAvatarHash: getEnvAsBool("PUBLIC_AVATAR_HASH", false),
+		spam:             NewSpamPipeline(store),
+		akismet:          NewAkismetClient(),
+		bans:             NewBanList(store),
+		reports:          reportStore(store),
+		reportThreshold:  getEnvAsInt("REPORT_THRESHOLD", 0),
+		audit:            auditStore(store),
+		trust:            trustStore(store),
+		trustThreshold:   getEnvAsInt("TRUST_THRESHOLD", 0),
+	}
 
-	return &CommentService{
-		client: rdb,
-		ctx:    context.Background(),
+	if cs.akismet != nil {
+		if err := cs.akismet.VerifyKey(); err != nil {
+			log.Printf("⚠️  Akismet: %v", err)
+		}
+		if cs.spam != nil {
+			cs.spam.AddCheck(&akismetCheck{client: cs.akismet, spamScore: getEnvAsFloat("AKISMET_SPAM_SCORE", 5)})
+		}
+		log.Printf("🛡️  Akismet aktiviert: %s", cs.akismet.baseURL)
 	}
+
+	return cs
 }
 
-// generateCommentID generiert eine neue Kommentar-ID
-func (cs *CommentService) generateCommentID() (int, error) {
-	id, err := cs.client.Incr(cs.ctx, "comment_counter").Result()
+// validateParent prüft, ob auf den Kommentar parentID geantwortet werden darf.
+// shadowed erlaubt Antworten auf Shadow-Ban-Kommentare (der Absender sieht sie ja).
+func (cs *CommentService) validateParent(postID string, parentID int, shadowed bool) error {
+	parent, err := cs.GetComment(parentID)
 	if err != nil {
-		return 0, err
+		return ErrParentNotFound
+	}
+	if parent.PostID != postID {
+		return ErrParentOtherPost
+	}
+	if !parent.Active && !(shadowed && parent.Shadowed && parent.Status == StatusSpam) {
+		return ErrParentInactive
+	}
+
+	// Tiefe des Elternkommentars bestimmen (Top-Level = 0)
+	depth := 0
+	current := parent
+	for current.ParentID != 0 && depth < cs.maxReplyDepth {
+		depth++
+		current, err = cs.GetComment(current.ParentID)
+		if err != nil {
+			break
+		}
+	}
+
+	if depth >= cs.maxReplyDepth {
+		return ErrMaxDepthExceeded
 	}
-	return int(id), nil
+	return nil
+}
+
+// CommentInput enthält die Daten eines neuen Kommentars inkl. Absender-Infos aus dem Request
+type CommentInput struct {
+	PostID      string
+	ParentID    int // 0 = Top-Level
+	Username    string
+	MailAddress string
+	Text        string
+	IP          string
+	UserAgent   string
+	Ban         *BanRule          // greifende Sperrregel mit Aktion spam oder shadow
+	Notify      SubscriptionScope // gewünschtes Abo des Autors
+	// EmailVerified: der Browser hat ein gültiges Verifizierungs-Cookie für MailAddress
+	EmailVerified bool
 }
 
 // CreateComment erstellt einen neuen Kommentar
-func (cs *CommentService) CreateComment(postID, username, mailAddress, text string) (*Comment, error) {
-	id, err := cs.generateCommentID()
-	if err != nil {
-		return nil, fmt.Errorf("fehler beim Generieren der ID: %w", err)
+func (cs *CommentService) CreateComment(input CommentInput) (*Comment, error) {
+	shadowed := input.Ban != nil && input.Ban.Action == BanShadow
+	if input.ParentID != 0 {
+		if err := cs.validateParent(input.PostID, input.ParentID, shadowed); err != nil {
+			return nil, err
+		}
 	}
 
 	comment := &Comment{
-		ID:          id,
-		PostID:      postID,
-		Username:    username,
-		MailAddress: mailAddress,
-		Text:        text,
-		Active:      false, // Standardmäßig aktiv
+		PostID:      input.PostID,
+		ParentID:    input.ParentID,
+		Username:    input.Username,
+		MailAddress: input.MailAddress,
+		Text:        input.Text,
+		IP:          input.IP,
+		UserAgent:   input.UserAgent,
+		Shadowed:    shadowed,
 		CreatedAt:   time.Now().Format(time.RFC3339),
+
+		EmailVerified: input.EmailVerified,
 	}
 
-	// Kommentar-Daten in ValKey speichern
-	pipe := cs.client.Pipeline()
-	pipe.Set(cs.ctx, fmt.Sprintf("comments/%d/post_id", id), postID, 0)
-	pipe.Set(cs.ctx, fmt.Sprintf("comments/%d/username", id), username, 0)
-	pipe.Set(cs.ctx, fmt.Sprintf("comments/%d/mailaddress", id), mailAddress, 0)
-	pipe.Set(cs.ctx, fmt.Sprintf("comments/%d/text", id), text, 0)
-	pipe.Set(cs.ctx, fmt.Sprintf("comments/%d/active", id), comment.Active, 0)
-	pipe.Set(cs.ctx, fmt.Sprintf("comments/%d/created_at", id), comment.CreatedAt, 0)
+	if input.Ban != nil {
+		// Gesperrte Absender landen ohne weitere Prüfung im Spam
+		comment.SpamReasons = []string{fmt.Sprintf("sperre: #%d %s %s", input.Ban.ID, input.Ban.Type, input.Ban.Value)}
+		comment.setStatus(StatusSpam)
+	} else {
+		// Spam-Pipeline bestimmt den Startstatus (ohne Pipeline: pending)
+		result := cs.spam.Evaluate(comment)
+		comment.SpamScore = result.Score
+		comment.SpamReasons = result.Reasons
+		comment.setStatus(result.Status)
+
+		// Bekannte Autoren überspringen die Warteschlange, Spam bleibt Spam
+		if comment.Status == StatusPending {
+			status, reason := cs.trustDecision(comment)
+			comment.setStatus(status)
+			comment.DecisionReason = reason
+		}
+	}
 
-	_, err = pipe.Exec(cs.ctx)
-	if err != nil {
-		return nil, fmt.Errorf("fehler beim Speichern des Kommentars: %w", err)
+	if err := cs.store.Create(comment); err != nil {
+		return nil, err
+	}
+	if comment.DecisionReason != "" {
+		log.Printf("🤝 Kommentar #%d direkt freigegeben (%s)", comment.ID, comment.DecisionReason)
 	}
+	cs.subscriptions.Subscribe(comment, input.Notify)
+	cs.subscriptions.CommentApproved(comment, "")
+	// Spam (auch Sperrliste und Shadow-Ban) meldet nur comment.spam, das Ziele eigens abonnieren
+	if comment.Status != StatusSpam {
+		cs.webhooks.Emit(EventCommentCreated, comment, "")
+	}
+	cs.webhooks.EmitStatus(comment, "")
 
 	return comment, nil
 }
 
 // GetComment holt einen Kommentar anhand der ID
 func (cs *CommentService) GetComment(id int) (*Comment, error) {
-	pipe := cs.client.Pipeline()
-	postIDCmd := pipe.Get(cs.ctx, fmt.Sprintf("comments/%d/post_id", id))
-	usernameCmd := pipe.Get(cs.ctx, fmt.Sprintf("comments/%d/username", id))
-	mailAddressCmd := pipe.Get(cs.ctx, fmt.Sprintf("comments/%d/mailaddress", id))
-	textCmd := pipe.Get(cs.ctx, fmt.Sprintf("comments/%d/text", id))
-	activeCmd := pipe.Get(cs.ctx, fmt.Sprintf("comments/%d/active", id))
-	createdAtCmd := pipe.Get(cs.ctx, fmt.Sprintf("comments/%d/created_at", id))
+	return cs.store.Get(id)
+}
 
-	_, err := pipe.Exec(cs.ctx)
+// UpdateCommentStatus setzt den Moderationsstatus, sofern der Wechsel erlaubt ist
+func (cs *CommentService) UpdateCommentStatus(id int, status CommentStatus, by Moderator) (*Comment, error) {
+	comment, err := cs.store.Get(id)
 	if err != nil {
-		return nil, fmt.Errorf("kommentar nicht gefunden: %w", err)
+		return nil, err
 	}
 
-	postID, _ := postIDCmd.Result()
-	username, _ := usernameCmd.Result()
-	mailAddress, _ := mailAddressCmd.Result()
-	text, _ := textCmd.Result()
-	activeStr, _ := activeCmd.Result()
-	createdAt, _ := createdAtCmd.Result()
+	if !canTransition(comment.Status, status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, comment.Status, status)
+	}
+	if comment.Status == status {
+		return comment, nil
+	}
 
-	active := activeStr == "true"
+	op := newModerationOp(comment, status, time.Now())
+	if err := cs.store.Moderate([]ModerationOp{op}); err != nil {
+		return nil, err
+	}
 
-	return &Comment{
-		ID:          id,
-		PostID:      postID,
-		Username:    username,
-		MailAddress: mailAddress,
-		Text:        text,
-		Active:      active,
-		CreatedAt:   createdAt,
-	}, nil
+	cs.afterModeration(op, by)
+	return op.Comment, nil
 }
 
-// UpdateCommentStatus aktualisiert den Active-Status eines Kommentars
-func (cs *CommentService) UpdateCommentStatus(id int, active bool) error {
-	activeStr := "false"
-	if active {
-		activeStr = "true"
+// afterModeration führt nach einem gespeicherten Statuswechsel Audit-Log,
+// Vertrauen, Akismet, Abos, Webhooks und Meldungen nach
+func (cs *CommentService) afterModeration(op ModerationOp, by Moderator) {
+	status := op.Comment.Status
+	action, previous := AuditStatus, op.Previous
+	if op.Previous == StatusDeleted {
+		// Aus dem Papierkorb geht es nur zurück in den Status davor: Vertrauen,
+		// Akismet, Abos und Meldungen haben den schon gesehen
+		action, previous = AuditRestore, status
 	}
-
-	err := cs.client.Set(cs.ctx, fmt.Sprintf("comments/%d/active", id), activeStr, 0).Err()
-	if err != nil {
-		return fmt.Errorf("fehler beim Aktualisieren des Status: %w", err)
+	cs.recordAudit(action, op.Comment, op.Previous, status, by)
+	cs.trackTrust(op.Comment, previous)
+	cs.akismet.ReportModeration(op.Comment, previous, status)
+	cs.subscriptions.CommentApproved(op.Comment, previous)
+	cs.webhooks.EmitStatus(op.Comment, op.Previous)
+
+	// Nach der Freigabe durch einen Moderator sind die Meldungen erledigt,
+	// sonst blendet die nächste Meldung den Kommentar gleich wieder aus
+	if status == StatusApproved && previous != StatusApproved && cs.reports != nil {
+		if err := cs.reports.DeleteCommentReports(op.Comment.ID); err != nil {
+			log.Printf("⚠️  Meldungen zu Kommentar #%d nicht verworfen: %v", op.Comment.ID, err)
+		}
 	}
+}
 
-	return nil
+// bulkActions übersetzt die Aktionen von POST /api/comments/bulk in Status
+// (delete verschiebt in den Papierkorb)
+var bulkActions = map[string]CommentStatus{
+	"approve": StatusApproved,
+	"reject":  StatusRejected,
+	"spam":    StatusSpam,
+	"delete":  StatusDeleted,
 }
 
-// GetCommentsByPostID holt alle Kommentare für einen bestimmten Blog-Post
-// GetCommentsByPostID holt alle Kommentare für einen bestimmten Blog-Post
-func (cs *CommentService) GetCommentsByPostID(postID string, includeInactive bool) ([]*Comment, error) {
-	log.Printf("Suche Kommentare für PostID: '%s'", postID)
+// maxBulkItems begrenzt die Anzahl der IDs pro Bulk-Request
+const maxBulkItems = 500
 
-	// Alle username Keys finden (als Indikator für existierende Kommentare)
-	keys, err := cs.client.Keys(cs.ctx, "comments/*/username").Result()
-	if err != nil {
-		return nil, fmt.Errorf("fehler beim Abrufen der Kommentar-Keys: %w", err)
-	}
+// BulkResult ist das Ergebnis der Bulk-Moderation für einen Kommentar
+type BulkResult struct {
+	ID     int           `json:"id"`
+	OK     bool          `json:"ok"`
+	Status CommentStatus `json:"status,omitempty"` // neuer bzw. unveränderter Status
+	Error  string        `json:"error,omitempty"`
+}
 
-	log.Printf("Gefundene Kommentar-Keys: %v", keys)
+// maxBulkAttempts begrenzt die Versuche, wenn parallel moderiert wurde
+const maxBulkAttempts = 3
 
-	var comments []*Comment
-	for _, key := range keys {
-		// ID aus dem Key extrahieren (comments/9/username -> 9)
-		parts := strings.Split(key, "/")
-		if len(parts) >= 2 {
-			idStr := parts[1]
-			id, err := strconv.Atoi(idStr)
-			if err != nil {
-				log.Printf("Fehler beim Parsen der ID %s: %v", idStr, err)
-				continue
-			}
+// BulkModerate setzt den Status mehrerer Kommentare. Unbekannte IDs und unerlaubte
+// Wechsel werden pro Eintrag gemeldet, alle übrigen Wechsel atomar gespeichert.
+// Hat parallel jemand einen der Kommentare moderiert, wird neu geladen und neu geprüft.
+func (cs *CommentService) BulkModerate(ids []int, status CommentStatus, by Moderator) ([]BulkResult, error) {
+	for attempt := 1; ; attempt++ {
+		results, ops, err := cs.planBulkModeration(ids, status)
+		if err != nil {
+			return nil, err
+		}
+		if len(ops) == 0 {
+			return results, nil
+		}
 
-			// Post-ID für diesen Kommentar abrufen
-			postIDKey := fmt.Sprintf("comments/%d/post_id", id)
-			storedPostID, err := cs.client.Get(cs.ctx, postIDKey).Result()
-			if err != nil {
-				log.Printf("Fehler beim Abrufen der PostID für Kommentar %d: %v", id, err)
-				continue
-			}
+		err = cs.store.Moderate(ops)
+		if errors.Is(err, ErrModerationConflict) && attempt < maxBulkAttempts {
+			log.Printf("🔁 Bulk-Moderation: Kommentare zwischenzeitlich geändert, lade neu (Versuch %d)", attempt+1)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("📦 Bulk-Moderation: %d Kommentar(e) -> %s", len(ops), status)
 
-			log.Printf("Kommentar %d: Gespeicherte PostID='%s', Gesuchte PostID='%s'", id, storedPostID, postID)
+		for _, op := range ops {
+			cs.afterModeration(op, by)
+		}
+		return results, nil
+	}
+}
 
-			// Nur Kommentare für die gewünschte PostID
-			if storedPostID == postID {
-				comment, err := cs.GetComment(id)
-				if err != nil {
-					log.Printf("Fehler beim Laden des Kommentars %d: %v", id, err)
-					continue
-				}
+// planBulkModeration lädt die Kommentare und berechnet Ergebnisse und Statuswechsel
+func (cs *CommentService) planBulkModeration(ids []int, status CommentStatus) ([]BulkResult, []ModerationOp, error) {
+	results := make([]BulkResult, 0, len(ids))
+	var ops []ModerationOp
+	seen := make(map[int]bool, len(ids))
+	now := time.Now()
 
-				// Nur aktive Kommentare einschließen, außer explizit anders gewünscht
-				if comment.Active || includeInactive {
-					comments = append(comments, comment)
-				}
-			}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		comment, err := cs.store.Get(id)
+		if errors.Is(err, ErrCommentNotFound) {
+			results = append(results, BulkResult{ID: id, Error: "kommentar nicht gefunden"})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if !canTransition(comment.Status, status) {
+			results = append(results, BulkResult{
+				ID:     id,
+				Status: comment.Status,
+				Error:  fmt.Sprintf("%v: %s -> %s", ErrInvalidTransition, comment.Status, status),
+			})
+			continue
+		}
+
+		results = append(results, BulkResult{ID: id, OK: true, Status: status})
+		if comment.Status != status {
+			ops = append(ops, newModerationOp(comment, status, now))
 		}
 	}
+	return results, ops, nil
+}
 
-	log.Printf("Gefundene Kommentare für PostID '%s': %d", postID, len(comments))
-	return comments, nil
+// ListCommentsByPostID holt eine Seite von Kommentaren eines Blog-Posts
+func (cs *CommentService) ListCommentsByPostID(postID string, includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	return cs.store.ListByPost(postID, includeInactive, q)
+}
+
+// ListAllComments holt eine Seite aller Kommentare
+func (cs *CommentService) ListAllComments(includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	return cs.store.ListAll(includeInactive, q)
+}
+
+// GetCommentsByPostID holt alle Kommentare für einen bestimmten Blog-Post
+func (cs *CommentService) GetCommentsByPostID(postID string, includeInactive bool) ([]*Comment, error) {
+	page, err := cs.store.ListByPost(postID, includeInactive, CommentQuery{Sort: SortOldest})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
 }
 
 // GetAllComments holt alle Kommentare (nur aktive standardmäßig)
 func (cs *CommentService) GetAllComments(includeInactive bool) ([]*Comment, error) {
-	// Alle Kommentar-Keys finden
-	keys, err := cs.client.Keys(cs.ctx, "comments/*/username").Result()
+	page, err := cs.store.ListAll(includeInactive, CommentQuery{Sort: SortOldest})
 	if err != nil {
-		return nil, fmt.Errorf("fehler beim Abrufen der Kommentar-Keys: %w", err)
+		return nil, err
 	}
+	return page.Items, nil
+}
 
-	var comments []*Comment
-	for _, key := range keys {
-		// ID aus dem Key extrahieren
-		parts := strings.Split(key, "/")
-		if len(parts) >= 2 {
-			idStr := parts[1]
-			id, err := strconv.Atoi(idStr)
-			if err != nil {
-				continue
-			}
+// CountComments liefert die Anzahl gespeicherter Kommentare
+func (cs *CommentService) CountComments() (CommentCounts, error) {
+	return cs.store.Counts()
+}
 
-			comment, err := cs.GetComment(id)
-			if err != nil {
-				continue
-			}
+// dependencyName liefert den Namen des Backends für Health-Checks
+// ("redis" für Valkey, damit bestehendes Monitoring weiter funktioniert)
+func (cs *CommentService) dependencyName() string {
+	if cs.backend == BackendValkey {
+		return "redis"
+	}
+	return cs.backend
+}
+
+// parseCommentQuery liest limit, sort, status, cursor, before und after aus der URL
+func parseCommentQuery(r *http.Request) (CommentQuery, error) {
+	values := r.URL.Query()
+	q := CommentQuery{Limit: defaultPageLimit, Sort: SortNewest}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return q, fmt.Errorf("limit muss zwischen 1 und %d liegen", maxPageLimit)
+		}
+		q.Limit = n
+	}
+
+	switch sort := values.Get("sort"); sort {
+	case "":
+	case SortNewest, SortOldest:
+		q.Sort = sort
+	default:
+		return q, fmt.Errorf("sort muss newest oder oldest sein")
+	}
 
-			// Nur aktive Kommentare einschließen, außer explizit anders gewünscht
-			if comment.Active || includeInactive {
-				comments = append(comments, comment)
+	if status := values.Get("status"); status != "" {
+		parsed, err := parseCommentStatus(status)
+		if err != nil {
+			return q, fmt.Errorf("status muss pending, approved, rejected, spam oder deleted sein")
+		}
+		q.Status = parsed
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		c, err := decodePageCursor(cursor)
+		if err != nil {
+			return q, err
+		}
+		q.Cursor = c
+	}
+
+	for param, target := range map[string]*time.Time{"before": &q.Before, "after": &q.After} {
+		if value := values.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return q, fmt.Errorf("%s muss ein RFC3339-Zeitstempel sein", param)
 			}
+			*target = t
 		}
 	}
 
-	return comments, nil
+	// Der Baum wird vollständig geladen, ohne Post wäre das die ganze Datenbank
+	if values.Get("format") == "tree" && values.Get("post_id") == "" {
+		return q, fmt.Errorf("format=tree erfordert post_id")
+	}
+
+	return q, nil
 }
 
-// DeleteComment löscht einen Kommentar
-func (cs *CommentService) DeleteComment(id int) error {
-	pipe := cs.client.Pipeline()
-	pipe.Del(cs.ctx, fmt.Sprintf("comments/%d/post_id", id))
-	pipe.Del(cs.ctx, fmt.Sprintf("comments/%d/username", id))
-	pipe.Del(cs.ctx, fmt.Sprintf("comments/%d/mailaddress", id))
-	pipe.Del(cs.ctx, fmt.Sprintf("comments/%d/text", id))
-	pipe.Del(cs.ctx, fmt.Sprintf("comments/%d/active", id))
-	pipe.Del(cs.ctx, fmt.Sprintf("comments/%d/created_at", id))
+// buildCommentTree ordnet Antworten ihren Elternkommentaren zu und liefert die
+// Top-Level-Kommentare. Alle Ebenen sind chronologisch sortiert.
+// Antworten, deren Elternkommentar nicht in der Liste ist, werden zu Top-Level-Einträgen.
+func buildCommentTree(comments []*Comment) []*Comment {
+	byID := make(map[int]*Comment, len(comments))
+	for _, c := range comments {
+		c.Children = nil
+		byID[c.ID] = c
+	}
+
+	sorted := make([]*Comment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return commentTime(sorted[i]).Before(commentTime(sorted[j]))
+	})
 
-	_, err := pipe.Exec(cs.ctx)
-	if err != nil {
-		return fmt.Errorf("fehler beim Löschen des Kommentars: %w", err)
+	roots := []*Comment{}
+	for _, c := range sorted {
+		if parent, ok := byID[c.ParentID]; ok && c.ParentID != 0 {
+			parent.Children = append(parent.Children, c)
+		} else {
+			roots = append(roots, c)
+		}
 	}
 
-	return nil
+	return roots
 }
 
 // HTTP Handler
 type CommentHandler struct {
-	service *CommentService
+	service        *CommentService
+	limiter        *RateLimiter   // nil = kein Rate-Limiting
+	guard          *FormGuard     // nil = kein Honeypot/Formular-Token
+	pow            *PowChallenger // nil = kein Proof-of-Work
+	own            *OwnCommentsCookie
+	trustedProxies []*net.IPNet // für X-Forwarded-For (TRUSTED_PROXIES)
+	links          *ModerationLinks
+	notifier       *SMTPNotifier // nil = keine E-Mail-Benachrichtigung
+	// credentials: das Widget sendet Cookies cross-origin mit (nur mit CORS_ALLOWED_ORIGINS)
+	credentials bool
+}
+
+func NewCommentHandler(service *CommentService, limiter *RateLimiter, guard *FormGuard, pow *PowChallenger, own *OwnCommentsCookie, trustedProxies []*net.IPNet, links *ModerationLinks, notifier *SMTPNotifier, credentials bool) *CommentHandler {
+	return &CommentHandler{service: service, limiter: limiter, guard: guard, pow: pow, own: own, trustedProxies: trustedProxies, links: links, notifier: notifier, credentials: credentials}
 }
 
-func NewCommentHandler(service *CommentService) *CommentHandler {
-	return &CommentHandler{service: service}
+// corsAllowedOrigins liest CORS_ALLOWED_ORIGINS; nil bei "*" (alle Origins)
+func corsAllowedOrigins() []string {
+	value := getEnv("CORS_ALLOWED_ORIGINS", "*")
+	if strings.TrimSpace(value) == "*" {
+		return nil
+	}
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
 }
 
 func (h *CommentHandler) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PostID      string `json:"post_id"`
-		Username    string `json:"username"`
-		MailAddress string `json:"mailaddress"`
-		Text        string `json:"text"`
+		PostID       string `json:"post_id"`
+		Username     string `json:"username"`
+		MailAddress  string `json:"mailaddress"`
+		Text         string `json:"text"`
+		ParentID     int    `json:"parent_id"`
+		FormToken    string `json:"form_token"`
+		Website      string `json:"website"` // Honeypot, bleibt bei Menschen leer
+		PowChallenge string `json:"pow_challenge"`
+		PowNonce     string `json:"pow_nonce"`
+		Notify       string `json:"notify"` // Abo: "", "replies" oder "post"
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -437,42 +745,221 @@ func (h *CommentHandler) CreateCommentHandler(w http.ResponseWriter, r *http.Req
 		http.Error(w, "Alle Felder sind erforderlich", http.StatusBadRequest)
 		return
 	}
+	if req.ParentID < 0 {
+		http.Error(w, "Ungültige parent_id", http.StatusBadRequest)
+		return
+	}
+	notify, err := parseSubscriptionScope(req.Notify)
+	if err != nil {
+		http.Error(w, "Ungültiges notify (erlaubt: replies, post)", http.StatusBadRequest)
+		return
+	}
+	if notify != ScopeNone && !validMailAddress(req.MailAddress) {
+		http.Error(w, "Ungültige E-Mail-Adresse für Benachrichtigungen", http.StatusBadRequest)
+		return
+	}
+
+	ip := clientIP(r, h.trustedProxies)
+
+	if err := h.guard.Check(req.FormToken, req.Website); err != nil {
+		if errors.Is(err, ErrFormTokenExpired) {
+			http.Error(w, "Formular abgelaufen, bitte Seite neu laden", http.StatusBadRequest)
+			return
+		}
+		// Fehlendes oder ungültiges Token: meist ein Client ohne Token-Unterstützung,
+		// der soll einen Fehler sehen statt eines scheinbar gespeicherten Kommentars
+		if errors.Is(err, ErrFormTokenInvalid) {
+			http.Error(w, "Formular-Token fehlt oder ist ungültig, bitte Seite neu laden", http.StatusBadRequest)
+			return
+		}
+		// Bots bekommen eine scheinbar erfolgreiche Antwort, damit sie nicht nachjustieren
+		log.Printf("🍯 Kommentar von %s verworfen: %v", ip, err)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PublicComment{
+			PostID:    req.PostID,
+			ParentID:  req.ParentID,
+			Username:  req.Username,
+			Text:      req.Text,
+			Status:    StatusPending,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	if err := h.pow.Verify(ip, req.PowChallenge, req.PowNonce); err != nil {
+		log.Printf("⛏️  Proof-of-Work von %s abgelehnt: %v", ip, err)
+		http.Error(w, "Proof-of-Work ungültig, bitte erneut absenden", http.StatusBadRequest)
+		return
+	}
+
+	if allowed, retryAfter := h.limiter.Allow(ip, req.PostID, req.MailAddress); !allowed {
+		writeRateLimitError(w, retryAfter)
+		return
+	}
+
+	ban := h.service.bans.Match(ip, req.MailAddress, req.Username)
+	if ban != nil {
+		log.Printf("🚫 Kommentar von %s trifft Sperrregel #%d (%s)", ip, ban.ID, ban.Action)
+		if ban.Action == BanReject {
+			http.Error(w, "Kommentieren ist nicht erlaubt", http.StatusForbidden)
+			return
+		}
+	}
+
+	comment, err := h.service.CreateComment(CommentInput{
+		PostID:      req.PostID,
+		ParentID:    req.ParentID,
+		Username:    req.Username,
+		MailAddress: req.MailAddress,
+		Text:        req.Text,
+		IP:          ip,
+		UserAgent:   r.UserAgent(),
+		Ban:         ban,
+		Notify:      notify,
+
+		EmailVerified: h.own.VerifiedEmail(r) == normalizeEmail(req.MailAddress),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrParentNotFound), errors.Is(err, ErrParentOtherPost),
+			errors.Is(err, ErrParentInactive), errors.Is(err, ErrMaxDepthExceeded):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Fehler beim Erstellen des Kommentars", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Jeder Absender bekommt das Cookie, damit es einen Shadow-Ban nicht verrät
+	h.own.Add(w, r, comment.ID)
+	h.notifier.NotifyNewComment(comment)
+
+	// Spam nicht verraten, für den Absender sieht es wie eine normale Moderation aus
+	public := toPublicComment(comment, h.service.publicAvatarHash)
+	if comment.Shadowed {
+		public.Status = StatusApproved
+	} else if public.Status == StatusSpam {
+		public.Status = StatusPending
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(public)
+}
+
+// FormTokenHandler stellt ein neues Formular-Token aus (z.B. für Antwort-Formulare)
+func (h *CommentHandler) FormTokenHandler(w http.ResponseWriter, r *http.Request) {
+	token := ""
+	if h.guard != nil {
+		var err error
+		if token, err = h.guard.Issue(); err != nil {
+			http.Error(w, "Fehler beim Erstellen des Formular-Tokens", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"form_token": token})
+}
+
+// ChallengeHandler stellt eine Proof-of-Work-Aufgabe für POST /api/comments aus
+func (h *CommentHandler) ChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	if h.pow == nil {
+		http.Error(w, "Proof-of-Work ist deaktiviert", http.StatusNotFound)
+		return
+	}
 
-	comment, err := h.service.CreateComment(req.PostID, req.Username, req.MailAddress, req.Text)
+	challenge, err := h.pow.Issue(clientIP(r, h.trustedProxies))
 	if err != nil {
-		http.Error(w, "Fehler beim Erstellen des Kommentars", http.StatusInternalServerError)
+		http.Error(w, "Fehler beim Erstellen der Aufgabe", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(comment)
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(challenge)
 }
 
-func (h *CommentHandler) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+// loadCommentPage liest post_id, format und die Listenparameter und lädt die passende Seite.
+// own sind zusätzliche Kommentare (Shadow-Ban des Betrachters), die einsortiert werden.
+func (h *CommentHandler) loadCommentPage(r *http.Request, includeInactive bool, query CommentQuery, own []*Comment) (*CommentPage, error) {
 	postID := r.URL.Query().Get("post_id")
-	includeInactive := r.URL.Query().Get("include_inactive") == "true"
-	// includeInactive := true
+	tree := r.URL.Query().Get("format") == "tree"
 
-	var comments []*Comment
-	var err error
-
-	if postID != "" {
-		// Kommentare für einen bestimmten Post abrufen
-		comments, err = h.service.GetCommentsByPostID(postID, includeInactive)
-	} else {
+	if !tree && len(own) == 0 {
+		if postID != "" {
+			// Kommentare für einen bestimmten Post abrufen
+			return h.service.ListCommentsByPostID(postID, includeInactive, query)
+		}
 		// Alle Kommentare abrufen
-		comments, err = h.service.GetAllComments(includeInactive)
+		return h.service.ListAllComments(includeInactive, query)
+	}
+
+	// Verschachtelte Darstellung für Threads: geblättert wird über die
+	// Top-Level-Kommentare, Antworten hängen immer vollständig daran.
+	// Mit eigenen Kommentaren wird auch flach erst nach dem Mischen geblättert.
+	// Beides gibt es nur mit post_id (parseCommentQuery, GetCommentsHandler).
+	all := CommentQuery{Sort: SortOldest, Status: query.Status}
+	page, err := h.service.ListCommentsByPostID(postID, includeInactive, all)
+	if err != nil {
+		return nil, err
+	}
+
+	items := append(page.Items, own...)
+	if tree {
+		items = buildCommentTree(items)
+	}
+	return paginateComments(items, query), nil
+}
+
+// GetCommentsHandler liefert öffentlich nur freigegebene Kommentare ohne E-Mail-Adressen.
+// include_inactive und status werden ignoriert, dafür gibt es GET /api/comments/admin/comments.
+func (h *CommentHandler) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseCommentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query.Status = ""
+
+	// Eigene Shadow-Ban-Kommentare sieht nur der Absender selbst. Gemischt wird
+	// nur innerhalb eines Posts, sonst müssten alle Kommentare geladen werden.
+	var own []*Comment
+	if postID := r.URL.Query().Get("post_id"); postID != "" {
+		own = h.service.ownShadowComments(postID, h.own.IDs(r))
+	}
+
+	page, err := h.loadCommentPage(r, false, query, own)
+	if err != nil {
+		http.Error(w, "Fehler beim Abrufen der Kommentare", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toPublicPage(page, h.service.publicAvatarHash))
+}
+
+// AdminListCommentsHandler liefert vollständige Datensätze inkl. nicht freigegebener Kommentare.
+// Standardmäßig werden alle Status geliefert, include_inactive=false beschränkt auf freigegebene.
+func (h *CommentHandler) AdminListCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseCommentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	includeInactive := r.URL.Query().Get("include_inactive") != "false"
 
+	page, err := h.loadCommentPage(r, includeInactive, query, nil)
 	if err != nil {
 		http.Error(w, "Fehler beim Abrufen der Kommentare", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(comments)
+	json.NewEncoder(w).Encode(page)
 }
 
+// GetCommentHandler liefert einen freigegebenen Kommentar in der öffentlichen Darstellung
 func (h *CommentHandler) GetCommentHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
@@ -483,6 +970,27 @@ func (h *CommentHandler) GetCommentHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	comment, err := h.service.GetComment(id)
+	if err != nil || !comment.Active {
+		http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toPublicComment(comment, h.service.publicAvatarHash))
+}
+
+// AdminGetCommentHandler liefert den vollständigen Datensatz eines Kommentars
+func (h *CommentHandler) AdminGetCommentHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
 	comment, err := h.service.GetComment(id)
 	if err != nil {
 		http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
@@ -503,8 +1011,10 @@ func (h *CommentHandler) UpdateCommentStatusHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// status hat Vorrang, active wird aus Kompatibilitätsgründen weiter akzeptiert
 	var req struct {
-		Active bool `json:"active"`
+		Status string `json:"status"`
+		Active *bool  `json:"active"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -512,34 +1022,907 @@ func (h *CommentHandler) UpdateCommentStatusHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
-	err = h.service.UpdateCommentStatus(id, req.Active)
+	var status CommentStatus
+	switch {
+	case req.Status != "":
+		status, err = parseCommentStatus(req.Status)
+		if err != nil {
+			http.Error(w, "Ungültiger Status (erlaubt: pending, approved, rejected, spam, deleted)", http.StatusBadRequest)
+			return
+		}
+	case req.Active != nil:
+		comment, err := h.service.GetComment(id)
+		if err != nil {
+			http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
+			return
+		}
+		status = statusForActive(comment.Status, *req.Active)
+	default:
+		http.Error(w, "status oder active ist erforderlich", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.service.UpdateCommentStatus(id, status, h.moderatorFromRequest(r))
+	if errors.Is(err, ErrCommentNotFound) {
+		http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrInvalidTransition) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, ErrModerationConflict) {
+		http.Error(w, "Kommentar wurde zwischenzeitlich moderiert, bitte neu laden", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Fehler beim Aktualisieren des Status", http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Status aktualisiert"})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Status aktualisiert",
+		"status":  comment.Status,
+		"active":  comment.Active,
+	})
 }
 
-func (h *CommentHandler) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-
-	id, err := strconv.Atoi(idStr)
+// ModerationLinkHandler bedient die signierten Links aus der E-Mail-Benachrichtigung
+// (ohne Admin-Token). GET zeigt nur eine Bestätigungsseite, erst POST setzt den Status.
+func (h *CommentHandler) ModerationLinkHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		http.Error(w, "Ungültige ID", http.StatusBadRequest)
 		return
 	}
 
-	err = h.service.DeleteComment(id)
+	status, err := h.links.Verify(id, r.URL.Query())
 	if err != nil {
-		http.Error(w, "Fehler beim Löschen des Kommentars", http.StatusInternalServerError)
+		renderLinkPage(w, http.StatusForbidden, linkPage{
+			Title:   "Link ungültig",
+			Message: "Der Link ist ungültig oder abgelaufen. Bitte im Admin-Panel moderieren.",
+		})
+		return
+	}
+
+	comment, err := h.service.GetComment(id)
+	if err != nil {
+		renderLinkPage(w, http.StatusNotFound, linkPage{
+			Title:   "Kommentar nicht gefunden",
+			Message: "Der Kommentar wurde inzwischen endgültig gelöscht.",
+		})
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		page := linkPage{Title: "Kommentar freigeben?", Comment: comment, Button: "✅ Freigeben", Style: "approve"}
+		if status == StatusRejected {
+			page = linkPage{Title: "Kommentar ablehnen?", Comment: comment, Button: "❌ Ablehnen", Style: "reject"}
+		}
+		renderLinkPage(w, http.StatusOK, page)
+		return
+	}
+
+	by := Moderator{Name: linkModerator, IP: clientIP(r, h.trustedProxies)}
+	comment, err = h.service.UpdateCommentStatus(id, status, by)
+	if errors.Is(err, ErrInvalidTransition) {
+		renderLinkPage(w, http.StatusConflict, linkPage{
+			Title:   "Nicht möglich",
+			Message: fmt.Sprintf("Der Status kann nicht geändert werden (%v).", err),
+		})
+		return
+	}
+	if errors.Is(err, ErrModerationConflict) {
+		renderLinkPage(w, http.StatusConflict, linkPage{
+			Title:   "Bereits moderiert",
+			Message: "Der Kommentar wurde inzwischen anderweitig moderiert. Bitte im Admin-Panel prüfen.",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Moderation per E-Mail-Link für Kommentar #%d fehlgeschlagen: %v", id, err)
+		renderLinkPage(w, http.StatusInternalServerError, linkPage{
+			Title:   "Fehler",
+			Message: "Fehler beim Aktualisieren des Status",
+		})
+		return
+	}
+
+	title := "Kommentar freigegeben"
+	if comment.Status == StatusRejected {
+		title = "Kommentar abgelehnt"
+	}
+	renderLinkPage(w, http.StatusOK, linkPage{Title: title, Comment: comment})
+}
+
+// SubscriptionLinkHandler bestätigt bzw. beendet ein Abo über den signierten Link
+// aus der E-Mail. GET zeigt nur eine Bestätigungsseite; POST führt die Aktion aus
+// (auch als One-Click-Abmeldung nach RFC 8058 aus dem Mailprogramm).
+func (h *CommentHandler) SubscriptionLinkHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions := h.service.subscriptions
+	if subscriptions == nil {
+		http.Error(w, "Abos sind deaktiviert", http.StatusNotFound)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+	action := vars["action"]
+	if !subscriptions.VerifyLink(id, action, r.URL.Query().Get("sig")) {
+		renderLinkPage(w, http.StatusForbidden, linkPage{Title: "Link ungültig", Message: "Der Link ist ungültig."})
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		page := linkPage{Title: "Abo bestätigen?", Message: "Du bekommst dann E-Mails zu neuen Kommentaren.", Button: "🔔 Abo bestätigen", Style: "approve"}
+		if action == "unsubscribe" {
+			page = linkPage{Title: "Abo beenden?", Message: "Du bekommst dann keine E-Mails mehr zu diesem Abo.", Button: "🔕 Abbestellen", Style: "reject"}
+		}
+		renderLinkPage(w, http.StatusOK, page)
+		return
+	}
+
+	if action == "unsubscribe" {
+		err = subscriptions.Unsubscribe(id)
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			// Schon abbestellt, für den Leser ist das Ergebnis dasselbe
+			err = nil
+		}
+		if err == nil {
+			renderLinkPage(w, http.StatusOK, linkPage{Title: "Abo beendet", Message: "Du bekommst keine E-Mails mehr zu diesem Abo."})
+			return
+		}
+	} else {
+		var sub *Subscription
+		sub, err = subscriptions.Confirm(id)
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			renderLinkPage(w, http.StatusNotFound, linkPage{Title: "Abo nicht gefunden", Message: "Das Abo wurde inzwischen abbestellt."})
+			return
+		}
+		if err == nil {
+			// Der Link kam per E-Mail: dieser Browser hat Zugriff auf die Adresse
+			h.own.SetVerifiedEmail(w, r, sub.Email)
+			renderLinkPage(w, http.StatusOK, linkPage{Title: "Abo bestätigt", Message: "Du wirst ab jetzt per E-Mail benachrichtigt. Abbestellen geht über den Link in jeder Benachrichtigung."})
+			return
+		}
+	}
+
+	log.Printf("❌ Abo #%d (%s) fehlgeschlagen: %v", id, action, err)
+	renderLinkPage(w, http.StatusInternalServerError, linkPage{Title: "Fehler", Message: "Bitte später erneut versuchen."})
+}
+
+// BulkModerationHandler setzt den Status mehrerer Kommentare auf einmal.
+// Das Ergebnis enthält pro ID, ob der Wechsel durchgeführt wurde.
+func (h *CommentHandler) BulkModerationHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs    []int  `json:"ids"`
+		Action string `json:"action"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Ungültige JSON", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := bulkActions[req.Action]
+	if !ok {
+		http.Error(w, "Ungültige Aktion (erlaubt: approve, reject, spam, delete)", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		http.Error(w, "ids ist erforderlich", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > maxBulkItems {
+		http.Error(w, fmt.Sprintf("Maximal %d IDs pro Request", maxBulkItems), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.service.BulkModerate(req.IDs, status, h.moderatorFromRequest(r))
+	if errors.Is(err, ErrModerationConflict) {
+		http.Error(w, "Kommentare wurden zwischenzeitlich moderiert, bitte neu laden", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Fehler bei der Bulk-Moderation", http.StatusInternalServerError)
+		return
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.OK {
+			succeeded++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"action":    req.Action,
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+func (h *CommentHandler) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.DeleteComment(id, h.moderatorFromRequest(r))
+	if errors.Is(err, ErrCommentNotFound) {
+		http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrModerationConflict) {
+		http.Error(w, "Kommentar wurde zwischenzeitlich moderiert, bitte neu laden", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Fehler beim Löschen des Kommentars", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Kommentar in den Papierkorb verschoben"})
+}
+
+// writeTrashError übersetzt Fehler beim Wiederherstellen und endgültigen Löschen in HTTP-Status
+func writeTrashError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrCommentNotFound):
+		http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
+	case errors.Is(err, ErrNotInTrash):
+		http.Error(w, "Kommentar ist nicht im Papierkorb", http.StatusConflict)
+	case errors.Is(err, ErrModerationConflict):
+		http.Error(w, "Kommentar wurde zwischenzeitlich moderiert, bitte neu laden", http.StatusConflict)
+	default:
+		http.Error(w, "Fehler beim Zugriff auf den Papierkorb", http.StatusInternalServerError)
+	}
+}
+
+// AdminListTrashHandler liefert die Kommentare im Papierkorb (Parameter wie /admin/comments)
+func (h *CommentHandler) AdminListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseCommentQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.service.ListTrash(query)
+	if err != nil {
+		http.Error(w, "Fehler beim Abrufen des Papierkorbs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// AdminRestoreCommentHandler holt einen Kommentar aus dem Papierkorb zurück
+func (h *CommentHandler) AdminRestoreCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	comment, err := h.service.RestoreComment(id, h.moderatorFromRequest(r))
+	if err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Kommentar wiederhergestellt",
+		"status":  comment.Status,
+		"active":  comment.Active,
+	})
+}
+
+// AdminPurgeCommentHandler löscht einen Kommentar aus dem Papierkorb sofort endgültig
+func (h *CommentHandler) AdminPurgeCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.PurgeComment(id, h.moderatorFromRequest(r)); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Kommentar endgültig gelöscht"})
+}
+
+// banRequest ist der Body für das Anlegen und Ändern von Sperrregeln.
+// expires_in (z.B. "24h") ist eine Alternative zu expires_at.
+type banRequest struct {
+	Type      BanType   `json:"type"`
+	Value     string    `json:"value"`
+	Action    BanAction `json:"action"`
+	Reason    string    `json:"reason"`
+	ExpiresAt string    `json:"expires_at"`
+	ExpiresIn string    `json:"expires_in"`
+}
+
+// decodeBanRequest liest eine Sperrregel aus dem Request
+func decodeBanRequest(r *http.Request) (*BanRule, error) {
+	var req banRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: ungültige JSON", ErrInvalidBan)
+	}
+
+	rule := &BanRule{
+		Type:      req.Type,
+		Value:     req.Value,
+		Action:    req.Action,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.ExpiresIn != "" {
+		duration, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%w: ungültiges expires_in %q", ErrInvalidBan, req.ExpiresIn)
+		}
+		rule.ExpiresAt = time.Now().Add(duration).UTC().Format(time.RFC3339)
+	}
+	return rule, nil
+}
+
+// writeBanError übersetzt Fehler der Sperrliste in HTTP-Status
+func writeBanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidBan):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrBanNotFound):
+		http.Error(w, "Sperrregel nicht gefunden", http.StatusNotFound)
+	case errors.Is(err, ErrBansUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, "Fehler beim Zugriff auf die Sperrliste", http.StatusInternalServerError)
+	}
+}
+
+// AdminListBansHandler liefert alle Sperrregeln inkl. abgelaufener
+func (h *CommentHandler) AdminListBansHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.service.bans.List()
+	if err != nil {
+		writeBanError(w, err)
+		return
+	}
+	if rules == nil {
+		rules = []*BanRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": rules, "total": len(rules)})
+}
+
+// AdminCreateBanHandler legt eine Sperrregel an
+func (h *CommentHandler) AdminCreateBanHandler(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeBanRequest(r)
+	if err == nil {
+		err = h.service.bans.Create(rule)
+	}
+	if err != nil {
+		writeBanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// AdminGetBanHandler liefert eine Sperrregel
+func (h *CommentHandler) AdminGetBanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.service.bans.Get(id)
+	if err != nil {
+		writeBanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// AdminUpdateBanHandler überschreibt eine Sperrregel
+func (h *CommentHandler) AdminUpdateBanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := decodeBanRequest(r)
+	if err == nil {
+		rule.ID = id
+		err = h.service.bans.Update(rule)
+	}
+	if err != nil {
+		writeBanError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// AdminDeleteBanHandler entfernt eine Sperrregel
+func (h *CommentHandler) AdminDeleteBanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.bans.Delete(id); err != nil {
+		writeBanError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Kommentar gelöscht"})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Sperrregel gelöscht"})
+}
+
+// writeReportError übersetzt Fehler bei Meldungen in HTTP-Status
+func writeReportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidReport):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrCommentNotFound):
+		http.Error(w, "Kommentar nicht gefunden", http.StatusNotFound)
+	case errors.Is(err, ErrReportNotFound):
+		http.Error(w, "Meldung nicht gefunden", http.StatusNotFound)
+	case errors.Is(err, ErrReportsUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, "Fehler beim Zugriff auf die Meldungen", http.StatusInternalServerError)
+	}
+}
+
+// ReportCommentHandler nimmt die Meldung eines Lesers entgegen. Doppelte
+// Meldungen (gleiche IP oder gleicher Browser) werden still ignoriert.
+func (h *CommentHandler) ReportCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Ungültige JSON", http.StatusBadRequest)
+		return
+	}
+
+	ip := clientIP(r, h.trustedProxies)
+	if _, err := h.service.ReportComment(id, req.Reason, ip, readerID(w, r)); err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Danke für die Meldung"})
+}
+
+// AdminListReportsHandler liefert Meldungen, optional nur zu ?comment_id=
+func (h *CommentHandler) AdminListReportsHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := 0
+	if value := r.URL.Query().Get("comment_id"); value != "" {
+		var err error
+		if commentID, err = strconv.Atoi(value); err != nil || commentID <= 0 {
+			http.Error(w, "Ungültige comment_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	reports, err := h.service.ListReports(commentID)
+	if err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": reports, "total": len(reports)})
+}
+
+// AdminDeleteReportHandler verwirft eine Meldung
+func (h *CommentHandler) AdminDeleteReportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteReport(id); err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Meldung verworfen"})
+}
+
+// AdminDismissReportsHandler verwirft alle Meldungen zu einem Kommentar
+func (h *CommentHandler) AdminDismissReportsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DismissReports(id); err != nil {
+		writeReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Meldungen verworfen"})
+}
+
+// AdminAuditHandler liefert das Audit-Log, filterbar nach ?comment_id=, ?post_id= und ?actor=
+func (h *CommentHandler) AdminAuditHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	filter := AuditFilter{
+		PostID: values.Get("post_id"),
+		Actor:  values.Get("actor"),
+		Limit:  defaultPageLimit,
+	}
+	if value := values.Get("comment_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			http.Error(w, "Ungültige comment_id", http.StatusBadRequest)
+			return
+		}
+		filter.CommentID = id
+	}
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageLimit {
+			http.Error(w, fmt.Sprintf("limit muss zwischen 1 und %d liegen", maxPageLimit), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+	if value := values.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, "Ungültiger offset", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = n
+	}
+
+	entries, total, err := h.service.ListAudit(filter)
+	if errors.Is(err, ErrAuditUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Fehler beim Laden des Audit-Logs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": entries, "total": total})
+}
+
+// writeTrustError übersetzt Fehler der Vertrauensdaten in HTTP-Status
+func writeTrustError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidTrust):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrTrustUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, "Fehler beim Zugriff auf die Vertrauensdaten", http.StatusInternalServerError)
+	}
+}
+
+// AdminListTrustHandler liefert alle Autoren mit Vertrauensdaten
+func (h *CommentHandler) AdminListTrustHandler(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.ListTrust()
+	if err != nil {
+		writeTrustError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": entries, "total": len(entries)})
+}
+
+// AdminGetTrustHandler liefert die Vertrauensdaten einer E-Mail-Adresse
+func (h *CommentHandler) AdminGetTrustHandler(w http.ResponseWriter, r *http.Request) {
+	trust, err := h.service.GetTrust(mux.Vars(r)["email"])
+	if err != nil {
+		writeTrustError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trust)
+}
+
+// AdminSetTrustHandler setzt den Override eines Autors ("trusted", "untrusted" oder "" für automatisch)
+func (h *CommentHandler) AdminSetTrustHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Override *string `json:"override"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Ungültige JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Override == nil {
+		http.Error(w, "override ist erforderlich", http.StatusBadRequest)
+		return
+	}
+
+	override, err := parseTrustOverride(*req.Override)
+	if err != nil {
+		writeTrustError(w, err)
+		return
+	}
+	trust, err := h.service.SetTrustOverride(mux.Vars(r)["email"], override)
+	if err != nil {
+		writeTrustError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trust)
+}
+
+// webhookRequest ist der Body für das Anlegen und Ändern von Webhooks.
+// Ohne secret wird beim Anlegen eins erzeugt, beim Ändern das alte behalten
+// (token ebenso).
+type webhookRequest struct {
+	URL         string         `json:"url"`
+	Events      []WebhookEvent `json:"events"`
+	Secret      string         `json:"secret"`
+	Format      WebhookFormat  `json:"format"` // Standard: json
+	Token       string         `json:"token"`
+	Description string         `json:"description"`
+	Active      *bool          `json:"active"` // Standard: true
+}
+
+// decodeWebhookRequest liest einen Webhook aus dem Request
+func decodeWebhookRequest(r *http.Request) (*Webhook, error) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("%w: ungültige JSON", ErrInvalidWebhook)
+	}
+
+	wh := &Webhook{
+		URL:         strings.TrimSpace(req.URL),
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Format:      req.Format,
+		Token:       req.Token,
+		Description: req.Description,
+		Active:      req.Active == nil || *req.Active,
+	}
+	return wh, nil
+}
+
+// writeWebhookError übersetzt Fehler bei Webhooks in HTTP-Status
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidWebhook):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrWebhookNotFound):
+		http.Error(w, "Webhook nicht gefunden", http.StatusNotFound)
+	case errors.Is(err, ErrDeliveryNotFound):
+		http.Error(w, "Zustellung nicht in der Dead-Letter-Liste", http.StatusNotFound)
+	case errors.Is(err, ErrWebhooksUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	default:
+		http.Error(w, "Fehler beim Zugriff auf die Webhooks", http.StatusInternalServerError)
+	}
+}
+
+// AdminListWebhooksHandler liefert alle Webhooks (ohne Secrets)
+func (h *CommentHandler) AdminListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.service.ListWebhooks()
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": webhooks, "total": len(webhooks)})
+}
+
+// AdminCreateWebhookHandler legt einen Webhook an; nur diese Antwort enthält das Secret
+func (h *CommentHandler) AdminCreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	wh, err := decodeWebhookRequest(r)
+	if err == nil {
+		wh, err = h.service.CreateWebhook(wh)
+	}
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wh)
+}
+
+// AdminGetWebhookHandler liefert einen Webhook
+func (h *CommentHandler) AdminGetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	wh, err := h.service.GetWebhook(id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wh)
+}
+
+// AdminUpdateWebhookHandler überschreibt einen Webhook
+func (h *CommentHandler) AdminUpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	wh, err := decodeWebhookRequest(r)
+	if err == nil {
+		wh.ID = id
+		wh, err = h.service.UpdateWebhook(wh)
+	}
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wh)
+}
+
+// AdminDeleteWebhookHandler entfernt einen Webhook
+func (h *CommentHandler) AdminDeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(id); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Webhook gelöscht"})
+}
+
+// AdminTestWebhookHandler plant ein ping-Ereignis für einen Webhook ein
+func (h *CommentHandler) AdminTestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Ungültige ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.PingWebhook(id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// AdminWebhookDeliveriesHandler liefert das Zustell-Log (neueste zuerst),
+// optional gefiltert mit ?webhook_id=
+func (h *CommentHandler) AdminWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	webhookID := 0
+	if value := values.Get("webhook_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			http.Error(w, "Ungültige webhook_id", http.StatusBadRequest)
+			return
+		}
+		webhookID = id
+	}
+	limit := defaultPageLimit
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageLimit {
+			http.Error(w, fmt.Sprintf("limit muss zwischen 1 und %d liegen", maxPageLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.service.ListWebhookDeliveries(webhookID, limit)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": entries, "total": len(entries)})
+}
+
+// AdminDeadLettersHandler liefert die endgültig fehlgeschlagenen Zustellungen
+// (neueste zuerst, blätterbar mit ?limit= und ?offset=)
+func (h *CommentHandler) AdminDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, offset := defaultPageLimit, 0
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageLimit {
+			http.Error(w, fmt.Sprintf("limit muss zwischen 1 und %d liegen", maxPageLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if value := values.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, "Ungültiger offset", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	deliveries, total, err := h.service.ListDeadLetters(offset, limit)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": deliveries, "total": total})
+}
+
+// AdminRetryDeadLetterHandler plant eine Zustellung aus der Dead-Letter-Liste neu ein
+func (h *CommentHandler) AdminRetryDeadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.service.RetryDeadLetter(mux.Vars(r)["id"])
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
 }
 
 // AdminPanelHandler serviert das Admin-Panel HTML
@@ -689,6 +2072,24 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             font-size: 14px;
         }
 
+        .bulk-bar {
+            padding: 12px 30px;
+            background: #f8f9fa;
+            border-bottom: 1px solid #e9ecef;
+            display: flex;
+            gap: 10px;
+            align-items: center;
+            flex-wrap: wrap;
+            font-size: 0.9rem;
+        }
+
+        .comment-select {
+            width: 18px;
+            height: 18px;
+            margin-right: 10px;
+            cursor: pointer;
+        }
+
         .comments-list {
             padding: 30px;
             max-height: 600px;
@@ -716,10 +2117,116 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             border-left: 4px solid #dc3545;
         }
 
-        .comment-item.active {
-            border-left: 4px solid #28a745;
+        .comment-item.active {
+            border-left: 4px solid #28a745;
+        }
+
+        .comment-item.focused {
+            box-shadow: 0 0 0 3px #667eea;
+        }
+
+        .comment-item.status-pending {
+            border-left: 4px solid #ffc107;
+        }
+
+        .comment-item.status-spam {
+            border-left: 4px solid #6f42c1;
+        }
+
+        .comment-item.status-deleted {
+            border-left: 4px solid #6c757d;
+        }
+
+        .spam-info {
+            color: #6f42c1;
+            font-size: 0.85rem;
+            margin-bottom: 10px;
+        }
+
+        .spam-info ul {
+            margin: 4px 0 0 20px;
+        }
+
+        .ban-form {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 10px;
+            align-items: center;
+            background: #fff5f5;
+            border: 1px solid #f5c6cb;
+            border-radius: 8px;
+            padding: 12px;
+            margin-bottom: 15px;
+            font-size: 0.9rem;
+        }
+
+        .ban-form select, .ban-form input {
+            padding: 6px 8px;
+            border: 1px solid #ddd;
+            border-radius: 6px;
+        }
+
+        .bans-section, .reports-section, .audit-section {
+            padding: 0 30px 20px;
+        }
+
+        .bans-section summary, .reports-section summary, .audit-section summary {
+            cursor: pointer;
+            font-weight: 600;
+            color: #333;
+            padding: 10px 0;
+        }
+
+        .bans-section table, .reports-section table, .audit-section table {
+            width: 100%;
+            border-collapse: collapse;
+            font-size: 0.9rem;
+        }
+
+        .bans-section th, .bans-section td, .reports-section th, .reports-section td,
+        .audit-section th, .audit-section td {
+            text-align: left;
+            padding: 6px 8px;
+            border-bottom: 1px solid #e9ecef;
+        }
+
+        .bans-section tr.expired {
+            color: #999;
+        }
+
+        .reports-section ul {
+            margin: 0 0 0 18px;
+        }
+
+        .audit-filters {
+            display: flex;
+            gap: 10px;
+            flex-wrap: wrap;
+            margin-bottom: 10px;
+        }
+
+        .audit-filters input {
+            padding: 6px 8px;
+            border: 1px solid #ddd;
+            border-radius: 6px;
+        }
+
+        .status-badge {
+            display: inline-block;
+            padding: 4px 10px;
+            border-radius: 12px;
+            font-size: 0.8rem;
+            font-weight: 600;
+            background: #e9ecef;
+            color: #495057;
         }
 
+        .status-badge.pending { background: #fff3cd; color: #856404; }
+        .status-badge.approved { background: #d4edda; color: #155724; }
+        .status-badge.rejected { background: #f8d7da; color: #721c24; }
+        .status-badge.spam { background: #e2d9f3; color: #4b2c84; }
+        .status-badge.deleted { background: #e9ecef; color: #6c757d; }
+
         .comment-header {
             display: flex;
             justify-content: space-between;
@@ -943,6 +2450,14 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
                 <div class="stat-number" id="inactiveComments">0</div>
                 <div class="stat-label">Inaktiv</div>
             </div>
+            <div class="stat-card">
+                <div class="stat-number" id="pendingComments">0</div>
+                <div class="stat-label">Ausstehend</div>
+            </div>
+            <div class="stat-card">
+                <div class="stat-number" id="spamComments">0</div>
+                <div class="stat-label">Spam</div>
+            </div>
             <div class="stat-card">
                 <div class="stat-number" id="uniquePosts">0</div>
                 <div class="stat-label">Posts</div>
@@ -954,8 +2469,11 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
                 <label>Status:</label>
                 <select id="statusFilter" onchange="filterComments()">
                     <option value="all">Alle</option>
-                    <option value="active">Nur Aktive</option>
-                    <option value="inactive">Nur Inaktive</option>
+                    <option value="pending">⏳ Ausstehend</option>
+                    <option value="approved">✅ Freigegeben</option>
+                    <option value="rejected">🚫 Abgelehnt</option>
+                    <option value="spam">🤖 Spam</option>
+                    <option value="deleted">🗑️ Papierkorb</option>
                 </select>
             </div>
             <div class="filter-group">
@@ -973,8 +2491,45 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             </div>
         </div>
 
+        <div id="bulkSection" class="bulk-bar" style="display: none;">
+            <label><input type="checkbox" id="selectAll" onchange="toggleSelectAll(this.checked)"> Alle angezeigten</label>
+            <span><strong id="selectedCount">0</strong> ausgewählt</span>
+            <button class="status-toggle active" onclick="bulkModerate('approve')">Freigeben</button>
+            <button class="status-toggle inactive" onclick="bulkModerate('reject')">Ablehnen</button>
+            <button class="status-toggle inactive" onclick="bulkModerate('spam')">Spam</button>
+            <button class="status-toggle inactive" onclick="bulkModerate('delete')">In den Papierkorb</button>
+            <button class="status-toggle active" id="approvePostBtn" onclick="approvePendingOnPost()" style="display: none;"></button>
+        </div>
+
         <div id="messageArea"></div>
 
+        <div id="bansSection" class="bans-section" style="display: none;">
+            <details>
+                <summary>🚫 Sperrliste (<span id="bansCount">0</span>)</summary>
+                <div id="bansContainer"></div>
+            </details>
+        </div>
+
+        <div id="reportsSection" class="reports-section" style="display: none;">
+            <details>
+                <summary>⚑ Meldungen (<span id="reportsCount">0</span>)</summary>
+                <div id="reportsContainer"></div>
+            </details>
+        </div>
+
+        <div id="auditSection" class="audit-section" style="display: none;">
+            <details id="auditDetails" ontoggle="if (this.open) loadAudit()">
+                <summary>📜 Verlauf</summary>
+                <div class="audit-filters">
+                    <input type="number" id="auditComment" placeholder="Kommentar-ID" min="1">
+                    <input type="text" id="auditPost" placeholder="Post-ID">
+                    <input type="text" id="auditActor" placeholder="Moderator">
+                    <button class="status-toggle active" onclick="loadAudit()">Filtern</button>
+                </div>
+                <div id="auditContainer"></div>
+            </details>
+        </div>
+
         <div class="comments-list">
             <div id="commentsContainer">
                 <div class="loading">Bitte authentifizieren Sie sich, um Kommentare zu laden</div>
@@ -988,6 +2543,8 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
         let allComments = [];
         let autoRefreshInterval = null;
         const API_BASE = '/api/comments';
+        // Deep-Link aus Benachrichtigungen: /admin/panel?comment={id}
+        let focusCommentId = Number(new URLSearchParams(window.location.search).get('comment')) || 0;
 
         // Token aus verschiedenen Quellen laden
         function loadTokenFromStorage() {
@@ -996,7 +2553,7 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             if (urlToken) {
                 adminToken = urlToken;
                 document.getElementById('adminToken').value = urlToken;
-                window.history.replaceState({}, document.title, window.location.pathname);
+                window.history.replaceState({}, document.title, window.location.pathname + (focusCommentId ? '?comment=' + focusCommentId : ''));
                 return urlToken;
             }
 
@@ -1052,6 +2609,7 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             document.getElementById('refreshBtn').disabled = true;
             document.getElementById('statsSection').style.display = 'none';
             document.getElementById('filtersSection').style.display = 'none';
+            document.getElementById('bulkSection').style.display = 'none';
             
             const tokenInput = document.getElementById('adminToken');
             tokenInput.style.borderColor = '#dc3545';
@@ -1134,25 +2692,78 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
                 updateStats(adminInfo);
                 document.getElementById('statsSection').style.display = 'grid';
                 document.getElementById('filtersSection').style.display = 'flex';
+                document.getElementById('bulkSection').style.display = 'flex';
                 enableAuthenticatedUI();
                 showMessage('Erfolgreich authentifiziert', 'success');
             } else {
                 return;
             }
 
-            const comments = await apiCall(API_BASE + '?include_inactive=true');
+            const comments = await loadAllCommentPages();
             if (comments) {
                 allComments = comments;
                 updatePostFilter();
                 filterComments();
                 showMessage('Kommentare erfolgreich geladen', 'success');
+                focusComment();
+            }
+
+            loadBans();
+            loadReports();
+            document.getElementById('auditSection').style.display = 'block';
+        }
+
+        // Alle Seiten nacheinander über den Cursor laden
+        async function loadAllCommentPages() {
+            let comments = [];
+            let cursor = '';
+
+            do {
+                let url = API_BASE + '/admin/comments?sort=newest&limit=200';
+                if (cursor) {
+                    url += '&cursor=' + encodeURIComponent(cursor);
+                }
+
+                const page = await apiCall(url);
+                if (!page) {
+                    return null;
+                }
+
+                comments = comments.concat(page.items || []);
+                cursor = page.next_cursor;
+            } while (cursor);
+
+            return comments;
+        }
+
+        // Kommentar aus dem Deep-Link einmalig anzeigen, notfalls ohne Filter
+        function focusComment() {
+            if (!focusCommentId) {
+                return;
+            }
+            const id = focusCommentId;
+            focusCommentId = 0;
+
+            if (!displayedIds.includes(id)) {
+                document.getElementById('statusFilter').value = 'all';
+                document.getElementById('postFilter').value = 'all';
+                filterComments();
+            }
+            const element = document.getElementById('comment-' + id);
+            if (!element) {
+                showMessage('Kommentar #' + id + ' nicht gefunden');
+                return;
             }
+            element.classList.add('focused');
+            element.scrollIntoView({ behavior: 'smooth', block: 'center' });
         }
 
         function updateStats(adminInfo) {
             document.getElementById('totalComments').textContent = adminInfo.total_comments;
             document.getElementById('activeComments').textContent = adminInfo.active_comments;
             document.getElementById('inactiveComments').textContent = adminInfo.inactive_comments;
+            document.getElementById('pendingComments').textContent = (adminInfo.status_counts || {}).pending || 0;
+            document.getElementById('spamComments').textContent = (adminInfo.status_counts || {}).spam || 0;
             
             const uniquePosts = new Set(allComments.map(c => c.post_id)).size;
             document.getElementById('uniquePosts').textContent = uniquePosts;
@@ -1178,10 +2789,8 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
 
             let filteredComments = [...allComments];
 
-            if (statusFilter === 'active') {
-                filteredComments = filteredComments.filter(c => c.active);
-            } else if (statusFilter === 'inactive') {
-                filteredComments = filteredComments.filter(c => !c.active);
+            if (statusFilter !== 'all') {
+                filteredComments = filteredComments.filter(c => c.status === statusFilter);
             }
 
             if (postFilter !== 'all') {
@@ -1199,6 +2808,8 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
 
         function displayComments(comments) {
             const container = document.getElementById('commentsContainer');
+            displayedIds = comments.map(c => c.id);
+            updateBulkBar();
             
             if (comments.length === 0) {
                 container.innerHTML = '<div class="empty-state"><h3>Keine Kommentare gefunden</h3><p>Es gibt keine Kommentare, die den aktuellen Filtern entsprechen.</p></div>';
@@ -1215,20 +2826,45 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
                     minute: '2-digit'
                 });
 
-                return '<div class="comment-item ' + (comment.active ? 'active' : 'inactive') + '">' +
+                const status = STATUS_LABELS[comment.status] ? comment.status : 'pending';
+                const actions = (STATUS_TRANSITIONS[status] || []).map(target =>
+                    '<button class="status-toggle ' + (target === 'approved' ? 'active' : 'inactive') + '" onclick="setCommentStatus(' + comment.id + ', \'' + target + '\')">' +
+                        STATUS_ACTIONS[target] +
+                    '</button>'
+                ).join('') + (status === 'deleted' ?
+                    '<button class="status-toggle active" onclick="restoreComment(' + comment.id + ')">♻️ Wiederherstellen</button>' +
+                    '<button class="status-toggle inactive" onclick="purgeComment(' + comment.id + ')">Endgültig löschen</button>' : '');
+
+                return '<div id="comment-' + comment.id + '" class="comment-item ' + (comment.active ? 'active' : 'inactive') + ' status-' + status + '">' +
                     '<div class="comment-header">' +
                         '<div class="comment-meta">' +
-                            '<div class="comment-author">👤 ' + escapeHtml(comment.username) + '</div>' +
-                            '<div class="comment-email">📧 ' + escapeHtml(comment.mailaddress) + '</div>' +
+                            '<div class="comment-author">' +
+                                '<input type="checkbox" class="comment-select" onchange="toggleSelect(' + comment.id + ', this.checked)"' + (selectedIds.has(comment.id) ? ' checked' : '') + '>' +
+                                '👤 ' + escapeHtml(comment.username) +
+                            '</div>' +
+                            '<div class="comment-email">📧 ' + escapeHtml(comment.mailaddress) + (comment.email_verified ? ' ✔️ bestätigt' : '') + '</div>' +
+                            (comment.ip ? '<div class="comment-email">🌐 ' + escapeHtml(comment.ip) + '</div>' : '') +
+                            (reportsByComment[comment.id] ? '<div class="comment-email">⚑ ' + reportsByComment[comment.id].length + ' Meldung(en)</div>' : '') +
                             '<div class="comment-post-id">📝 ' + escapeHtml(comment.post_id) + '</div>' +
+                            (comment.parent_id ? ' <div class="comment-post-id">↪️ Antwort auf #' + comment.parent_id + '</div>' : '') +
+                            (comment.deleted_at ? '<div class="comment-email">🗑️ Im Papierkorb seit ' + new Date(comment.deleted_at).toLocaleString('de-DE') + '</div>' : '') +
+                            (comment.decision_reason ? '<div class="comment-email">🤝 ' + escapeHtml(comment.decision_reason) + '</div>' : '') +
                         '</div>' +
                         '<div class="comment-actions">' +
-                            '<button class="status-toggle ' + (comment.active ? 'active' : 'inactive') + '" onclick="toggleCommentStatus(' + comment.id + ', ' + !comment.active + ')">' +
-                                (comment.active ? '✅ Aktiv' : '❌ Inaktiv') +
-                            '</button>' +
+                            '<span class="status-badge ' + status + '">' + STATUS_LABELS[status] + '</span>' +
+                            (comment.shadowed ? '<span class="status-badge spam" title="Nur für den Autor sichtbar">👻 Shadow</span>' : '') +
+                            actions +
+                            '<button class="status-toggle inactive" onclick="openBanForm(' + comment.id + ')">🚫 Autor sperren</button>' +
+                            '<button class="status-toggle inactive" onclick="trustAuthor(' + comment.id + ')">🤝 Autor vertrauen</button>' +
+                            '<button class="status-toggle inactive" onclick="showCommentHistory(' + comment.id + ')">📜 Verlauf</button>' +
                         '</div>' +
                     '</div>' +
+                    '<div id="banForm-' + comment.id + '"></div>' +
                     '<div class="comment-text">' + escapeHtml(comment.text) + '</div>' +
+                    (comment.spam_score || (comment.spam_reasons || []).length ?
+                        '<div class="spam-info">🛡️ Spam-Score: ' + Number(comment.spam_score || 0).toFixed(1) +
+                            ((comment.spam_reasons || []).length ? '<ul>' + comment.spam_reasons.map(r => '<li>' + escapeHtml(r) + '</li>').join('') + '</ul>' : '') +
+                        '</div>' : '') +
                     '<div class="comment-footer">' +
                         '<div class="comment-date">🕒 ' + formattedDate + '</div>' +
                         '<div class="comment-id">ID: ' + comment.id + '</div>' +
@@ -1239,19 +2875,42 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             container.innerHTML = commentsHTML;
         }
 
-        async function toggleCommentStatus(commentId, newStatus) {
+        // Moderationsstatus (entspricht statusTransitions im Server)
+        const STATUS_LABELS = {
+            pending: '⏳ Ausstehend',
+            approved: '✅ Freigegeben',
+            rejected: '🚫 Abgelehnt',
+            spam: '🤖 Spam',
+            deleted: '🗑️ Papierkorb'
+        };
+        const STATUS_ACTIONS = {
+            approved: 'Freigeben',
+            rejected: 'Ablehnen',
+            spam: 'Spam',
+            deleted: 'In den Papierkorb'
+        };
+        const STATUS_TRANSITIONS = {
+            pending: ['approved', 'rejected', 'spam', 'deleted'],
+            approved: ['rejected', 'spam', 'deleted'],
+            rejected: ['approved', 'spam', 'deleted'],
+            spam: ['approved', 'rejected', 'deleted'],
+            deleted: []
+        };
+
+        async function setCommentStatus(commentId, newStatus) {
             const result = await apiCall(API_BASE + '/' + commentId + '/status', {
                 method: 'PUT',
-                body: JSON.stringify({ active: newStatus })
+                body: JSON.stringify({ status: newStatus })
             });
 
             if (result) {
                 const comment = allComments.find(c => c.id === commentId);
                 if (comment) {
-                    comment.active = newStatus;
+                    comment.status = result.status;
+                    comment.active = result.active;
                 }
                 
-                showMessage('Kommentar ' + (newStatus ? 'aktiviert' : 'deaktiviert'), 'success');
+                showMessage('Kommentar #' + commentId + ': ' + STATUS_LABELS[result.status], 'success');
                 filterComments();
                 
                 const adminInfo = await apiCall(API_BASE + '/admin/info');
@@ -1261,6 +2920,386 @@ func (h *CommentHandler) AdminPanelHandler(w http.ResponseWriter, r *http.Reques
             }
         }
 
+        // Vertrauen: Kommentare des Autors künftig direkt freigeben (Override "trusted")
+        async function trustAuthor(commentId) {
+            const comment = allComments.find(c => c.id === commentId);
+            if (!comment || !confirm('Künftige Kommentare von ' + comment.mailaddress + ' direkt freigeben?')) {
+                return;
+            }
+
+            const result = await apiCall(API_BASE + '/admin/trust/' + encodeURIComponent(comment.mailaddress), {
+                method: 'PUT',
+                body: JSON.stringify({ override: 'trusted' })
+            });
+            if (result) {
+                showMessage('🤝 ' + escapeHtml(result.email) + ' ist vertrauenswürdig (' + result.approved + ' freigegeben, ' + result.rejected + ' abgelehnt)', 'success');
+            }
+        }
+
+        // Papierkorb: Wiederherstellen bzw. vor Ablauf der Frist endgültig löschen
+        async function restoreComment(commentId) {
+            const result = await apiCall(API_BASE + '/admin/trash/' + commentId + '/restore', { method: 'POST' });
+            if (result) {
+                const comment = allComments.find(c => c.id === commentId);
+                if (comment) {
+                    comment.status = result.status;
+                    comment.active = result.active;
+                    delete comment.deleted_at;
+                    delete comment.deleted_from;
+                }
+
+                showMessage('Kommentar #' + commentId + ' wiederhergestellt: ' + STATUS_LABELS[result.status], 'success');
+                filterComments();
+
+                const adminInfo = await apiCall(API_BASE + '/admin/info');
+                if (adminInfo) {
+                    updateStats(adminInfo);
+                }
+            }
+        }
+
+        async function purgeComment(commentId) {
+            if (!confirm('Kommentar #' + commentId + ' endgültig löschen? Das kann nicht rückgängig gemacht werden.')) {
+                return;
+            }
+
+            const result = await apiCall(API_BASE + '/admin/trash/' + commentId, { method: 'DELETE' });
+            if (result) {
+                allComments = allComments.filter(c => c.id !== commentId);
+                selectedIds.delete(commentId);
+                showMessage('Kommentar #' + commentId + ' endgültig gelöscht', 'success');
+                filterComments();
+
+                const adminInfo = await apiCall(API_BASE + '/admin/info');
+                if (adminInfo) {
+                    updateStats(adminInfo);
+                }
+            }
+        }
+
+        // Mehrfachauswahl und Bulk-Moderation (POST /api/comments/bulk)
+        let selectedIds = new Set();
+        let displayedIds = [];
+
+        function toggleSelect(commentId, checked) {
+            if (checked) {
+                selectedIds.add(commentId);
+            } else {
+                selectedIds.delete(commentId);
+            }
+            updateBulkBar();
+        }
+
+        function toggleSelectAll(checked) {
+            displayedIds.forEach(id => checked ? selectedIds.add(id) : selectedIds.delete(id));
+            filterComments();
+        }
+
+        function updateBulkBar() {
+            document.getElementById('selectedCount').textContent = selectedIds.size;
+            document.getElementById('selectAll').checked = displayedIds.length > 0 && displayedIds.every(id => selectedIds.has(id));
+
+            const postFilter = document.getElementById('postFilter').value;
+            const pending = allComments.filter(c => c.post_id === postFilter && c.status === 'pending').length;
+            const button = document.getElementById('approvePostBtn');
+            button.style.display = postFilter !== 'all' && pending > 0 ? 'inline-block' : 'none';
+            button.textContent = '✅ Alle ' + pending + ' ausstehenden dieses Posts freigeben';
+        }
+
+        async function bulkModerate(action, ids) {
+            ids = ids || Array.from(selectedIds);
+            if (ids.length === 0) {
+                showMessage('Keine Kommentare ausgewählt');
+                return;
+            }
+            if (action === 'delete' && !confirm(ids.length + ' Kommentar(e) in den Papierkorb verschieben?')) {
+                return;
+            }
+
+            const result = await apiCall(API_BASE + '/bulk', {
+                method: 'POST',
+                body: JSON.stringify({ ids: ids, action: action })
+            });
+            if (!result) {
+                return;
+            }
+
+            const failed = [];
+            (result.results || []).forEach(item => {
+                const comment = allComments.find(c => c.id === item.id);
+                if (item.ok && comment) {
+                    comment.status = item.status;
+                    comment.active = item.status === 'approved';
+                }
+                if (item.ok) {
+                    selectedIds.delete(item.id);
+                } else {
+                    failed.push('#' + item.id + ': ' + escapeHtml(item.error));
+                }
+            });
+
+            showMessage(result.succeeded + ' Kommentar(e) aktualisiert' + (failed.length ? ', fehlgeschlagen: ' + failed.join('; ') : ''), failed.length ? 'error' : 'success');
+            filterComments();
+
+            const adminInfo = await apiCall(API_BASE + '/admin/info');
+            if (adminInfo) {
+                updateStats(adminInfo);
+            }
+        }
+
+        function approvePendingOnPost() {
+            const postFilter = document.getElementById('postFilter').value;
+            const ids = allComments.filter(c => c.post_id === postFilter && c.status === 'pending').map(c => c.id);
+            if (ids.length === 0 || !confirm(ids.length + ' ausstehende Kommentar(e) von ' + postFilter + ' freigeben?')) {
+                return;
+            }
+            bulkModerate('approve', ids);
+        }
+
+        // Sperrliste
+        const BAN_TYPES = {
+            ip: 'IP',
+            cidr: 'Netz',
+            email: 'E-Mail',
+            email_domain: 'E-Mail-Domain',
+            username: 'Name (Regex)'
+        };
+
+        const BAN_ACTIONS = {
+            reject: '⛔ Ablehnen',
+            spam: '🤖 Als Spam annehmen',
+            shadow: '👻 Shadow-Ban'
+        };
+
+        async function loadBans() {
+            const result = await apiCall(API_BASE + '/admin/bans');
+            if (!result) {
+                return;
+            }
+
+            const rules = result.items || [];
+            document.getElementById('bansSection').style.display = 'block';
+            document.getElementById('bansCount').textContent = rules.filter(r => !r.expired).length;
+
+            if (rules.length === 0) {
+                document.getElementById('bansContainer').innerHTML = '<p>Keine Sperrregeln.</p>';
+                return;
+            }
+
+            document.getElementById('bansContainer').innerHTML = '<table>' +
+                '<tr><th>ID</th><th>Typ</th><th>Wert</th><th>Aktion</th><th>Grund</th><th>Bis</th><th></th></tr>' +
+                rules.map(rule =>
+                    '<tr class="' + (rule.expired ? 'expired' : '') + '">' +
+                        '<td>' + rule.id + '</td>' +
+                        '<td>' + escapeHtml(BAN_TYPES[rule.type] || rule.type) + '</td>' +
+                        '<td><code>' + escapeHtml(rule.value) + '</code></td>' +
+                        '<td>' + escapeHtml(BAN_ACTIONS[rule.action] || rule.action) + '</td>' +
+                        '<td>' + escapeHtml(rule.reason || '') + '</td>' +
+                        '<td>' + (rule.expires_at ? new Date(rule.expires_at).toLocaleString('de-DE') + (rule.expired ? ' (abgelaufen)' : '') : 'unbefristet') + '</td>' +
+                        '<td><button class="status-toggle inactive" onclick="deleteBan(' + rule.id + ')">Aufheben</button></td>' +
+                    '</tr>'
+                ).join('') +
+            '</table>';
+        }
+
+        function escapeRegExp(text) {
+            return text.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+        }
+
+        function openBanForm(commentId) {
+            const comment = allComments.find(c => c.id === commentId);
+            const target = document.getElementById('banForm-' + commentId);
+            if (!comment || !target) {
+                return;
+            }
+            if (target.innerHTML) {
+                target.innerHTML = '';
+                return;
+            }
+
+            const mail = (comment.mailaddress || '').toLowerCase();
+            const options = [];
+            if (comment.ip) {
+                options.push(['ip', comment.ip, 'IP ' + comment.ip]);
+            }
+            options.push(['email', mail, 'E-Mail ' + mail]);
+            if (mail.includes('@')) {
+                const domain = mail.split('@').pop();
+                options.push(['email_domain', domain, 'Domain ' + domain]);
+            }
+            options.push(['username', '(?i)^' + escapeRegExp(comment.username) + '$', 'Name ' + comment.username]);
+
+            target.innerHTML = '<div class="ban-form">' +
+                '<select id="banTarget-' + commentId + '">' +
+                    options.map((o, i) => '<option value="' + i + '">' + escapeHtml(o[2]) + '</option>').join('') +
+                '</select>' +
+                '<select id="banAction-' + commentId + '">' +
+                    '<option value="reject">⛔ Ablehnen</option>' +
+                    '<option value="spam">🤖 Als Spam annehmen</option>' +
+                    '<option value="shadow">👻 Shadow-Ban (nur für Autor sichtbar)</option>' +
+                '</select>' +
+                '<select id="banDuration-' + commentId + '">' +
+                    '<option value="24h">24 Stunden</option>' +
+                    '<option value="168h">7 Tage</option>' +
+                    '<option value="720h">30 Tage</option>' +
+                    '<option value="">Unbefristet</option>' +
+                '</select>' +
+                '<input type="text" id="banReason-' + commentId + '" placeholder="Grund (optional)">' +
+                '<button class="status-toggle inactive" id="banSubmit-' + commentId + '">Sperren</button>' +
+            '</div>';
+
+            document.getElementById('banSubmit-' + commentId).addEventListener('click', () => {
+                const option = options[document.getElementById('banTarget-' + commentId).value];
+                createBan(commentId, {
+                    type: option[0],
+                    value: option[1],
+                    action: document.getElementById('banAction-' + commentId).value,
+                    expires_in: document.getElementById('banDuration-' + commentId).value,
+                    reason: document.getElementById('banReason-' + commentId).value || ('Kommentar #' + commentId)
+                });
+            });
+        }
+
+        async function createBan(commentId, rule) {
+            const result = await apiCall(API_BASE + '/admin/bans', {
+                method: 'POST',
+                body: JSON.stringify(rule)
+            });
+
+            if (result) {
+                document.getElementById('banForm-' + commentId).innerHTML = '';
+                showMessage('Sperrregel #' + result.id + ' angelegt: ' + (BAN_TYPES[result.type] || result.type) + ' ' + result.value, 'success');
+                loadBans();
+            }
+        }
+
+        // Meldungen von Lesern, gruppiert nach Kommentar
+        let reportsByComment = {};
+
+        async function loadReports() {
+            const result = await apiCall(API_BASE + '/admin/reports');
+            if (!result) {
+                return;
+            }
+
+            reportsByComment = {};
+            (result.items || []).forEach(report => {
+                (reportsByComment[report.comment_id] = reportsByComment[report.comment_id] || []).push(report);
+            });
+            const commentIds = Object.keys(reportsByComment);
+
+            document.getElementById('reportsSection').style.display = 'block';
+            document.getElementById('reportsCount').textContent = commentIds.length;
+            filterComments();
+
+            if (commentIds.length === 0) {
+                document.getElementById('reportsContainer').innerHTML = '<p>Keine Meldungen.</p>';
+                return;
+            }
+
+            document.getElementById('reportsContainer').innerHTML = '<table>' +
+                '<tr><th>Kommentar</th><th>Status</th><th>Meldungen</th><th></th></tr>' +
+                commentIds.map(commentId => {
+                    const comment = allComments.find(c => c.id === Number(commentId));
+                    const reports = reportsByComment[commentId];
+                    return '<tr>' +
+                        '<td>#' + commentId + (comment ? ' ' + escapeHtml(comment.username) + ': ' + escapeHtml(comment.text.substring(0, 80)) : '') + '</td>' +
+                        '<td>' + (comment ? STATUS_LABELS[comment.status] || comment.status : '-') + '</td>' +
+                        '<td><ul>' + reports.map(report =>
+                            '<li>' + escapeHtml(report.reason) + ' <small>(' + new Date(report.created_at).toLocaleString('de-DE') + ', ' + escapeHtml(report.ip || '') + ')</small> ' +
+                            '<a href="#" onclick="deleteReport(' + report.id + '); return false;">verwerfen</a></li>'
+                        ).join('') + '</ul></td>' +
+                        '<td><button class="status-toggle inactive" onclick="dismissReports(' + commentId + ')">Alle verwerfen</button></td>' +
+                    '</tr>';
+                }).join('') +
+            '</table>';
+        }
+
+        async function deleteReport(reportId) {
+            const result = await apiCall(API_BASE + '/admin/reports/' + reportId, { method: 'DELETE' });
+            if (result) {
+                showMessage('Meldung #' + reportId + ' verworfen', 'success');
+                loadReports();
+            }
+        }
+
+        async function dismissReports(commentId) {
+            if (!confirm('Alle Meldungen zu Kommentar #' + commentId + ' verwerfen?')) {
+                return;
+            }
+
+            const result = await apiCall(API_BASE + '/admin/comments/' + commentId + '/reports', { method: 'DELETE' });
+            if (result) {
+                showMessage('Meldungen zu Kommentar #' + commentId + ' verworfen', 'success');
+                loadReports();
+            }
+        }
+
+        // Audit-Log: wer hat wann welchen Status gesetzt oder gelöscht
+        async function loadAudit() {
+            const params = new URLSearchParams({ limit: '100' });
+            const filters = { comment_id: 'auditComment', post_id: 'auditPost', actor: 'auditActor' };
+            Object.keys(filters).forEach(key => {
+                const value = document.getElementById(filters[key]).value.trim();
+                if (value) {
+                    params.set(key, value);
+                }
+            });
+
+            const result = await apiCall(API_BASE + '/admin/audit?' + params.toString());
+            if (!result) {
+                return;
+            }
+
+            const entries = result.items || [];
+            if (entries.length === 0) {
+                document.getElementById('auditContainer').innerHTML = '<p>Keine Einträge.</p>';
+                return;
+            }
+
+            document.getElementById('auditContainer').innerHTML = '<table>' +
+                '<tr><th>Zeit</th><th>Kommentar</th><th>Post</th><th>Änderung</th><th>Moderator</th><th>IP</th></tr>' +
+                entries.map(entry =>
+                    '<tr>' +
+                        '<td>' + new Date(entry.created_at).toLocaleString('de-DE') + '</td>' +
+                        '<td>#' + entry.comment_id + '</td>' +
+                        '<td>' + escapeHtml(entry.post_id) + '</td>' +
+                        '<td>' + (entry.action === 'restore' ? '♻️ ' : '') +
+                            (STATUS_LABELS[entry.old_status] || escapeHtml(entry.old_status)) + ' → ' +
+                            (entry.action === 'delete' ? '❌ endgültig gelöscht' : STATUS_LABELS[entry.new_status] || escapeHtml(entry.new_status)) + '</td>' +
+                        '<td>' + escapeHtml(entry.actor) + '</td>' +
+                        '<td>' + escapeHtml(entry.ip || '') + '</td>' +
+                    '</tr>'
+                ).join('') +
+            '</table>' +
+            (result.total > entries.length ? '<p>' + entries.length + ' von ' + result.total + ' Einträgen</p>' : '');
+        }
+
+        function showCommentHistory(commentId) {
+            document.getElementById('auditComment').value = commentId;
+            document.getElementById('auditPost').value = '';
+            document.getElementById('auditActor').value = '';
+            const details = document.getElementById('auditDetails');
+            if (details.open) {
+                loadAudit();
+            } else {
+                details.open = true;
+            }
+            document.getElementById('auditSection').scrollIntoView({ behavior: 'smooth' });
+        }
+
+        async function deleteBan(banId) {
+            if (!confirm('Sperrregel #' + banId + ' aufheben?')) {
+                return;
+            }
+
+            const result = await apiCall(API_BASE + '/admin/bans/' + banId, { method: 'DELETE' });
+            if (result) {
+                showMessage('Sperrregel #' + banId + ' aufgehoben', 'success');
+                loadBans();
+            }
+        }
+
         function toggleAutoRefresh() {
             const checkbox = document.getElementById('autoRefresh');
             
@@ -1327,6 +3366,7 @@ func (h *CommentHandler) AdminInfoHandler(w http.ResponseWriter, r *http.Request
 	// Statistiken berechnen
 	activeCount := 0
 	inactiveCount := 0
+	statusCounts := newCommentCounts().ByStatus
 	postIds := make(map[string]int)
 	recentComments := 0
 
@@ -1339,6 +3379,7 @@ func (h *CommentHandler) AdminInfoHandler(w http.ResponseWriter, r *http.Request
 		} else {
 			inactiveCount++
 		}
+		statusCounts[comment.Status]++
 
 		// Posts zählen
 		postIds[comment.PostID]++
@@ -1381,6 +3422,7 @@ func (h *CommentHandler) AdminInfoHandler(w http.ResponseWriter, r *http.Request
 		"total_comments":    len(allComments),
 		"active_comments":   activeCount,
 		"inactive_comments": inactiveCount,
+		"status_counts":     statusCounts,
 		"unique_posts":      len(postIds),
 		"recent_comments":   recentComments, // Letzte 24h
 		"top_posts":         topPosts,
@@ -1584,16 +3626,16 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// Erweiterte Health Check mit Redis-Status
+// Erweiterte Health Check mit Storage-Status
 func healthCheckWithRedisHandler(commentService *CommentService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		// Redis-Verbindung testen
+		// Storage-Verbindung testen
 		redisStatus := "healthy"
 		redisError := ""
 
-		_, err := commentService.client.Ping(commentService.ctx).Result()
+		err := commentService.store.Ping()
 		if err != nil {
 			redisStatus = "unhealthy"
 			redisError = err.Error()
@@ -1618,7 +3660,7 @@ func healthCheckWithRedisHandler(commentService *CommentService) http.HandlerFun
 			"timestamp": time.Now().UTC().Format(time.RFC3339),
 			"uptime":    uptime.String(),
 			"dependencies": map[string]interface{}{
-				"redis": map[string]interface{}{
+				commentService.dependencyName(): map[string]interface{}{
 					"status": redisStatus,
 					"error":  redisError,
 				},
@@ -1647,8 +3689,8 @@ func readinessCheckHandler(commentService *CommentService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		// Redis-Verbindung testen
-		_, err := commentService.client.Ping(commentService.ctx).Result()
+		// Storage-Verbindung testen
+		err := commentService.store.Ping()
 		if err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
 			response := map[string]interface{}{
@@ -1686,22 +3728,20 @@ func metricsHandler(commentService *CommentService) http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 
 		// Kommentar-Statistiken
+		counts, err := commentService.CountComments()
+		if err != nil {
+			http.Error(w, "Fehler beim Abrufen der Metriken", http.StatusInternalServerError)
+			return
+		}
+
 		allComments, err := commentService.GetAllComments(true)
 		if err != nil {
 			http.Error(w, "Fehler beim Abrufen der Metriken", http.StatusInternalServerError)
 			return
 		}
 
-		activeCount := 0
-		inactiveCount := 0
 		postIds := make(map[string]int)
-
 		for _, comment := range allComments {
-			if comment.Active {
-				activeCount++
-			} else {
-				inactiveCount++
-			}
 			postIds[comment.PostID]++
 		}
 
@@ -1711,9 +3751,10 @@ func metricsHandler(commentService *CommentService) http.HandlerFunc {
 
 		response := map[string]interface{}{
 			"metrics": map[string]interface{}{
-				"comments_total":      len(allComments),
-				"comments_active":     activeCount,
-				"comments_inactive":   inactiveCount,
+				"comments_total":      counts.Total,
+				"comments_active":     counts.Active,
+				"comments_inactive":   counts.Inactive,
+				"comments_by_status":  counts.ByStatus,
 				"posts_with_comments": len(postIds),
 				"uptime_seconds":      time.Since(startTime).Seconds(),
 				"memory_bytes":        memStats.Alloc,
@@ -1756,14 +3797,28 @@ func (h *CommentHandler) JSWidgetHandler(w http.ResponseWriter, r *http.Request)
 
 	// Template-Daten
 	data := JSWidgetTemplateData{
-		ApiUrl:  apiUrl,
-		Version: version,
-		Stage:   stage,
+		ApiUrl:        apiUrl,
+		Version:       version,
+		Stage:         stage,
+		MaxReplyDepth: h.service.maxReplyDepth,
+		PowEnabled:    h.pow != nil,
+
+		SubscriptionsEnabled: h.service.subscriptions != nil,
+		Credentials:          h.credentials,
 	}
 
 	// Korrekte Headers für JavaScript
 	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
 	w.Header().Set("Cache-Control", "public, max-age=1800") // 30 Minuten Cache
+
+	if h.guard != nil {
+		// Jede Auslieferung bekommt ein eigenes Token, daher nicht cachen
+		if data.FormToken, err = h.guard.Issue(); err != nil {
+			http.Error(w, "Fehler beim Erstellen des Formular-Tokens", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+	}
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
 
@@ -1874,9 +3929,10 @@ func validateJSTemplate() error {
 
 	// Test-Daten für Validation
 	testData := JSWidgetTemplateData{
-		ApiUrl:  "https://example.com/api/comments",
-		Version: "1.0.0",
-		Stage:   "test",
+		ApiUrl:        "https://example.com/api/comments",
+		Version:       "1.0.0",
+		Stage:         "test",
+		MaxReplyDepth: 3,
 	}
 
 	// Template mit Test-Daten ausführen (Dry-Run)
@@ -1938,27 +3994,77 @@ func enableTemplateHotReload() {
 	log.Println("🔥 Template Hot-Reload aktiviert (Development Mode)")
 }
 
+// runAdminCommand führt einmalige Wartungskommandos aus
+func runAdminCommand(store CommentStore, args []string) error {
+	valkeyStore, ok := store.(*ValkeyStore)
+	if !ok {
+		return fmt.Errorf("kommando %s ist nur mit STORAGE_BACKEND=%s verfügbar", args[0], BackendValkey)
+	}
+
+	switch args[0] {
+	case "migrate":
+		log.Println("🔄 Migriere Kommentare auf Hash-Format...")
+		migrated, err := valkeyStore.MigrateLegacyComments()
+		if err != nil {
+			return fmt.Errorf("migration fehlgeschlagen: %w", err)
+		}
+		log.Printf("✅ Migration abgeschlossen: %d Kommentare umgewandelt", migrated)
+		return nil
+	case "rebuild-index":
+		log.Println("🔄 Baue Kommentar-Index neu auf...")
+		indexed, err := valkeyStore.RebuildIndex()
+		if err != nil {
+			return fmt.Errorf("index-aufbau fehlgeschlagen: %w", err)
+		}
+		log.Printf("✅ Index aufgebaut: %d Kommentare indiziert", indexed)
+		return nil
+	default:
+		return fmt.Errorf("unbekanntes Kommando: %s (verfügbar: migrate, rebuild-index)", args[0])
+	}
+}
+
 func main() {
 	// Environment Variablen lesen
-	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
-	redisPassword := getEnv("REDIS_PASSWORD", "")
-	redisDB := getEnvAsInt("REDIS_DB", 0)
+	storageBackend := getEnv("STORAGE_BACKEND", BackendValkey)
 	port := getEnv("PORT", "8080")
 
 	log.Printf("🚀 Starting Comment API %s (%s)", version, stage)
-	log.Printf("📡 Connecting to Redis: %s", redisAddr)
+	log.Printf("💾 Storage backend: %s", storageBackend)
 
 	// Auth-System initialisieren
 	auth := NewTokenAuth()
 	log.Printf("🔐 Authentication: %v", auth.Enabled)
-	commentService := NewCommentService(redisAddr, redisPassword, redisDB)
 
-	// Verbindung testen
-	_, err := commentService.client.Ping(commentService.ctx).Result()
+	store, err := newCommentStore(storageBackend)
 	if err != nil {
-		log.Fatal("❌ Redis connection failed:", err)
+		log.Fatal("❌ Storage-Initialisierung fehlgeschlagen:", err)
+	}
+
+	// Verbindung testen
+	if err := store.Ping(); err != nil {
+		log.Fatal("❌ Storage connection failed:", err)
+	}
+	log.Println("✅ Storage connection successful")
+
+	// Admin-Kommandos (z.B. "./server migrate") statt Serverstart
+	if len(os.Args) > 1 {
+		if err := runAdminCommand(store, os.Args[1:]); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		return
+	}
+
+	if valkeyStore, ok := store.(*ValkeyStore); ok {
+		if err := valkeyStore.EnsureSchema(); err != nil {
+			log.Fatal("❌ Schema-Prüfung fehlgeschlagen:", err)
+		}
+		if err := valkeyStore.EnsureIndex(); err != nil {
+			log.Fatal("❌ Index-Prüfung fehlgeschlagen:", err)
+		}
 	}
-	log.Println("✅ Redis connection successful")
+
+	commentService := NewCommentService(store, storageBackend)
+	startTrashPurge(commentService)
 
 	// Template-Setup
 	if err := setupTemplateDirectory(); err != nil {
@@ -1973,17 +4079,103 @@ func main() {
 	// Hot-Reload im Development Mode
 	enableTemplateHotReload()
 
-	handler := NewCommentHandler(commentService)
+	trustedProxies, err := parseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// Rate-Limiting (Valkey, sonst In-Memory)
+	auxClient := auxRedisClient(store)
+	var rateLimitStore RateLimitStore = NewMemoryRateLimitStore()
+	if auxClient != nil {
+		rateLimitStore = NewValkeyRateLimitStore(auxClient)
+	}
+	limiter, err := NewRateLimiter(rateLimitStore, trustedProxies)
+	if err != nil {
+		log.Fatal("❌ Rate-Limit-Konfiguration ungültig:", err)
+	}
+	log.Printf("🚦 Rate limiting: %v", limiter != nil)
+
+	// Honeypot und Formular-Token (verbrauchte Tokens in Valkey, sonst In-Memory)
+	var formTokenStore NonceStore = NewMemoryNonceStore()
+	if auxClient != nil {
+		formTokenStore = NewValkeyNonceStore(auxClient, "formtoken:")
+	}
+	guard, err := NewFormGuard(formTokenStore)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	log.Printf("🍯 Form guard: %v", guard != nil)
+
+	// Proof-of-Work (Lösungen und Aktivität pro IP in Valkey, sonst In-Memory)
+	var powUsed NonceStore = NewMemoryNonceStore()
+	var powActivity PowActivityStore = NewMemoryPowActivityStore()
+	if auxClient != nil {
+		powUsed = NewValkeyNonceStore(auxClient, "pow:used:")
+		powActivity = NewValkeyPowActivityStore(auxClient)
+	}
+	pow, err := NewPowChallenger(powUsed, powActivity)
+	if err != nil {
+		log.Fatal("❌ PoW-Konfiguration ungültig:", err)
+	}
+	log.Printf("⛏️  Proof-of-work: %v", pow != nil)
+
+	// Signiertes Cookie mit den eigenen Kommentaren (Shadow-Ban)
+	ownComments, err := NewOwnCommentsCookie()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	// Signierte Moderationslinks und E-Mail-Benachrichtigung über neue Kommentare
+	moderationLinks, err := NewModerationLinks()
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+	mailer, err := NewSMTPMailer()
+	if err != nil {
+		log.Fatal("❌ SMTP-Konfiguration ungültig: ", err)
+	}
+	notifier := NewSMTPNotifier(mailer, moderationLinks)
+	log.Printf("📧 SMTP notifications: %v", notifier != nil)
+
+	// Abos von Lesern: E-Mails über eine Warteschlange (Valkey, sonst In-Memory)
+	var mailQueue MailQueue = NewMemoryMailQueue()
+	if auxClient != nil {
+		mailQueue = NewValkeyMailQueue(auxClient)
+	}
+	commentService.subscriptions = NewSubscriptions(store, mailer, mailQueue, moderationLinks)
+	if commentService.subscriptions != nil {
+		worker, err := NewMailWorker(mailer, mailQueue, rateLimitStore)
+		if err != nil {
+			log.Fatal("❌ Mail-Konfiguration ungültig: ", err)
+		}
+		worker.Start()
+	}
+	log.Printf("🔔 Subscriptions: %v", commentService.subscriptions != nil)
+
+	// Webhooks: Ziele im Backend, Zustellungen in Valkey (überstehen Neustarts)
+	var webhookQueue WebhookQueue = NewMemoryWebhookQueue()
+	if auxClient != nil {
+		webhookQueue = NewValkeyWebhookQueue(auxClient)
+	}
+	commentService.webhooks = NewWebhookDispatcher(store, webhookQueue)
+	if commentService.webhooks != nil {
+		commentService.webhooks.Start()
+	}
+
+	corsOrigins := corsAllowedOrigins()
+	handler := NewCommentHandler(commentService, limiter, guard, pow, ownComments, trustedProxies, moderationLinks, notifier, corsOrigins != nil)
 
 	// Router einrichten
 	r := mux.NewRouter()
 
-	// Static Files ZUERST registrieren
-	setupStaticRoutes(r)
-
-	// JavaScript Widget Template
+	// JavaScript Widget Template vor den Static Files, sonst greift der /js/-Prefix
+	// (das Template enthält u.a. das Formular-Token)
 	r.HandleFunc("/js/comment-widget.js", handler.JSWidgetHandler).Methods("GET")
 
+	// Static Files
+	setupStaticRoutes(r)
+
 	// Health Check Routes ZUERST
 	setupHealthRoutes(r, commentService)
 
@@ -1995,26 +4187,65 @@ func main() {
 	api := r.PathPrefix("/api/comments").Subrouter()
 	api.HandleFunc("", handler.CreateCommentHandler).Methods("POST")
 	api.HandleFunc("", handler.GetCommentsHandler).Methods("GET")
+	api.HandleFunc("/form-token", handler.FormTokenHandler).Methods("GET")
+	api.HandleFunc("/challenge", handler.ChallengeHandler).Methods("GET")
 	api.HandleFunc("/{id}", handler.GetCommentHandler).Methods("GET")
+	api.HandleFunc("/{id}/report", handler.ReportCommentHandler).Methods("POST")
+	api.HandleFunc("/{id}/moderate", handler.ModerationLinkHandler).Methods("GET", "POST")
+	api.HandleFunc("/subscriptions/{id}/{action:confirm|unsubscribe}", handler.SubscriptionLinkHandler).Methods("GET", "POST")
 
 	// Geschützte Admin-Endpunkte
 	adminAPI := r.PathPrefix("/api/comments").Subrouter()
 	adminAPI.Use(auth.AuthMiddleware) // Auth-Middleware anwenden
+	adminAPI.HandleFunc("/bulk", handler.BulkModerationHandler).Methods("POST")
 	adminAPI.HandleFunc("/{id}/status", handler.UpdateCommentStatusHandler).Methods("PUT")
 	adminAPI.HandleFunc("/{id}", handler.DeleteCommentHandler).Methods("DELETE")
 	adminAPI.HandleFunc("/admin/info", handler.AdminInfoHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/comments", handler.AdminListCommentsHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/comments/{id}", handler.AdminGetCommentHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/bans", handler.AdminListBansHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/bans", handler.AdminCreateBanHandler).Methods("POST")
+	adminAPI.HandleFunc("/admin/bans/{id}", handler.AdminGetBanHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/bans/{id}", handler.AdminUpdateBanHandler).Methods("PUT")
+	adminAPI.HandleFunc("/admin/bans/{id}", handler.AdminDeleteBanHandler).Methods("DELETE")
+	adminAPI.HandleFunc("/admin/reports", handler.AdminListReportsHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/reports/{id}", handler.AdminDeleteReportHandler).Methods("DELETE")
+	adminAPI.HandleFunc("/admin/comments/{id}/reports", handler.AdminDismissReportsHandler).Methods("DELETE")
+	adminAPI.HandleFunc("/admin/audit", handler.AdminAuditHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/trash", handler.AdminListTrashHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/trash/{id}/restore", handler.AdminRestoreCommentHandler).Methods("POST")
+	adminAPI.HandleFunc("/admin/trash/{id}", handler.AdminPurgeCommentHandler).Methods("DELETE")
+	adminAPI.HandleFunc("/admin/trust", handler.AdminListTrustHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/trust/{email}", handler.AdminGetTrustHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/trust/{email}", handler.AdminSetTrustHandler).Methods("PUT")
+	adminAPI.HandleFunc("/admin/webhooks", handler.AdminListWebhooksHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/webhooks", handler.AdminCreateWebhookHandler).Methods("POST")
+	adminAPI.HandleFunc("/admin/webhooks/deliveries", handler.AdminWebhookDeliveriesHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/webhooks/dead-letters", handler.AdminDeadLettersHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/webhooks/dead-letters/{id}/retry", handler.AdminRetryDeadLetterHandler).Methods("POST")
+	adminAPI.HandleFunc("/admin/webhooks/{id:[0-9]+}", handler.AdminGetWebhookHandler).Methods("GET")
+	adminAPI.HandleFunc("/admin/webhooks/{id:[0-9]+}", handler.AdminUpdateWebhookHandler).Methods("PUT")
+	adminAPI.HandleFunc("/admin/webhooks/{id:[0-9]+}", handler.AdminDeleteWebhookHandler).Methods("DELETE")
+	adminAPI.HandleFunc("/admin/webhooks/{id:[0-9]+}/test", handler.AdminTestWebhookHandler).Methods("POST")
 
 	// Admin Panel (geschützt über HTTP Basic Auth oder Token in URL)
 	adminPanel := r.PathPrefix("/admin").Subrouter()
 	adminPanel.HandleFunc("/", handler.AdminPanelHandler).Methods("GET")
 	adminPanel.HandleFunc("/panel", handler.AdminPanelHandler).Methods("GET")
 
-	// CORS konfigurieren
-	c := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"}, // In Produktion spezifischer konfigurieren
+	// CORS konfigurieren. Cookies (Shadow-Ban, Melden) gehen cross-origin nur an
+	// ausdrücklich erlaubte Origins; bei "*" ohne Credentials, sonst könnte jede
+	// Seite Requests mit den Cookies der Leser stellen und die Antwort lesen.
+	corsOptions := cors.Options{
+		AllowedOrigins: []string{"*"},
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"*"},
-	})
+	}
+	if corsOrigins != nil {
+		corsOptions.AllowedOrigins = corsOrigins
+		corsOptions.AllowCredentials = true
+	}
+	c := cors.New(corsOptions)
 
 	corsHandler := c.Handler(r)
 
@@ -2029,6 +4260,7 @@ func main() {
 	fmt.Println("  GET    /                        - Simple Health Check")
 	fmt.Println("  POST   /api/comments            - Create Comment")
 	fmt.Println("  GET    /api/comments            - Get Comments")
+	fmt.Println("  POST   /api/comments/{id}/report - Report Comment")
 	fmt.Println("📁 Static Files:")
 	fmt.Println("  GET    /js/comment-widget.js    - Comment Widget")
 	fmt.Println("🔐 Admin:")
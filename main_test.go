@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// racingStore lässt vor jedem Moderate einen "anderen Moderator" zum Zug kommen
+type racingStore struct {
+	CommentStore
+	calls  int
+	before func(calls int) error
+}
+
+func (s *racingStore) Moderate(ops []ModerationOp) error {
+	s.calls++
+	if err := s.before(s.calls); err != nil {
+		return err
+	}
+	return s.CommentStore.Moderate(ops)
+}
+
+func TestBulkModerateReloadsOnConflict(t *testing.T) {
+	memory := NewMemoryStore()
+	first := mustCreate(t, memory, newContractComment("post-a", StatusPending, 0))
+	second := mustCreate(t, memory, newContractComment("post-a", StatusPending, 0))
+
+	// Zwischen Laden und Speichern verschiebt jemand #2 in den Papierkorb
+	store := &racingStore{CommentStore: memory, before: func(calls int) error {
+		if calls == 1 {
+			return memory.UpdateStatus(second.ID, StatusDeleted)
+		}
+		return nil
+	}}
+	cs := &CommentService{store: store}
+
+	results, err := cs.BulkModerate([]int{first.ID, second.ID}, StatusApproved, Moderator{Name: "test"})
+	if err != nil {
+		t.Fatalf("BulkModerate: %v", err)
+	}
+	if store.calls != 2 {
+		t.Fatalf("%d Versuche, erwartet 2", store.calls)
+	}
+	if len(results) != 2 || !results[0].OK || results[1].OK || results[1].Status != StatusDeleted {
+		t.Fatalf("Ergebnisse %+v, erwartet #%d freigegeben und #%d abgelehnt (deleted)", results, first.ID, second.ID)
+	}
+
+	if got, _ := memory.Get(first.ID); got.Status != StatusApproved {
+		t.Errorf("#%d: status %s, erwartet approved", first.ID, got.Status)
+	}
+	if got, _ := memory.Get(second.ID); got.Status != StatusDeleted {
+		t.Errorf("#%d: status %s, der parallele Wechsel wurde überschrieben", second.ID, got.Status)
+	}
+}
+
+func TestBulkModerateGivesUpAfterRepeatedConflicts(t *testing.T) {
+	memory := NewMemoryStore()
+	comment := mustCreate(t, memory, newContractComment("post-a", StatusPending, 0))
+
+	store := &racingStore{CommentStore: memory, before: func(int) error { return ErrModerationConflict }}
+	cs := &CommentService{store: store}
+
+	_, err := cs.BulkModerate([]int{comment.ID}, StatusApproved, Moderator{Name: "test"})
+	if !errors.Is(err, ErrModerationConflict) {
+		t.Fatalf("BulkModerate: %v, erwartet ErrModerationConflict", err)
+	}
+	if store.calls != maxBulkAttempts {
+		t.Fatalf("%d Versuche, erwartet %d", store.calls, maxBulkAttempts)
+	}
+}
+
+func TestParseCommentQueryTreeRequiresPost(t *testing.T) {
+	for target, wantErr := range map[string]bool{
+		"/api/comments?format=tree":                true,
+		"/api/comments?format=tree&post_id=post-a": false,
+		"/api/comments":                            false,
+	} {
+		_, err := parseCommentQuery(httptest.NewRequest("GET", target, nil))
+		if (err != nil) != wantErr {
+			t.Errorf("%s: Fehler %v, erwartet Fehler: %v", target, err, wantErr)
+		}
+	}
+}
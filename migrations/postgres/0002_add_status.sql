@@ -0,0 +1,7 @@
+-- Moderationsstatus (pending, approved, rejected, spam, deleted), active bleibt als Kompatibilitätsspalte
+ALTER TABLE comments ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+
+UPDATE comments SET status = 'approved' WHERE active = TRUE;
+
+CREATE INDEX IF NOT EXISTS idx_comments_status_created ON comments (status, created_unix, id);
+CREATE INDEX IF NOT EXISTS idx_comments_post_status_created ON comments (post_id, status, created_unix, id);
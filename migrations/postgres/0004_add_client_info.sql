@@ -0,0 +1,3 @@
+-- Absender-Infos für Spam-Dienste (Akismet submit-spam/submit-ham) und Moderation
+ALTER TABLE comments ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Sperrregeln für IPs, Netze, E-Mail-Adressen, Domains und Benutzernamen
+CREATE TABLE IF NOT EXISTS ban_rules (
+    id         BIGSERIAL PRIMARY KEY,
+    type       TEXT        NOT NULL,
+    value      TEXT        NOT NULL,
+    action     TEXT        NOT NULL DEFAULT 'reject',
+    reason     TEXT        NOT NULL DEFAULT '',
+    created_at TEXT        NOT NULL,
+    expires_at TEXT        NOT NULL DEFAULT ''
+);
@@ -0,0 +1,2 @@
+-- Kommentare gesperrter Absender im Shadow-Ban-Modus (nur für den Absender sichtbar)
+ALTER TABLE comments ADD COLUMN shadowed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- Meldungen von Lesern (reporter = zufällige ID aus dem Cookie comments_reader)
+CREATE TABLE IF NOT EXISTS comment_reports (
+    id         BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL,
+    reason     TEXT   NOT NULL,
+    ip         TEXT   NOT NULL DEFAULT '',
+    reporter   TEXT   NOT NULL DEFAULT '',
+    created_at TEXT   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_reports_comment ON comment_reports (comment_id);
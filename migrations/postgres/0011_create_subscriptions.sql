@@ -0,0 +1,13 @@
+-- Abos von Lesern (Double-Opt-In: Benachrichtigung erst nach Bestätigung)
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id           BIGSERIAL PRIMARY KEY,
+    email        TEXT    NOT NULL,
+    post_id      TEXT    NOT NULL,
+    comment_id   BIGINT  NOT NULL DEFAULT 0,
+    scope        TEXT    NOT NULL,
+    confirmed    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TEXT    NOT NULL,
+    confirmed_at TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_post ON subscriptions (post_id);
@@ -0,0 +1,10 @@
+-- Ausgehende Webhooks (events: kommagetrennt, z.B. "comment.created,comment.approved")
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          BIGSERIAL PRIMARY KEY,
+    url         TEXT    NOT NULL,
+    events      TEXT    NOT NULL,
+    secret      TEXT    NOT NULL,
+    description TEXT    NOT NULL DEFAULT '',
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TEXT    NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Absender hat die E-Mail-Adresse per Double-Opt-In nachgewiesen (Voraussetzung für Vertrauen)
+ALTER TABLE comments ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- Pro Kommentar zählt jede IP und jeder Browser nur einmal (atomar per Index).
+-- Doppelte Meldungen aus der Zeit davor werden vorher entfernt.
+DELETE FROM comment_reports
+WHERE id NOT IN (SELECT MIN(id) FROM comment_reports GROUP BY comment_id, ip);
+
+DELETE FROM comment_reports
+WHERE reporter <> ''
+  AND id NOT IN (SELECT MIN(id) FROM comment_reports WHERE reporter <> '' GROUP BY comment_id, reporter);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reports_ip ON comment_reports (comment_id, ip);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_reports_reporter ON comment_reports (comment_id, reporter) WHERE reporter <> '';
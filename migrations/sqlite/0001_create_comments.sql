@@ -0,0 +1,16 @@
+-- Kommentare inkl. Antworten (parent_id 0 = Top-Level)
+CREATE TABLE IF NOT EXISTS comments (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id      TEXT    NOT NULL,
+    parent_id    INTEGER NOT NULL DEFAULT 0,
+    username     TEXT    NOT NULL,
+    mailaddress  TEXT    NOT NULL,
+    text         TEXT    NOT NULL,
+    active       INTEGER NOT NULL DEFAULT 0,
+    created_at   TEXT    NOT NULL,
+    created_unix INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_created ON comments (post_id, created_unix, id);
+CREATE INDEX IF NOT EXISTS idx_comments_created ON comments (created_unix, id);
+CREATE INDEX IF NOT EXISTS idx_comments_active_created ON comments (active, created_unix, id);
@@ -0,0 +1,3 @@
+-- Ergebnis der Spam-Pipeline (spam_reasons als JSON-Array)
+ALTER TABLE comments ADD COLUMN spam_score REAL NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN spam_reasons TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,16 @@
+-- Audit-Log der Moderation, nur INSERT (new_status leer = gelöscht)
+CREATE TABLE IF NOT EXISTS moderation_audit (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INTEGER NOT NULL,
+    post_id    TEXT    NOT NULL DEFAULT '',
+    action     TEXT    NOT NULL,
+    actor      TEXT    NOT NULL,
+    old_status TEXT    NOT NULL DEFAULT '',
+    new_status TEXT    NOT NULL DEFAULT '',
+    ip         TEXT    NOT NULL DEFAULT '',
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_audit_comment ON moderation_audit (comment_id);
+CREATE INDEX IF NOT EXISTS idx_moderation_audit_post ON moderation_audit (post_id);
+CREATE INDEX IF NOT EXISTS idx_moderation_audit_actor ON moderation_audit (actor);
@@ -0,0 +1,3 @@
+-- Papierkorb: Zeitpunkt des Löschens und Status davor (für Wiederherstellen und Purge)
+ALTER TABLE comments ADD COLUMN deleted_at TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN deleted_from TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Vertrauen pro E-Mail-Adresse (Zähler der Moderationsentscheidungen, Override)
+CREATE TABLE IF NOT EXISTS author_trust (
+    email      TEXT    PRIMARY KEY,
+    approved   INTEGER NOT NULL DEFAULT 0,
+    rejected   INTEGER NOT NULL DEFAULT 0,
+    override   TEXT    NOT NULL DEFAULT '',
+    updated_at TEXT    NOT NULL DEFAULT ''
+);
+
+-- Begründung für einen automatisch gesetzten Startstatus
+ALTER TABLE comments ADD COLUMN decision_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,13 @@
+-- Abos von Lesern (Double-Opt-In: Benachrichtigung erst nach Bestätigung)
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    email        TEXT    NOT NULL,
+    post_id      TEXT    NOT NULL,
+    comment_id   INTEGER NOT NULL DEFAULT 0,
+    scope        TEXT    NOT NULL,
+    confirmed    INTEGER NOT NULL DEFAULT 0,
+    created_at   TEXT    NOT NULL,
+    confirmed_at TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_post ON subscriptions (post_id);
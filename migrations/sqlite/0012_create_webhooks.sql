@@ -0,0 +1,10 @@
+-- Ausgehende Webhooks (events: kommagetrennt, z.B. "comment.created,comment.approved")
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    url         TEXT    NOT NULL,
+    events      TEXT    NOT NULL,
+    secret      TEXT    NOT NULL,
+    description TEXT    NOT NULL DEFAULT '',
+    active      INTEGER NOT NULL DEFAULT 1,
+    created_at  TEXT    NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Chat-Formate für Webhooks (json, slack, matrix, ntfy, gotify) und Zugangstoken des Dienstes
+ALTER TABLE webhooks ADD COLUMN format TEXT NOT NULL DEFAULT 'json';
+ALTER TABLE webhooks ADD COLUMN token TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceStore merkt sich verbrauchte Einmalwerte (Formular-Tokens, PoW-Lösungen).
+// MarkUsed liefert false, wenn die Nonce bereits verwendet wurde.
+type NonceStore interface {
+	MarkUsed(nonce string, ttl time.Duration) (bool, error)
+}
+
+// ValkeyNonceStore speichert verbrauchte Nonces als {prefix}{nonce} mit TTL
+type ValkeyNonceStore struct {
+	client *redis.Client
+	ctx    context.Context
+	prefix string
+}
+
+// NewValkeyNonceStore erstellt einen ValkeyNonceStore mit Key-Prefix (z.B. "formtoken:")
+func NewValkeyNonceStore(client *redis.Client, prefix string) *ValkeyNonceStore {
+	return &ValkeyNonceStore{client: client, ctx: context.Background(), prefix: prefix}
+}
+
+// MarkUsed setzt die Nonce per SET NX
+func (s *ValkeyNonceStore) MarkUsed(nonce string, ttl time.Duration) (bool, error) {
+	fresh, err := s.client.SetNX(s.ctx, s.prefix+nonce, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Prüfen der Nonce: %w", err)
+	}
+	return fresh, nil
+}
+
+// MemoryNonceStore hält verbrauchte Nonces im Prozessspeicher (nur Einzelinstanz)
+type MemoryNonceStore struct {
+	mutex sync.Mutex
+	used  map[string]time.Time // Nonce -> Ablauf
+}
+
+// NewMemoryNonceStore erstellt einen leeren MemoryNonceStore
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{used: make(map[string]time.Time)}
+}
+
+// MarkUsed merkt sich die Nonce bis zum Ablauf
+func (s *MemoryNonceStore) MarkUsed(nonce string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if expires, ok := s.used[nonce]; ok && now.Before(expires) {
+		return false, nil
+	}
+
+	// Abgelaufene Nonces gelegentlich aufräumen
+	if len(s.used) > 10000 {
+		for key, expires := range s.used {
+			if now.After(expires) {
+				delete(s.used, key)
+			}
+		}
+	}
+
+	s.used[nonce] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidModerationLink: Signatur falsch, Link abgelaufen oder Aktion unbekannt
+var ErrInvalidModerationLink = errors.New("ungültiger oder abgelaufener moderationslink")
+
+// linkActions sind die Aktionen, die per Link aus der E-Mail ausgelöst werden können
+var linkActions = map[string]CommentStatus{
+	"approve": StatusApproved,
+	"reject":  StatusRejected,
+}
+
+// linkModerator ist der Auslöser im Audit-Log für Aktionen per E-Mail-Link
+const linkModerator = "mail-link"
+
+// ModerationLinks signiert Links, mit denen Moderatoren ohne Admin-Token
+// einen Kommentar freigeben oder ablehnen können
+type ModerationLinks struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewModerationLinks liest Secret und Gültigkeit aus der Umgebung
+func NewModerationLinks() (*ModerationLinks, error) {
+	// Ohne festes Secret gelten Links aus E-Mails nur bis zum Neustart
+	secret, err := derivedSecret("MODERATION_LINK_SECRET", "moderation-link")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ModerationLinks{
+		secret: secret,
+		ttl:    getEnvAsDuration("MODERATION_LINK_TTL", 72*time.Hour),
+	}, nil
+}
+
+// sign liefert die Signatur über Kommentar-ID, Aktion und Ablaufzeit
+func (l *ModerationLinks) sign(id int, action string, expires int64) string {
+	mac := hmac.New(sha256.New, l.secret)
+	fmt.Fprintf(mac, "%d:%s:%d", id, action, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// URL liefert den signierten Link für eine Aktion (apiBase = .../api/comments)
+func (l *ModerationLinks) URL(apiBase string, id int, action string) string {
+	expires := time.Now().Add(l.ttl).Unix()
+	query := url.Values{
+		"action":  {action},
+		"expires": {strconv.FormatInt(expires, 10)},
+		"sig":     {l.sign(id, action, expires)},
+	}
+	return fmt.Sprintf("%s/%d/moderate?%s", strings.TrimRight(apiBase, "/"), id, query.Encode())
+}
+
+// Verify prüft einen Link und liefert den Zielstatus
+func (l *ModerationLinks) Verify(id int, query url.Values) (CommentStatus, error) {
+	action := query.Get("action")
+	status, ok := linkActions[action]
+	if !ok {
+		return "", ErrInvalidModerationLink
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", ErrInvalidModerationLink
+	}
+	if !hmac.Equal([]byte(query.Get("sig")), []byte(l.sign(id, action, expires))) {
+		return "", ErrInvalidModerationLink
+	}
+	return status, nil
+}
+
+// publicAPIBase liefert die öffentliche API-URL für Links ohne Request
+// (PUBLIC_API_URL, sonst DOMAIN, sonst localhost)
+func publicAPIBase() string {
+	if envURL := getEnv("PUBLIC_API_URL", ""); envURL != "" {
+		return strings.TrimRight(envURL, "/")
+	}
+	if domain := getEnv("DOMAIN", ""); domain != "" {
+		return fmt.Sprintf("https://%s/api/comments", domain)
+	}
+	return fmt.Sprintf("http://localhost:%s/api/comments", getEnv("PORT", "8080"))
+}
+
+// adminCommentURL liefert den Link ins Admin-Panel, das den Kommentar hervorhebt
+func adminCommentURL(apiBase string, id int) string {
+	return fmt.Sprintf("%s/admin/panel?comment=%d", strings.TrimSuffix(apiBase, "/api/comments"), id)
+}
+
+// SMTPMailer verschickt E-Mails über den konfigurierten SMTP-Server
+type SMTPMailer struct {
+	host     string
+	port     int
+	startTLS bool
+	username string
+	password string
+	from     string
+	timeout  time.Duration
+}
+
+// NewSMTPMailer liest die Konfiguration aus der Umgebung.
+// Liefert nil, wenn SMTP_HOST nicht gesetzt ist.
+func NewSMTPMailer() (*SMTPMailer, error) {
+	host := getEnv("SMTP_HOST", "")
+	if host == "" {
+		return nil, nil
+	}
+
+	from := getEnv("SMTP_FROM", "")
+	if from == "" {
+		return nil, fmt.Errorf("SMTP_FROM ist erforderlich, wenn SMTP_HOST gesetzt ist")
+	}
+
+	return &SMTPMailer{
+		host:     host,
+		port:     getEnvAsInt("SMTP_PORT", 587),
+		startTLS: getEnvAsBool("SMTP_STARTTLS", true),
+		username: getEnv("SMTP_USERNAME", ""),
+		password: getEnv("SMTP_PASSWORD", ""),
+		from:     from,
+		timeout:  getEnvAsDuration("SMTP_TIMEOUT", 10*time.Second),
+	}, nil
+}
+
+// message baut die komplette E-Mail (Header + quoted-printable Text).
+// extra sind zusätzliche Header, z.B. List-Unsubscribe.
+func (m *SMTPMailer) message(to []string, subject, body string, extra map[string]string) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", m.from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), m.host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", header[0], header[1])
+	}
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, extra[name])
+	}
+	msg.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// Send liefert eine E-Mail per SMTP aus (optional STARTTLS und AUTH PLAIN)
+func (m *SMTPMailer) Send(to []string, subject, body string, extra map[string]string) error {
+	msg, err := m.message(to, subject, body, extra)
+	if err != nil {
+		return fmt.Errorf("fehler beim Erstellen der E-Mail: %w", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)), m.timeout)
+	if err != nil {
+		return fmt.Errorf("smtp-verbindung fehlgeschlagen: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(m.timeout))
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp-verbindung fehlgeschlagen: %w", err)
+	}
+	defer client.Close()
+
+	if m.startTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp-server bietet kein STARTTLS an (SMTP_STARTTLS=false für lokale Tests)")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("starttls fehlgeschlagen: %w", err)
+		}
+	}
+	if m.username != "" {
+		// PlainAuth verweigert unverschlüsselte Verbindungen außer zu localhost
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("smtp-anmeldung fehlgeschlagen: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM fehlgeschlagen: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s fehlgeschlagen: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA fehlgeschlagen: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp DATA fehlgeschlagen: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA fehlgeschlagen: %w", err)
+	}
+	return client.Quit()
+}
+
+// SMTPNotifier benachrichtigt Moderatoren per E-Mail über neue Kommentare
+type SMTPNotifier struct {
+	mailer *SMTPMailer
+	to     []string
+	// notifySpam: auch Kommentare melden, die direkt als Spam eingestuft wurden
+	notifySpam bool
+	links      *ModerationLinks
+	apiBase    string
+}
+
+// NewSMTPNotifier liest die Empfänger aus der Umgebung.
+// Liefert nil ohne SMTP-Server oder ohne SMTP_TO.
+func NewSMTPNotifier(mailer *SMTPMailer, links *ModerationLinks) *SMTPNotifier {
+	to := splitList(getEnv("SMTP_TO", ""))
+	if mailer == nil || len(to) == 0 {
+		return nil
+	}
+
+	return &SMTPNotifier{
+		mailer:     mailer,
+		to:         to,
+		notifySpam: getEnvAsBool("SMTP_NOTIFY_SPAM", false),
+		links:      links,
+		apiBase:    publicAPIBase(),
+	}
+}
+
+// NotifyNewComment verschickt die Benachrichtigung im Hintergrund
+func (n *SMTPNotifier) NotifyNewComment(c *Comment) {
+	if n == nil || (c.Status == StatusSpam && !n.notifySpam) {
+		return
+	}
+
+	subject, body := n.newCommentMessage(c)
+	go func() {
+		if err := n.mailer.Send(n.to, subject, body, nil); err != nil {
+			log.Printf("⚠️  E-Mail zu Kommentar #%d nicht versendet: %v", c.ID, err)
+			return
+		}
+		log.Printf("📧 E-Mail zu Kommentar #%d an %s versendet", c.ID, strings.Join(n.to, ", "))
+	}()
+}
+
+// newCommentMessage baut Betreff und Text der Benachrichtigung
+func (n *SMTPNotifier) newCommentMessage(c *Comment) (string, string) {
+	subject := fmt.Sprintf("Neuer Kommentar zu %s von %s", c.PostID, c.Username)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Post:       %s\n", c.PostID)
+	fmt.Fprintf(&b, "Autor:      %s <%s>\n", c.Username, c.MailAddress)
+	if c.ParentID != 0 {
+		fmt.Fprintf(&b, "Antwort auf: #%d\n", c.ParentID)
+	}
+	fmt.Fprintf(&b, "Status:     %s\n", c.Status)
+	fmt.Fprintf(&b, "Spam-Score: %.1f\n", c.SpamScore)
+	for _, reason := range c.SpamReasons {
+		fmt.Fprintf(&b, "  - %s\n", reason)
+	}
+	if c.DecisionReason != "" {
+		fmt.Fprintf(&b, "Freigabe:   %s\n", c.DecisionReason)
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", c.Text)
+
+	fmt.Fprintf(&b, "Freigeben: %s\n", n.links.URL(n.apiBase, c.ID, "approve"))
+	fmt.Fprintf(&b, "Ablehnen:  %s\n", n.links.URL(n.apiBase, c.ID, "reject"))
+	fmt.Fprintf(&b, "\nAdmin-Panel: %s\n", adminCommentURL(n.apiBase, c.ID))
+	fmt.Fprintf(&b, "Die Links gelten %v.\n", n.links.ttl)
+	return subject, b.String()
+}
+
+// linkPage sind die Daten der Seite hinter einem Link aus einer E-Mail
+type linkPage struct {
+	Title   string
+	Message string
+	Comment *Comment
+	Button  string // Beschriftung des Bestätigungsbuttons, leer = kein Formular
+	Style   string // CSS-Klasse des Buttons (approve oder reject)
+}
+
+// linkPageTemplate ist die Bestätigungs- bzw. Ergebnisseite. Eine Aktion
+// wird erst per POST ausgeführt, da Mailprogramme und Virenscanner Links vorab abrufen.
+var linkPageTemplate = template.Must(template.New("link").Parse(`<!DOCTYPE html>
+<html lang="de">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 640px; margin: 40px auto; padding: 0 20px; color: #333; }
+.comment { background: #f8f9fa; border-left: 4px solid #007bff; padding: 12px 16px; margin: 20px 0; white-space: pre-wrap; }
+.meta { color: #666; font-size: 14px; }
+button { padding: 10px 20px; border: none; border-radius: 4px; color: white; font-size: 16px; cursor: pointer; }
+.approve { background: #28a745; }
+.reject { background: #dc3545; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{with .Comment}}
+<p class="meta">#{{.ID}} · {{.PostID}} · {{.Username}} &lt;{{.MailAddress}}&gt; · Status: {{.Status}} · Spam-Score: {{printf "%.1f" .SpamScore}}</p>
+<div class="comment">{{.Text}}</div>
+{{end}}
+{{if .Button}}
+<form method="post">
+<button type="submit" class="{{.Style}}">{{.Button}}</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// renderLinkPage schreibt die Seite hinter einem Link aus einer E-Mail
+func renderLinkPage(w http.ResponseWriter, code int, page linkPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Die Signatur steht in der URL: nicht cachen und nicht weitergeben
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(code)
+	if err := linkPageTemplate.Execute(w, page); err != nil {
+		log.Printf("⚠️  Seite nicht gerendert: %v", err)
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sinkMail ist eine beim Test-SMTP-Server eingegangene E-Mail
+type sinkMail struct {
+	from    string
+	to      []string
+	auth    string // user:passwort aus AUTH PLAIN
+	header  mail.Header
+	body    string // dekodiert (quoted-printable)
+	rawBody string
+}
+
+// startSMTPSink startet einen SMTP-Server im Prozess (ohne STARTTLS) und
+// liefert einen Mailer, der an ihn sendet
+func startSMTPSink(t *testing.T) (*SMTPMailer, chan sinkMail) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan sinkMail, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sinkSession(t, conn, mails)
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	mailer := &SMTPMailer{
+		host:    "127.0.0.1",
+		port:    port,
+		from:    "comments@example.com",
+		timeout: 5 * time.Second,
+	}
+	return mailer, mails
+}
+
+// sinkSession verarbeitet eine SMTP-Verbindung
+func sinkSession(t *testing.T, conn net.Conn, mails chan sinkMail) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 sink")
+	var current sinkMail
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			text.PrintfLine("250-sink")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "HELO", "NOOP", "RSET":
+			text.PrintfLine("250 ok")
+		case "AUTH":
+			_, initial, _ := strings.Cut(arg, " ")
+			credentials, _ := base64.StdEncoding.DecodeString(initial)
+			parts := bytes.Split(credentials, []byte{0})
+			if len(parts) == 3 {
+				current.auth = string(parts[1]) + ":" + string(parts[2])
+			}
+			text.PrintfLine("235 ok")
+		case "MAIL":
+			current.from = strings.Trim(arg[len("FROM:"):], "<>")
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			current.to = append(current.to, strings.Trim(arg[len("TO:"):], "<>"))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			raw, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Errorf("ungültige E-Mail: %v", err)
+				return
+			}
+			rawBody, _ := io.ReadAll(msg.Body)
+			body, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(rawBody)))
+			if err != nil {
+				t.Errorf("ungültiges quoted-printable: %v", err)
+			}
+			current.header = msg.Header
+			current.rawBody = string(rawBody)
+			current.body = strings.ReplaceAll(string(body), "\r\n", "\n")
+			mails <- current
+			current = sinkMail{}
+			text.PrintfLine("250 ok")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 unbekannt")
+		}
+	}
+}
+
+// nextMail wartet auf die nächste E-Mail (NotifyNewComment sendet im Hintergrund)
+func nextMail(t *testing.T, mails chan sinkMail) sinkMail {
+	t.Helper()
+	select {
+	case m := <-mails:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("keine E-Mail beim SMTP-Server angekommen")
+		return sinkMail{}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	mailer, mails := startSMTPSink(t)
+	mailer.username = "mailer"
+	mailer.password = "geheim"
+
+	// Umlaute und eine lange Zeile erzwingen Kodierung und weiche Umbrüche
+	longLine := strings.Repeat("Kommentar mit Überlänge ", 8)
+	body := "Hallo Moderator,\n\nein neuer Kommentar wartet: äöüß €\n" + longLine + "\nEnde.\n"
+	extra := map[string]string{
+		"List-Unsubscribe":      "<https://blog.example/api/comments/subscriptions/x?action=unsubscribe>",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+
+	err := mailer.Send([]string{"mod@example.com", "mod2@example.com"}, "Neuer Kommentar zu Grüße von Jörg", body, extra)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	m := nextMail(t, mails)
+
+	if m.from != "comments@example.com" {
+		t.Errorf("MAIL FROM = %q", m.from)
+	}
+	if strings.Join(m.to, ",") != "mod@example.com,mod2@example.com" {
+		t.Errorf("RCPT TO = %v", m.to)
+	}
+	if m.auth != "mailer:geheim" {
+		t.Errorf("AUTH PLAIN = %q", m.auth)
+	}
+
+	wantHeaders := map[string]string{
+		"From":                      "comments@example.com",
+		"To":                        "mod@example.com, mod2@example.com",
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+		"List-Unsubscribe":          extra["List-Unsubscribe"],
+		"List-Unsubscribe-Post":     extra["List-Unsubscribe-Post"],
+	}
+	for name, want := range wantHeaders {
+		if got := m.header.Get(name); got != want {
+			t.Errorf("%s = %q, erwartet %q", name, got, want)
+		}
+	}
+
+	rawSubject := m.header.Get("Subject")
+	if !strings.HasPrefix(rawSubject, "=?utf-8?q?") {
+		t.Errorf("Subject nicht kodiert: %q", rawSubject)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(rawSubject)
+	if err != nil || subject != "Neuer Kommentar zu Grüße von Jörg" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if _, err := m.header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+	if id := m.header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@127.0.0.1>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+
+	for _, line := range strings.Split(m.rawBody, "\n") {
+		if len(strings.TrimSuffix(line, "\r")) > 76 {
+			t.Errorf("Zeile länger als 76 Zeichen: %q", line)
+		}
+	}
+	if strings.ContainsAny(m.rawBody, "äöüß€") {
+		t.Error("Body enthält unkodierte Umlaute")
+	}
+	if m.body != body {
+		t.Errorf("Body = %q, erwartet %q", m.body, body)
+	}
+}
+
+func TestSMTPMailerSendRequiresStartTLS(t *testing.T) {
+	mailer, _ := startSMTPSink(t)
+	mailer.startTLS = true
+
+	err := mailer.Send([]string{"mod@example.com"}, "Test", "Text", nil)
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("Fehler wegen fehlendem STARTTLS erwartet, bekommen: %v", err)
+	}
+}
+
+// newTestNotifier liefert einen Notifier, der an den Test-SMTP-Server sendet
+func newTestNotifier(t *testing.T, ttl time.Duration) (*SMTPNotifier, chan sinkMail) {
+	mailer, mails := startSMTPSink(t)
+	notifier := &SMTPNotifier{
+		mailer:  mailer,
+		to:      []string{"mod@example.com"},
+		links:   &ModerationLinks{secret: []byte("test-secret"), ttl: ttl},
+		apiBase: "https://blog.example/api/comments",
+	}
+	return notifier, mails
+}
+
+// mailLink sucht die URL hinter label im Text der E-Mail
+func mailLink(t *testing.T, body, label string) *url.URL {
+	t.Helper()
+	for _, line := range strings.Split(body, "\n") {
+		if rest, ok := strings.CutPrefix(line, label); ok {
+			link, err := url.Parse(strings.TrimSpace(rest))
+			if err != nil {
+				t.Fatalf("ungültiger Link %q: %v", rest, err)
+			}
+			return link
+		}
+	}
+	t.Fatalf("kein %q in der E-Mail:\n%s", label, body)
+	return nil
+}
+
+func newNotifyComment() *Comment {
+	return &Comment{
+		ID:          42,
+		PostID:      "hallo-welt",
+		ParentID:    7,
+		Username:    "Jörg",
+		MailAddress: "joerg@example.com",
+		Text:        "Schöner Beitrag!",
+		Status:      StatusPending,
+		SpamScore:   2.5,
+		SpamReasons: []string{"enthält Links"},
+	}
+}
+
+func TestNotifyNewComment(t *testing.T) {
+	notifier, mails := newTestNotifier(t, time.Hour)
+	comment := newNotifyComment()
+
+	notifier.NotifyNewComment(comment)
+	m := nextMail(t, mails)
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(m.header.Get("Subject"))
+	if subject != "Neuer Kommentar zu hallo-welt von Jörg" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if m.header.Get("To") != "mod@example.com" {
+		t.Errorf("To = %q", m.header.Get("To"))
+	}
+	for _, want := range []string{
+		"Post:       hallo-welt\n",
+		"Autor:      Jörg <joerg@example.com>\n",
+		"Antwort auf: #7\n",
+		"Status:     pending\n",
+		"Spam-Score: 2.5\n",
+		"  - enthält Links\n",
+		"\nSchöner Beitrag!\n",
+		"Admin-Panel: https://blog.example/admin/panel?comment=42\n",
+		"Die Links gelten 1h0m0s.\n",
+	} {
+		if !strings.Contains(m.body, want) {
+			t.Errorf("Body ohne %q:\n%s", want, m.body)
+		}
+	}
+
+	for label, want := range map[string]CommentStatus{"Freigeben:": StatusApproved, "Ablehnen:": StatusRejected} {
+		link := mailLink(t, m.body, label)
+		if link.Host != "blog.example" || link.Path != "/api/comments/42/moderate" {
+			t.Errorf("%s Link %s zeigt auf die falsche Route", label, link)
+		}
+		status, err := notifier.links.Verify(comment.ID, link.Query())
+		if err != nil || status != want {
+			t.Errorf("%s Verify = (%q, %v), erwartet %q", label, status, err, want)
+		}
+	}
+}
+
+func TestNotifyNewCommentSpam(t *testing.T) {
+	notifier, mails := newTestNotifier(t, time.Hour)
+	comment := newNotifyComment()
+	comment.Status = StatusSpam
+
+	notifier.NotifyNewComment(comment)
+	select {
+	case <-mails:
+		t.Fatal("Spam ohne SMTP_NOTIFY_SPAM darf keine E-Mail auslösen")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	notifier.notifySpam = true
+	notifier.NotifyNewComment(comment)
+	nextMail(t, mails)
+}
+
+func TestModerationLinksRejected(t *testing.T) {
+	notifier, mails := newTestNotifier(t, time.Hour)
+	comment := newNotifyComment()
+	notifier.NotifyNewComment(comment)
+	approve := mailLink(t, nextMail(t, mails).body, "Freigeben:").Query()
+
+	tamper := func(change func(q url.Values)) url.Values {
+		q := url.Values{}
+		for key, values := range approve {
+			q[key] = append([]string(nil), values...)
+		}
+		change(q)
+		return q
+	}
+
+	tests := []struct {
+		name  string
+		id    int
+		query url.Values
+	}{
+		{name: "anderer Kommentar", id: comment.ID + 1, query: approve},
+		{name: "Aktion vertauscht", id: comment.ID, query: tamper(func(q url.Values) { q.Set("action", "reject") })},
+		{name: "unbekannte Aktion", id: comment.ID, query: tamper(func(q url.Values) { q.Set("action", "delete") })},
+		{name: "Ablauf verlängert", id: comment.ID, query: tamper(func(q url.Values) {
+			expires, _ := strconv.ParseInt(q.Get("expires"), 10, 64)
+			q.Set("expires", strconv.FormatInt(expires+3600, 10))
+		})},
+		{name: "Signatur verändert", id: comment.ID, query: tamper(func(q url.Values) {
+			sig := []byte(q.Get("sig"))
+			sig[0] ^= 1
+			q.Set("sig", string(sig))
+		})},
+		{name: "ohne Signatur", id: comment.ID, query: tamper(func(q url.Values) { q.Del("sig") })},
+		{name: "ohne Ablauf", id: comment.ID, query: tamper(func(q url.Values) { q.Del("expires") })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := notifier.links.Verify(tt.id, tt.query); err != ErrInvalidModerationLink {
+				t.Fatalf("Verify = %v, erwartet ErrInvalidModerationLink", err)
+			}
+		})
+	}
+
+	t.Run("anderes Secret", func(t *testing.T) {
+		other := &ModerationLinks{secret: []byte("anderes-secret"), ttl: time.Hour}
+		if _, err := other.Verify(comment.ID, approve); err != ErrInvalidModerationLink {
+			t.Fatalf("Verify = %v, erwartet ErrInvalidModerationLink", err)
+		}
+	})
+
+	t.Run("abgelaufen", func(t *testing.T) {
+		expired, mails := newTestNotifier(t, -time.Minute)
+		expired.NotifyNewComment(comment)
+		body := nextMail(t, mails).body
+		for _, label := range []string{"Freigeben:", "Ablehnen:"} {
+			if _, err := expired.links.Verify(comment.ID, mailLink(t, body, label).Query()); err != ErrInvalidModerationLink {
+				t.Fatalf("%s Verify = %v, erwartet ErrInvalidModerationLink", label, err)
+			}
+		}
+	})
+}
+
+func TestModerationLinksUseOwnKey(t *testing.T) {
+	t.Setenv("FORM_TOKEN_SECRET", "basis")
+	links, err := NewModerationLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(time.Hour).Unix()
+	query := func(sig string) url.Values {
+		return url.Values{"action": {"approve"}, "expires": {strconv.FormatInt(expires, 10)}, "sig": {sig}}
+	}
+
+	if status, err := links.Verify(7, query(links.sign(7, "approve", expires))); err != nil || status != StatusApproved {
+		t.Fatalf("Verify = %s, %v, erwartet approved", status, err)
+	}
+
+	// Mit FORM_TOKEN_SECRET selbst signierte Links gelten nicht
+	forged := &ModerationLinks{secret: []byte("basis")}
+	if _, err := links.Verify(7, query(forged.sign(7, "approve", expires))); err != ErrInvalidModerationLink {
+		t.Fatalf("Verify = %v, erwartet ErrInvalidModerationLink", err)
+	}
+}
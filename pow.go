@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Fehler bei der Prüfung einer Proof-of-Work-Lösung
+var (
+	ErrPowMissing  = errors.New("proof-of-work fehlt")
+	ErrPowInvalid  = errors.New("proof-of-work ungültig")
+	ErrPowExpired  = errors.New("proof-of-work abgelaufen")
+	ErrPowTooWeak  = errors.New("proof-of-work erfüllt die Schwierigkeit nicht")
+	ErrPowReused   = errors.New("proof-of-work bereits verwendet")
+	ErrPowTooLarge = errors.New("proof-of-work-lösung zu lang")
+)
+
+// PowActivityStore zählt Einsendungen pro IP innerhalb eines Zeitfensters
+type PowActivityStore interface {
+	Count(ip string) (int, error)
+	Record(ip string, window time.Duration) error
+}
+
+// PowChallenge ist eine ausgestellte Aufgabe: gesucht ist eine Nonce, sodass
+// SHA-256(challenge + ":" + nonce) mindestens difficulty führende Null-Bits hat
+type PowChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// PowChallenger stellt signierte Hashcash-Aufgaben aus und prüft Lösungen.
+// Die Schwierigkeit steigt für IPs, die im Zeitfenster häufig einsenden.
+type PowChallenger struct {
+	secret        []byte
+	difficulty    int           // Grundschwierigkeit in Bits
+	maxDifficulty int           // Obergrenze
+	freeCount     int           // Einsendungen pro Fenster ohne Aufschlag
+	window        time.Duration // Zeitfenster für die Zählung pro IP
+	maxAge        time.Duration // Gültigkeit einer Aufgabe
+	used          NonceStore
+	activity      PowActivityStore
+}
+
+// NewPowChallenger liest die Konfiguration aus der Umgebung.
+// Liefert nil ohne POW_ENABLED=true: Clients brauchen einen Solver (siehe Widget).
+func NewPowChallenger(used NonceStore, activity PowActivityStore) (*PowChallenger, error) {
+	if !getEnvAsBool("POW_ENABLED", false) {
+		return nil, nil
+	}
+
+	secret, err := derivedSecret("POW_SECRET", "pow")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PowChallenger{
+		secret:        secret,
+		difficulty:    getEnvAsInt("POW_DIFFICULTY", 16),
+		maxDifficulty: getEnvAsInt("POW_MAX_DIFFICULTY", 22),
+		freeCount:     getEnvAsInt("POW_FREE_SUBMISSIONS", 3),
+		window:        getEnvAsDuration("POW_WINDOW", time.Hour),
+		maxAge:        getEnvAsDuration("POW_MAX_AGE", 10*time.Minute),
+		used:          used,
+		activity:      activity,
+	}
+	if p.difficulty < 1 || p.difficulty > 32 || p.maxDifficulty < p.difficulty || p.maxDifficulty > 32 {
+		return nil, fmt.Errorf("ungültige PoW-Schwierigkeit: %d-%d (erlaubt 1-32)", p.difficulty, p.maxDifficulty)
+	}
+
+	return p, nil
+}
+
+// difficultyFor liefert die Schwierigkeit für eine IP: +1 Bit je Einsendung über freeCount
+func (p *PowChallenger) difficultyFor(ip string) int {
+	count, err := p.activity.Count(ip)
+	if err != nil {
+		log.Printf("⚠️  PoW-Aktivität nicht lesbar: %v", err)
+		return p.difficulty
+	}
+
+	difficulty := p.difficulty
+	if count > p.freeCount {
+		difficulty += count - p.freeCount
+	}
+	if difficulty > p.maxDifficulty {
+		difficulty = p.maxDifficulty
+	}
+	return difficulty
+}
+
+// sign liefert die HMAC-Signatur über den Aufgaben-Inhalt
+func (p *PowChallenger) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Issue erstellt eine Aufgabe für ip: base64(nonce | ausgestellt | bits) "." base64(signatur)
+func (p *PowChallenger) Issue(ip string) (*PowChallenge, error) {
+	difficulty := p.difficultyFor(ip)
+	now := time.Now()
+
+	payload := make([]byte, 25)
+	if _, err := rand.Read(payload[:16]); err != nil {
+		return nil, fmt.Errorf("fehler beim Erzeugen der PoW-Aufgabe: %w", err)
+	}
+	binary.BigEndian.PutUint64(payload[16:24], uint64(now.Unix()))
+	payload[24] = byte(difficulty)
+
+	encoding := base64.RawURLEncoding
+	return &PowChallenge{
+		Challenge:  encoding.EncodeToString(payload) + "." + encoding.EncodeToString(p.sign(payload)),
+		Difficulty: difficulty,
+		ExpiresAt:  now.Add(p.maxAge).UTC(),
+	}, nil
+}
+
+// Verify prüft eine Lösung und verbraucht die Aufgabe. Jede geprüfte Einsendung
+// zählt für die Schwierigkeit der nächsten Aufgaben dieser IP.
+func (p *PowChallenger) Verify(ip, challenge, nonce string) error {
+	if p == nil {
+		return nil
+	}
+	if challenge == "" || nonce == "" {
+		return ErrPowMissing
+	}
+	if len(nonce) > 64 {
+		return ErrPowTooLarge
+	}
+
+	if err := p.activity.Record(ip, p.window); err != nil {
+		log.Printf("⚠️  PoW-Aktivität nicht speicherbar: %v", err)
+	}
+
+	encoding := base64.RawURLEncoding
+	parts := strings.SplitN(challenge, ".", 2)
+	if len(parts) != 2 {
+		return ErrPowInvalid
+	}
+	payload, err := encoding.DecodeString(parts[0])
+	if err != nil || len(payload) != 25 {
+		return ErrPowInvalid
+	}
+	signature, err := encoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, p.sign(payload)) {
+		return ErrPowInvalid
+	}
+
+	if time.Since(time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0)) > p.maxAge {
+		return ErrPowExpired
+	}
+
+	hash := sha256.Sum256([]byte(challenge + ":" + nonce))
+	if leadingZeroBits(hash[:]) < int(payload[24]) {
+		return ErrPowTooWeak
+	}
+
+	fresh, err := p.used.MarkUsed(encoding.EncodeToString(payload[:16]), p.maxAge)
+	if err != nil {
+		// Fail open, die Lösung selbst ist gültig
+		log.Printf("⚠️  PoW-Lösung nicht prüfbar: %v", err)
+		return nil
+	}
+	if !fresh {
+		return ErrPowReused
+	}
+	return nil
+}
+
+// leadingZeroBits zählt die führenden Null-Bits eines Hashes
+func leadingZeroBits(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+// ValkeyPowActivityStore zählt Einsendungen als pow:ip:{ip} mit Ablauf nach dem Zeitfenster
+type ValkeyPowActivityStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewValkeyPowActivityStore erstellt einen ValkeyPowActivityStore
+func NewValkeyPowActivityStore(client *redis.Client) *ValkeyPowActivityStore {
+	return &ValkeyPowActivityStore{client: client, ctx: context.Background()}
+}
+
+// Count liefert die Einsendungen der IP im aktuellen Fenster
+func (s *ValkeyPowActivityStore) Count(ip string) (int, error) {
+	count, err := s.client.Get(s.ctx, "pow:ip:"+ip).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("fehler beim Lesen der PoW-Aktivität: %w", err)
+	}
+	return count, nil
+}
+
+// Record zählt eine Einsendung; das Fenster beginnt mit der ersten Einsendung
+func (s *ValkeyPowActivityStore) Record(ip string, window time.Duration) error {
+	key := "pow:ip:" + ip
+	count, err := s.client.Incr(s.ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern der PoW-Aktivität: %w", err)
+	}
+	if count == 1 {
+		if err := s.client.Expire(s.ctx, key, window).Err(); err != nil {
+			return fmt.Errorf("fehler beim Speichern der PoW-Aktivität: %w", err)
+		}
+	}
+	return nil
+}
+
+// powActivity ist der Zähler einer IP im Prozessspeicher
+type powActivity struct {
+	count   int
+	expires time.Time
+}
+
+// MemoryPowActivityStore zählt Einsendungen im Prozessspeicher (nur Einzelinstanz)
+type MemoryPowActivityStore struct {
+	mutex    sync.Mutex
+	activity map[string]*powActivity
+}
+
+// NewMemoryPowActivityStore erstellt einen leeren MemoryPowActivityStore
+func NewMemoryPowActivityStore() *MemoryPowActivityStore {
+	return &MemoryPowActivityStore{activity: make(map[string]*powActivity)}
+}
+
+// Count liefert die Einsendungen der IP im aktuellen Fenster
+func (s *MemoryPowActivityStore) Count(ip string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.activity[ip]
+	if !ok || time.Now().After(entry.expires) {
+		return 0, nil
+	}
+	return entry.count, nil
+}
+
+// Record zählt eine Einsendung; das Fenster beginnt mit der ersten Einsendung
+func (s *MemoryPowActivityStore) Record(ip string, window time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := s.activity[ip]
+	if !ok || now.After(entry.expires) {
+		// Abgelaufene Zähler gelegentlich aufräumen
+		if len(s.activity) > 10000 {
+			for key, e := range s.activity {
+				if now.After(e.expires) {
+					delete(s.activity, key)
+				}
+			}
+		}
+		entry = &powActivity{expires: now.Add(window)}
+		s.activity[ip] = entry
+	}
+	entry.count++
+	return nil
+}
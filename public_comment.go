@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// PublicComment ist die öffentliche Darstellung eines Kommentars ohne E-Mail-Adresse.
+// Vollständige Datensätze gibt es nur über die Admin-Endpunkte.
+type PublicComment struct {
+	ID         int              `json:"id"`
+	PostID     string           `json:"post_id"`
+	ParentID   int              `json:"parent_id,omitempty"`
+	Username   string           `json:"username"`
+	Text       string           `json:"text"`
+	Status     CommentStatus    `json:"status"`
+	AvatarHash string           `json:"avatar_hash,omitempty"`
+	CreatedAt  string           `json:"created_at"`
+	Children   []*PublicComment `json:"children,omitempty"`
+}
+
+// PublicCommentPage ist eine Seite der öffentlichen Kommentarliste
+type PublicCommentPage struct {
+	Items      []*PublicComment `json:"items"`
+	NextCursor string           `json:"next_cursor"`
+	Total      int              `json:"total"`
+}
+
+// avatarHash liefert den Gravatar-kompatiblen SHA-256-Hash einer E-Mail-Adresse
+func avatarHash(mailAddress string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(mailAddress))))
+	return hex.EncodeToString(sum[:])
+}
+
+// toPublicComment wandelt einen Kommentar (inkl. Antworten) in die öffentliche Darstellung um
+func toPublicComment(c *Comment, withAvatar bool) *PublicComment {
+	public := &PublicComment{
+		ID:        c.ID,
+		PostID:    c.PostID,
+		ParentID:  c.ParentID,
+		Username:  c.Username,
+		Text:      c.Text,
+		Status:    c.Status,
+		CreatedAt: c.CreatedAt,
+	}
+	if withAvatar {
+		public.AvatarHash = avatarHash(c.MailAddress)
+	}
+	for _, child := range c.Children {
+		public.Children = append(public.Children, toPublicComment(child, withAvatar))
+	}
+	return public
+}
+
+// toPublicPage wandelt eine Seite in die öffentliche Darstellung um
+func toPublicPage(page *CommentPage, withAvatar bool) *PublicCommentPage {
+	public := &PublicCommentPage{
+		Items:      make([]*PublicComment, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+	for _, c := range page.Items {
+		public.Items = append(public.Items, toPublicComment(c, withAvatar))
+	}
+	return public
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitStore verwaltet Token-Buckets. Take entnimmt einen Token und liefert
+// bei leerem Bucket die Wartezeit bis zum nächsten Token.
+type RateLimitStore interface {
+	Take(key string, capacity int, per time.Duration) (bool, time.Duration, error)
+}
+
+// RateLimitRule beschreibt einen Token-Bucket: capacity Tokens, vollständig aufgefüllt nach per
+type RateLimitRule struct {
+	Name     string
+	Capacity int
+	Per      time.Duration
+}
+
+// parseRateLimitRule liest eine Regel im Format "5/10m" (leer oder "0" = deaktiviert)
+func parseRateLimitRule(name, value string) (*RateLimitRule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("ungültiges Rate-Limit %q (Format: anzahl/dauer, z.B. 5/10m)", value)
+	}
+	capacity, err := strconv.Atoi(parts[0])
+	if err != nil || capacity < 1 {
+		return nil, fmt.Errorf("ungültiges Rate-Limit %q: anzahl muss >= 1 sein", value)
+	}
+	per, err := time.ParseDuration(parts[1])
+	if err != nil || per <= 0 {
+		return nil, fmt.Errorf("ungültiges Rate-Limit %q: ungültige dauer", value)
+	}
+
+	return &RateLimitRule{Name: name, Capacity: capacity, Per: per}, nil
+}
+
+// RateLimiter begrenzt das Erstellen von Kommentaren pro IP, Post und E-Mail
+type RateLimiter struct {
+	store RateLimitStore
+	ip    *RateLimitRule
+	post  *RateLimitRule
+	email *RateLimitRule
+}
+
+// defaultIPRateLimit gilt für RATE_LIMIT_IP, sobald TRUSTED_PROXIES gesetzt ist
+const defaultIPRateLimit = "5/10m"
+
+// NewRateLimiter liest die Konfiguration aus der Umgebung.
+// Liefert nil, wenn Rate-Limiting deaktiviert ist.
+func NewRateLimiter(store RateLimitStore, trustedProxies []*net.IPNet) (*RateLimiter, error) {
+	if !getEnvAsBool("RATE_LIMIT_ENABLED", true) {
+		return nil, nil
+	}
+
+	limiter := &RateLimiter{store: store}
+
+	// Hinter einem Proxy ohne TRUSTED_PROXIES hätten alle Besucher dieselbe IP und
+	// teilten sich einen Bucket, die IP-Regel ist dann nur explizit aktiv
+	ipDefault := ""
+	if len(trustedProxies) > 0 {
+		ipDefault = defaultIPRateLimit
+	}
+	ipValue := getEnv("RATE_LIMIT_IP", ipDefault)
+	if ipValue == "" {
+		log.Printf("⚠️  RATE_LIMIT_IP aus: ohne TRUSTED_PROXIES nur explizit (z.B. RATE_LIMIT_IP=%s)", defaultIPRateLimit)
+	}
+
+	var err error
+	if limiter.ip, err = parseRateLimitRule("ip", ipValue); err != nil {
+		return nil, err
+	}
+	if limiter.post, err = parseRateLimitRule("post", getEnv("RATE_LIMIT_POST", "")); err != nil {
+		return nil, err
+	}
+	if limiter.email, err = parseRateLimitRule("email", getEnv("RATE_LIMIT_EMAIL", "")); err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// parseTrustedProxies liest eine kommagetrennte Liste von IPs und CIDR-Netzen
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("ungültiger Eintrag in TRUSTED_PROXIES: %q", entry)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// isTrustedProxy prüft, ob eine IP in TRUSTED_PROXIES liegt
+func isTrustedProxy(ip net.IP, trusted []*net.IPNet) bool {
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP bestimmt die IP des Clients. X-Forwarded-For wird nur ausgewertet,
+// wenn die Verbindung von einem vertrauenswürdigen Proxy kommt; die Liste wird
+// von rechts gelesen und die erste nicht vertrauenswürdige Adresse genommen.
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote := net.ParseIP(host)
+	if remote == nil || !isTrustedProxy(remote, trusted) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		if !isTrustedProxy(hop, trusted) {
+			return hop.String()
+		}
+		host = hop.String()
+	}
+
+	return host
+}
+
+// Allow prüft alle aktiven Regeln und liefert bei Überschreitung die Wartezeit.
+// Fehler des Stores werden geloggt und lassen den Request durch (fail open).
+func (l *RateLimiter) Allow(ip, postID, mailAddress string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	checks := []struct {
+		rule  *RateLimitRule
+		value string
+	}{
+		{l.ip, ip},
+		{l.post, postID},
+		// E-Mail-Adressen nicht im Klartext als Key speichern
+		{l.email, hashRateLimitValue(strings.ToLower(strings.TrimSpace(mailAddress)))},
+	}
+
+	for _, check := range checks {
+		if check.rule == nil || check.value == "" {
+			continue
+		}
+
+		allowed, retryAfter, err := l.store.Take(check.rule.Name+":"+check.value, check.rule.Capacity, check.rule.Per)
+		if err != nil {
+			log.Printf("⚠️  Rate-Limit (%s) nicht prüfbar: %v", check.rule.Name, err)
+			continue
+		}
+		if !allowed {
+			log.Printf("🚦 Rate-Limit (%s) erreicht: %s", check.rule.Name, check.value)
+			return false, retryAfter
+		}
+	}
+
+	return true, 0
+}
+
+// hashRateLimitValue kürzt einen Wert auf einen stabilen Hash
+func hashRateLimitValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:16])
+}
+
+// writeRateLimitError antwortet mit 429 und Retry-After (Sekunden, aufgerundet)
+func writeRateLimitError(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, fmt.Sprintf("Zu viele Kommentare, bitte in %d Sekunden erneut versuchen", seconds), http.StatusTooManyRequests)
+}
+
+// tokenBucketScript entnimmt atomar einen Token (Tokens als Kommazahl, Zeit in ms)
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate))
+return {allowed, wait}
+`)
+
+// ValkeyRateLimitStore hält die Buckets in Valkey, damit alle Replicas dieselben Limits sehen
+type ValkeyRateLimitStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewValkeyRateLimitStore erstellt einen ValkeyRateLimitStore
+func NewValkeyRateLimitStore(client *redis.Client) *ValkeyRateLimitStore {
+	return &ValkeyRateLimitStore{client: client, ctx: context.Background()}
+}
+
+// Take entnimmt einen Token aus dem Bucket ratelimit:{key}
+func (s *ValkeyRateLimitStore) Take(key string, capacity int, per time.Duration) (bool, time.Duration, error) {
+	ratePerMs := float64(capacity) / float64(per.Milliseconds())
+	now := time.Now().UnixMilli()
+
+	result, err := tokenBucketScript.Run(s.ctx, s.client, []string{"ratelimit:" + key},
+		capacity, strconv.FormatFloat(ratePerMs, 'g', -1, 64), now).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("fehler beim Prüfen des Rate-Limits: %w", err)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
+// memoryBucket ist ein Token-Bucket im Prozessspeicher
+type memoryBucket struct {
+	tokens   float64
+	last     time.Time
+	capacity float64
+	rate     float64 // Tokens pro Sekunde
+}
+
+// refill füllt den Bucket bis now auf
+func (b *memoryBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// MemoryRateLimitStore hält die Buckets im Prozessspeicher (nur Einzelinstanz)
+type MemoryRateLimitStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryRateLimitStore erstellt einen leeren MemoryRateLimitStore
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// Take entnimmt einen Token aus dem Bucket key
+func (s *MemoryRateLimitStore) Take(key string, capacity int, per time.Duration) (bool, time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		// Volle Buckets müssen nicht gespeichert werden, gelegentlich aufräumen
+		if len(s.buckets) > 10000 {
+			s.prune(now)
+		}
+		bucket = &memoryBucket{
+			tokens:   float64(capacity),
+			last:     now,
+			capacity: float64(capacity),
+			rate:     float64(capacity) / per.Seconds(),
+		}
+		s.buckets[key] = bucket
+	}
+
+	bucket.refill(now)
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / bucket.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// prune entfernt wieder vollständig aufgefüllte Buckets
+func (s *MemoryRateLimitStore) prune(now time.Time) {
+	for key, bucket := range s.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= bucket.capacity {
+			delete(s.buckets, key)
+		}
+	}
+}
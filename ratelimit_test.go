@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitRule(t *testing.T) {
+	cases := []struct {
+		value    string
+		capacity int
+		per      time.Duration
+		wantErr  bool
+	}{
+		{"5/10m", 5, 10 * time.Minute, false},
+		{" 30/1h ", 30, time.Hour, false},
+		{"", 0, 0, false},
+		{"0", 0, 0, false},
+		{"5", 0, 0, true},
+		{"0/1m", 0, 0, true},
+		{"-1/1m", 0, 0, true},
+		{"x/1m", 0, 0, true},
+		{"5/10", 0, 0, true},
+		{"5/0s", 0, 0, true},
+	}
+	for _, tc := range cases {
+		rule, err := parseRateLimitRule("ip", tc.value)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: Fehler %v, erwartet Fehler: %v", tc.value, err, tc.wantErr)
+			continue
+		}
+		if tc.capacity == 0 {
+			if rule != nil {
+				t.Errorf("%q: Regel %+v, erwartet keine", tc.value, rule)
+			}
+			continue
+		}
+		if rule == nil || rule.Name != "ip" || rule.Capacity != tc.capacity || rule.Per != tc.per {
+			t.Errorf("%q: Regel %+v, erwartet %d/%v", tc.value, rule, tc.capacity, tc.per)
+		}
+	}
+}
+
+func TestMemoryRateLimitRefill(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	take := func() (bool, time.Duration) {
+		t.Helper()
+		allowed, wait, err := store.Take("ip:192.0.2.1", 2, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return allowed, wait
+	}
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := take(); !allowed {
+			t.Fatalf("Token %d abgelehnt", i+1)
+		}
+	}
+	allowed, wait := take()
+	if allowed {
+		t.Fatal("leerer Bucket lässt durch")
+	}
+	// 2 Tokens pro Minute: ein Token nach 30 Sekunden
+	if wait < 29*time.Second || wait > 30*time.Second {
+		t.Errorf("Wartezeit %v, erwartet etwa 30s", wait)
+	}
+
+	// Zeit vorspulen: nach 30 Sekunden genau ein neuer Token
+	store.buckets["ip:192.0.2.1"].last = time.Now().Add(-30 * time.Second)
+	if allowed, _ := take(); !allowed {
+		t.Fatal("nach 30s kein neuer Token")
+	}
+	if allowed, _ := take(); allowed {
+		t.Fatal("mehr als ein Token nachgefüllt")
+	}
+
+	// Nie über die Kapazität auffüllen
+	store.buckets["ip:192.0.2.1"].last = time.Now().Add(-time.Hour)
+	for i := 0; i < 2; i++ {
+		if allowed, _ := take(); !allowed {
+			t.Fatalf("voller Bucket: Token %d abgelehnt", i+1)
+		}
+	}
+	if allowed, _ := take(); allowed {
+		t.Fatal("Bucket über die Kapazität aufgefüllt")
+	}
+
+	// Andere Keys haben eigene Buckets
+	if allowed, _, _ := store.Take("ip:192.0.2.2", 2, time.Minute); !allowed {
+		t.Fatal("anderer Key abgelehnt")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies("10.0.0.0/8, 127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"direkt", "198.51.100.7:1234", "", "198.51.100.7"},
+		{"Header von fremdem Client ignoriert", "198.51.100.7:1234", "203.0.113.9", "198.51.100.7"},
+		{"über Proxy", "10.0.0.5:1234", "203.0.113.9", "203.0.113.9"},
+		{"Proxy-Kette von rechts", "127.0.0.1:1234", "203.0.113.9, 10.1.2.3", "203.0.113.9"},
+		{"gefälschter Eintrag links", "10.0.0.5:1234", "1.1.1.1, 203.0.113.9", "203.0.113.9"},
+		{"nur Proxys", "10.0.0.5:1234", "10.1.2.3", "10.1.2.3"},
+		{"ohne Header", "10.0.0.5:1234", "", "10.0.0.5"},
+		{"ungültiger Eintrag", "10.0.0.5:1234", "kaputt, 10.1.2.3", "10.1.2.3"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("POST", "/api/comments", nil)
+		r.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		if got := clientIP(r, trusted); got != tc.want {
+			t.Errorf("%s: %s, erwartet %s", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := parseTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("ungültiges CIDR akzeptiert")
+	}
+}
+
+func TestRateLimitIPRuleNeedsTrustedProxies(t *testing.T) {
+	proxies := []*net.IPNet{{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(8, 32)}}
+
+	limiter, err := NewRateLimiter(NewMemoryRateLimitStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limiter.ip != nil {
+		t.Errorf("IP-Regel ohne TRUSTED_PROXIES aktiv: %+v", limiter.ip)
+	}
+
+	limiter, err = NewRateLimiter(NewMemoryRateLimitStore(), proxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limiter.ip == nil || limiter.ip.Capacity != 5 || limiter.ip.Per != 10*time.Minute {
+		t.Errorf("IP-Regel mit TRUSTED_PROXIES: %+v, erwartet 5/10m", limiter.ip)
+	}
+
+	t.Setenv("RATE_LIMIT_IP", "3/1m")
+	limiter, err = NewRateLimiter(NewMemoryRateLimitStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limiter.ip == nil || limiter.ip.Capacity != 3 {
+		t.Errorf("explizite IP-Regel: %+v, erwartet 3/1m", limiter.ip)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// readerCookieName enthält eine zufällige ID pro Browser, damit Meldungen auch
+// bei wechselnder IP nur einmal zählen
+const readerCookieName = "comments_reader"
+
+// maxReportReason begrenzt die Länge eines Meldungsgrunds (Zeichen)
+const maxReportReason = 500
+
+// Fehler bei Meldungen
+var (
+	ErrReportNotFound = errors.New("meldung nicht gefunden")
+	ErrInvalidReport  = errors.New("ungültige meldung")
+	// ErrReportsUnsupported: das Storage-Backend kann keine Meldungen speichern
+	ErrReportsUnsupported = errors.New("meldungen werden vom storage-backend nicht unterstützt")
+)
+
+// Report ist die Meldung eines Lesers zu einem Kommentar
+type Report struct {
+	ID        int    `json:"id"`
+	CommentID int    `json:"comment_id"`
+	Reason    string `json:"reason"`
+	IP        string `json:"ip,omitempty"`
+	Reporter  string `json:"-"` // ID aus dem Cookie comments_reader
+	CreatedAt string `json:"created_at"`
+}
+
+// ReportStore speichert Meldungen im gewählten Storage-Backend
+type ReportStore interface {
+	// ListReports liefert die Meldungen zu commentID (0 = alle), neueste zuerst
+	ListReports(commentID int) ([]*Report, error)
+	// CreateReport vergibt eine neue ID und speichert die Meldung. Hat dieselbe
+	// IP oder derselbe Browser den Kommentar schon gemeldet, wird atomar nichts
+	// gespeichert (false).
+	CreateReport(report *Report) (bool, error)
+	DeleteReport(id int) error
+	// DeleteCommentReports entfernt alle Meldungen zu einem Kommentar
+	DeleteCommentReports(commentID int) error
+}
+
+// reportStore liefert den ReportStore des Backends oder nil
+func reportStore(store CommentStore) ReportStore {
+	reports, _ := store.(ReportStore)
+	return reports
+}
+
+// readerID liest die Leser-ID aus dem Cookie oder vergibt eine neue
+func readerID(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(readerCookieName); err == nil && len(cookie.Value) == 32 {
+		if _, err := hex.DecodeString(cookie.Value); err == nil {
+			return cookie.Value
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// Ohne Cookie greift weiterhin die Deduplizierung per IP
+		return ""
+	}
+	value := hex.EncodeToString(id)
+	setWidgetCookie(w, r, &http.Cookie{
+		Name:     readerCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+	})
+	return value
+}
+
+// ReportComment speichert eine Meldung zu einem freigegebenen Kommentar.
+// Meldet dieselbe IP oder derselbe Browser erneut, wird nichts gespeichert (false).
+// Ab reportThreshold Meldungen geht ein freigegebener Kommentar zurück auf pending.
+func (cs *CommentService) ReportComment(id int, reason, ip, reporter string) (bool, error) {
+	if cs.reports == nil {
+		return false, ErrReportsUnsupported
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return false, fmt.Errorf("%w: reason fehlt", ErrInvalidReport)
+	}
+	if utf8.RuneCountInString(reason) > maxReportReason {
+		return false, fmt.Errorf("%w: reason ist länger als %d zeichen", ErrInvalidReport, maxReportReason)
+	}
+
+	comment, err := cs.store.Get(id)
+	if err != nil {
+		return false, err
+	}
+	// Nur öffentlich sichtbare Kommentare können gemeldet werden
+	if comment.Status != StatusApproved {
+		return false, fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+
+	report := &Report{
+		CommentID: id,
+		Reason:    reason,
+		IP:        ip,
+		Reporter:  reporter,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	created, err := cs.reports.CreateReport(report)
+	if err != nil || !created {
+		return false, err
+	}
+
+	reports, err := cs.reports.ListReports(id)
+	if err != nil {
+		return true, err
+	}
+	log.Printf("⚑ Kommentar #%d gemeldet (%d. Meldung)", id, len(reports))
+
+	if cs.reportThreshold > 0 && len(reports) >= cs.reportThreshold {
+		// Automatisch ausblenden, bis ein Moderator entscheidet
+		if err := cs.hideReportedComment(comment); err != nil {
+			log.Printf("⚠️  Kommentar #%d konnte nicht ausgeblendet werden: %v", id, err)
+		} else {
+			log.Printf("⚑ Kommentar #%d nach %d Meldungen ausgeblendet (pending)", id, len(reports))
+		}
+	}
+	return true, nil
+}
+
+// hideReportedComment setzt einen freigegebenen Kommentar zurück auf pending.
+// Der Wechsel ist Moderatoren nicht erlaubt, nur dieser automatischen Aktion.
+func (cs *CommentService) hideReportedComment(comment *Comment) error {
+	if !canSystemTransition(comment.Status, StatusPending) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, comment.Status, StatusPending)
+	}
+	if comment.Status == StatusPending {
+		return nil
+	}
+
+	op := newModerationOp(comment, StatusPending, time.Now())
+	if err := cs.store.Moderate([]ModerationOp{op}); err != nil {
+		return err
+	}
+	cs.afterModeration(op, systemModerator)
+	return nil
+}
+
+// ListReports liefert die Meldungen zu commentID (0 = alle)
+func (cs *CommentService) ListReports(commentID int) ([]*Report, error) {
+	if cs.reports == nil {
+		return nil, ErrReportsUnsupported
+	}
+	return cs.reports.ListReports(commentID)
+}
+
+// DeleteReport verwirft eine Meldung
+func (cs *CommentService) DeleteReport(id int) error {
+	if cs.reports == nil {
+		return ErrReportsUnsupported
+	}
+	return cs.reports.DeleteReport(id)
+}
+
+// DismissReports verwirft alle Meldungen zu einem Kommentar (z.B. nach erneuter Freigabe)
+func (cs *CommentService) DismissReports(commentID int) error {
+	if cs.reports == nil {
+		return ErrReportsUnsupported
+	}
+	return cs.reports.DeleteCommentReports(commentID)
+}
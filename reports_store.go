@@ -0,0 +1,316 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Meldungen werden im selben Backend wie die Kommentare gespeichert
+
+// reportKey liefert den Hash-Key einer Meldung
+func reportKey(id int) string {
+	return fmt.Sprintf("report:%d", id)
+}
+
+// commentReportsKey liefert das Set mit den Meldungs-IDs eines Kommentars
+func commentReportsKey(commentID int) string {
+	return fmt.Sprintf("comment_reports:%d", commentID)
+}
+
+// commentReportersKey liefert das Set mit IPs ("ip:...") und Browsern
+// ("reader:..."), die einen Kommentar gemeldet haben
+func commentReportersKey(commentID int) string {
+	return fmt.Sprintf("comment_reporters:%d", commentID)
+}
+
+// reporterMembers liefert die Einträge einer Meldung in comment_reporters:{id}
+func reporterMembers(ip, reporter string) (string, string) {
+	if reporter == "" {
+		return "ip:" + ip, ""
+	}
+	return "ip:" + ip, "reader:" + reporter
+}
+
+// claimReporterScript trägt IP und Browser atomar ein, sofern noch keiner von
+// beiden den Kommentar gemeldet hat (1 = eingetragen, 0 = schon gemeldet)
+var claimReporterScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[1], ARGV[1]) == 1 then
+	return 0
+end
+if ARGV[2] ~= '' and redis.call('SISMEMBER', KEYS[1], ARGV[2]) == 1 then
+	return 0
+end
+redis.call('SADD', KEYS[1], ARGV[1])
+if ARGV[2] ~= '' then
+	redis.call('SADD', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// ListReports liefert Meldungen aus comment_reports:{id} bzw. reports:index
+func (s *ValkeyStore) ListReports(commentID int) ([]*Report, error) {
+	key := "reports:index"
+	if commentID != 0 {
+		key = commentReportsKey(commentID)
+	}
+	ids, err := s.client.SMembers(s.ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Meldungen: %w", err)
+	}
+
+	reports := make([]*Report, 0, len(ids))
+	for _, value := range ids {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		fields, err := s.client.HGetAll(s.ctx, reportKey(id)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Laden der Meldung %d: %w", id, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		reportCommentID, _ := strconv.Atoi(fields["comment_id"])
+		reports = append(reports, &Report{
+			ID:        id,
+			CommentID: reportCommentID,
+			Reason:    fields["reason"],
+			IP:        fields["ip"],
+			Reporter:  fields["reporter"],
+			CreatedAt: fields["created_at"],
+		})
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID > reports[j].ID })
+	return reports, nil
+}
+
+// CreateReport trägt IP und Browser in comment_reporters:{id} ein, vergibt eine
+// ID (report_counter) und speichert die Meldung
+func (s *ValkeyStore) CreateReport(report *Report) (bool, error) {
+	ipMember, readerMember := reporterMembers(report.IP, report.Reporter)
+	reportersKey := commentReportersKey(report.CommentID)
+	claimed, err := claimReporterScript.Run(s.ctx, s.client, []string{reportersKey}, ipMember, readerMember).Int()
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Prüfen der Meldung: %w", err)
+	}
+	if claimed == 0 {
+		return false, nil
+	}
+	// Schlägt das Speichern fehl, darf derselbe Leser es erneut versuchen
+	release := func() {
+		s.client.SRem(s.ctx, reportersKey, ipMember, readerMember)
+	}
+
+	id, err := s.client.Incr(s.ctx, "report_counter").Result()
+	if err != nil {
+		release()
+		return false, fmt.Errorf("fehler beim Generieren der ID: %w", err)
+	}
+	report.ID = int(id)
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.ctx, reportKey(report.ID), map[string]interface{}{
+		"comment_id": report.CommentID,
+		"reason":     report.Reason,
+		"ip":         report.IP,
+		"reporter":   report.Reporter,
+		"created_at": report.CreatedAt,
+	})
+	pipe.SAdd(s.ctx, "reports:index", report.ID)
+	pipe.SAdd(s.ctx, commentReportsKey(report.CommentID), report.ID)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		release()
+		return false, fmt.Errorf("fehler beim Speichern der Meldung: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteReport entfernt eine Meldung. IP und Browser dürfen danach erneut melden.
+func (s *ValkeyStore) DeleteReport(id int) error {
+	fields, err := s.client.HMGet(s.ctx, reportKey(id), "comment_id", "ip", "reporter").Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Laden der Meldung %d: %w", id, err)
+	}
+	if fields[0] == nil {
+		return fmt.Errorf("meldung %d: %w", id, ErrReportNotFound)
+	}
+	commentID, _ := strconv.Atoi(fmt.Sprint(fields[0]))
+	ip, _ := fields[1].(string)
+	reporter, _ := fields[2].(string)
+	ipMember, readerMember := reporterMembers(ip, reporter)
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(s.ctx, reportKey(id))
+	pipe.SRem(s.ctx, "reports:index", id)
+	pipe.SRem(s.ctx, commentReportsKey(commentID), id)
+	pipe.SRem(s.ctx, commentReportersKey(commentID), ipMember, readerMember)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Löschen der Meldung: %w", err)
+	}
+	return nil
+}
+
+// DeleteCommentReports entfernt alle Meldungen zu einem Kommentar
+func (s *ValkeyStore) DeleteCommentReports(commentID int) error {
+	ids, err := s.client.SMembers(s.ctx, commentReportsKey(commentID)).Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Laden der Meldungen: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	for _, id := range ids {
+		pipe.Del(s.ctx, "report:"+id)
+		pipe.SRem(s.ctx, "reports:index", id)
+	}
+	pipe.Del(s.ctx, commentReportsKey(commentID))
+	pipe.Del(s.ctx, commentReportersKey(commentID))
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Löschen der Meldungen: %w", err)
+	}
+	return nil
+}
+
+// reportColumns ist die Spaltenliste für scanReport
+const reportColumns = "id, comment_id, reason, ip, reporter, created_at"
+
+// scanReport liest eine Zeile mit reportColumns
+func scanReport(row rowScanner) (*Report, error) {
+	var report Report
+	if err := row.Scan(&report.ID, &report.CommentID, &report.Reason, &report.IP, &report.Reporter, &report.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports liefert Meldungen, neueste zuerst
+func (s *SQLStore) ListReports(commentID int) ([]*Report, error) {
+	query := "SELECT " + reportColumns + " FROM comment_reports"
+	var args []interface{}
+	if commentID != 0 {
+		query += " WHERE comment_id = ?"
+		args = append(args, commentID)
+	}
+	rows, err := s.db.Query(s.rebind(query+" ORDER BY id DESC"), args...)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Meldungen: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []*Report{}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen der Meldungen: %w", err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// CreateReport speichert eine neue Meldung. Die eindeutigen Indizes auf
+// (comment_id, ip) und (comment_id, reporter) verhindern doppelte Meldungen.
+func (s *SQLStore) CreateReport(report *Report) (bool, error) {
+	err := s.db.QueryRow(s.rebind(`INSERT INTO comment_reports
+		(comment_id, reason, ip, reporter, created_at)
+		VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id`),
+		report.CommentID, report.Reason, report.IP, report.Reporter, report.CreatedAt,
+	).Scan(&report.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Speichern der Meldung: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteReport entfernt eine Meldung
+func (s *SQLStore) DeleteReport(id int) error {
+	result, err := s.db.Exec(s.rebind("DELETE FROM comment_reports WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen der Meldung: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("meldung %d: %w", id, ErrReportNotFound)
+	}
+	return nil
+}
+
+// DeleteCommentReports entfernt alle Meldungen zu einem Kommentar
+func (s *SQLStore) DeleteCommentReports(commentID int) error {
+	if _, err := s.db.Exec(s.rebind("DELETE FROM comment_reports WHERE comment_id = ?"), commentID); err != nil {
+		return fmt.Errorf("fehler beim Löschen der Meldungen: %w", err)
+	}
+	return nil
+}
+
+// ListReports liefert Meldungen, neueste zuerst
+func (s *MemoryStore) ListReports(commentID int) ([]*Report, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	reports := make([]*Report, 0)
+	for _, report := range s.reports {
+		if commentID != 0 && report.CommentID != commentID {
+			continue
+		}
+		clone := *report
+		reports = append(reports, &clone)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID > reports[j].ID })
+	return reports, nil
+}
+
+// CreateReport vergibt eine neue ID und speichert die Meldung, sofern IP und
+// Browser den Kommentar noch nicht gemeldet haben
+func (s *MemoryStore) CreateReport(report *Report) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, existing := range s.reports {
+		if existing.CommentID != report.CommentID {
+			continue
+		}
+		if existing.IP == report.IP || (report.Reporter != "" && existing.Reporter == report.Reporter) {
+			return false, nil
+		}
+	}
+
+	s.nextReportID++
+	report.ID = s.nextReportID
+	clone := *report
+	s.reports[report.ID] = &clone
+	return true, nil
+}
+
+// DeleteReport entfernt eine Meldung
+func (s *MemoryStore) DeleteReport(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.reports[id]; !ok {
+		return fmt.Errorf("meldung %d: %w", id, ErrReportNotFound)
+	}
+	delete(s.reports, id)
+	return nil
+}
+
+// DeleteCommentReports entfernt alle Meldungen zu einem Kommentar
+func (s *MemoryStore) DeleteCommentReports(commentID int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for id, report := range s.reports {
+		if report.CommentID == commentID {
+			delete(s.reports, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestReportService baut einen CommentService mit Meldungen auf MemoryStore
+func newTestReportService(threshold int) (*CommentService, *MemoryStore) {
+	memory := NewMemoryStore()
+	return &CommentService{store: memory, audit: memory, reports: memory, reportThreshold: threshold}, memory
+}
+
+func TestReportCommentDedupe(t *testing.T) {
+	cs, memory := newTestReportService(0)
+	c := mustCreate(t, memory, newContractComment("post-a", StatusApproved, 0))
+
+	tests := []struct {
+		name     string
+		ip       string
+		reporter string
+		created  bool
+	}{
+		{"erste Meldung", "192.0.2.1", "leser-a", true},
+		{"gleicher Browser, andere IP", "192.0.2.2", "leser-a", false},
+		{"gleiche IP, anderer Browser", "192.0.2.1", "leser-b", false},
+		{"gleiche IP ohne Cookie", "192.0.2.1", "", false},
+		{"anderer Leser", "192.0.2.3", "leser-c", true},
+		{"ohne Cookie, neue IP", "192.0.2.4", "", true},
+		{"ohne Cookie, weitere IP", "192.0.2.5", "", true},
+	}
+	for _, tt := range tests {
+		created, err := cs.ReportComment(c.ID, "Beleidigung", tt.ip, tt.reporter)
+		if err != nil || created != tt.created {
+			t.Errorf("%s: ReportComment = %v, %v, erwartet %v", tt.name, created, err, tt.created)
+		}
+	}
+
+	reports, err := cs.ListReports(c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 4 {
+		t.Errorf("%d Meldungen gespeichert, erwartet 4", len(reports))
+	}
+
+	// Meldungen zu anderen Kommentaren zählen getrennt
+	other := mustCreate(t, memory, newContractComment("post-a", StatusApproved, 0))
+	if created, err := cs.ReportComment(other.ID, "Spam", "192.0.2.1", "leser-a"); err != nil || !created {
+		t.Errorf("anderer Kommentar: %v, %v", created, err)
+	}
+}
+
+func TestReportCommentRejected(t *testing.T) {
+	cs, memory := newTestReportService(0)
+	approved := mustCreate(t, memory, newContractComment("post-a", StatusApproved, 0))
+	pending := mustCreate(t, memory, newContractComment("post-a", StatusPending, 0))
+
+	if _, err := cs.ReportComment(approved.ID, "  ", "192.0.2.1", ""); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("ohne Grund: %v", err)
+	}
+	if _, err := cs.ReportComment(approved.ID, strings.Repeat("x", maxReportReason+1), "192.0.2.1", ""); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("zu langer Grund: %v", err)
+	}
+	if _, err := cs.ReportComment(pending.ID, "Spam", "192.0.2.1", ""); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("nicht freigegeben: %v", err)
+	}
+	if _, err := (&CommentService{store: memory}).ReportComment(approved.ID, "Spam", "192.0.2.1", ""); err != ErrReportsUnsupported {
+		t.Errorf("ohne ReportStore: %v", err)
+	}
+}
+
+func TestReportCommentThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		reports   int
+		want      CommentStatus
+	}{
+		{"nie ausblenden", 0, 5, StatusApproved},
+		{"unter der Schwelle", 3, 2, StatusApproved},
+		{"Schwelle erreicht", 3, 3, StatusPending},
+		{"Schwelle 1", 1, 1, StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, memory := newTestReportService(tt.threshold)
+			c := mustCreate(t, memory, newContractComment("post-a", StatusApproved, 0))
+			for i := 0; i < tt.reports; i++ {
+				ip := "192.0.2." + strconv.Itoa(i+1)
+				if created, err := cs.ReportComment(c.ID, "Beleidigung", ip, ""); err != nil || !created {
+					t.Fatalf("Meldung %d: %v, %v", i+1, created, err)
+				}
+			}
+			got, err := memory.Get(c.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("Status %s, erwartet %s", got.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportedCommentApprovedAgain(t *testing.T) {
+	cs, memory := newTestReportService(2)
+	c := mustCreate(t, memory, newContractComment("post-a", StatusApproved, 0))
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2"} {
+		if _, err := cs.ReportComment(c.ID, "Beleidigung", ip, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, _, err := memory.ListAudit(AuditFilter{CommentID: c.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Actor != systemModerator.Name || entries[0].NewStatus != StatusPending {
+		t.Errorf("Audit-Log %+v, erwartet Ausblenden durch system", entries)
+	}
+	// Ausgeblendete Kommentare lassen sich nicht weiter melden
+	if _, err := cs.ReportComment(c.ID, "Beleidigung", "192.0.2.3", ""); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Meldung nach dem Ausblenden: %v", err)
+	}
+
+	// Gibt ein Moderator den Kommentar wieder frei, sind die Meldungen erledigt
+	if _, err := cs.UpdateCommentStatus(c.ID, StatusApproved, Moderator{Name: "mod"}); err != nil {
+		t.Fatalf("UpdateCommentStatus: %v", err)
+	}
+	if reports, _ := cs.ListReports(c.ID); len(reports) != 0 {
+		t.Errorf("%d Meldungen nach Freigabe, erwartet keine", len(reports))
+	}
+	if _, err := cs.ReportComment(c.ID, "Beleidigung", "192.0.2.1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := memory.Get(c.ID); got.Status != StatusApproved {
+		t.Errorf("nach einer neuen Meldung %s, erwartet approved", got.Status)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ownCommentsCookieName ist bewusst neutral, das Cookie bekommt jeder Absender
+const ownCommentsCookieName = "comments_session"
+
+// OwnCommentsCookie merkt sich in einem signierten Cookie die IDs der
+// Kommentare, die ein Browser selbst geschrieben hat. Darüber sieht ein
+// Absender im Shadow-Ban seine eigenen Kommentare, alle anderen nicht.
+type OwnCommentsCookie struct {
+	secret []byte
+	maxAge time.Duration
+	maxIDs int // ältere IDs fallen aus dem Cookie
+}
+
+// NewOwnCommentsCookie liest die Konfiguration aus der Umgebung
+func NewOwnCommentsCookie() (*OwnCommentsCookie, error) {
+	secret, err := derivedSecret("COOKIE_SECRET", "own-comments")
+	if err != nil {
+		return nil, err
+	}
+
+	return &OwnCommentsCookie{
+		secret: secret,
+		maxAge: getEnvAsDuration("COOKIE_MAX_AGE", 30*24*time.Hour),
+		maxIDs: getEnvAsInt("COOKIE_MAX_COMMENTS", 50),
+	}, nil
+}
+
+// sign liefert die HMAC-Signatur über den Cookie-Inhalt
+func (c *OwnCommentsCookie) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// IDs liefert die Kommentar-IDs aus dem Cookie (leer bei fehlendem oder ungültigem Cookie)
+func (c *OwnCommentsCookie) IDs(r *http.Request) []int {
+	if c == nil {
+		return nil
+	}
+	cookie, err := r.Cookie(ownCommentsCookieName)
+	if err != nil {
+		return nil
+	}
+
+	encoding := base64.RawURLEncoding
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	payload, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return nil
+	}
+	signature, err := encoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return nil
+	}
+
+	var ids []int
+	for _, value := range strings.Split(string(payload), ",") {
+		if id, err := strconv.Atoi(value); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Add hängt eine Kommentar-ID an und setzt das Cookie neu
+func (c *OwnCommentsCookie) Add(w http.ResponseWriter, r *http.Request, id int) {
+	if c == nil {
+		return
+	}
+
+	ids := append(c.IDs(r), id)
+	if len(ids) > c.maxIDs {
+		ids = ids[len(ids)-c.maxIDs:]
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	payload := []byte(strings.Join(values, ","))
+
+	encoding := base64.RawURLEncoding
+	cookie := &http.Cookie{
+		Name:     ownCommentsCookieName,
+		Value:    encoding.EncodeToString(payload) + "." + encoding.EncodeToString(c.sign(payload)),
+		Path:     "/",
+		MaxAge:   int(c.maxAge.Seconds()),
+		HttpOnly: true,
+	}
+	setWidgetCookie(w, r, cookie)
+}
+
+// setWidgetCookie setzt ein Cookie, das das Widget per credentials: 'include'
+// mitsendet. Das Widget läuft meist auf einer anderen Domain als die API,
+// Cross-Site-Cookies gehen nur mit SameSite=None über HTTPS.
+func setWidgetCookie(w http.ResponseWriter, r *http.Request, cookie *http.Cookie) {
+	cookie.SameSite = http.SameSiteLaxMode
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		cookie.SameSite = http.SameSiteNoneMode
+		cookie.Secure = true
+	}
+	http.SetCookie(w, cookie)
+}
+
+// ownShadowComments liefert die Shadow-Ban-Kommentare aus ids, wie sie der
+// Absender sehen soll (als freigegeben). postID leer = alle Posts.
+func (cs *CommentService) ownShadowComments(postID string, ids []int) []*Comment {
+	var own []*Comment
+	for _, id := range ids {
+		comment, err := cs.store.Get(id)
+		if err != nil || !comment.Shadowed || comment.Status != StatusSpam {
+			continue
+		}
+		if postID != "" && comment.PostID != postID {
+			continue
+		}
+		comment.setStatus(StatusApproved)
+		own = append(own, comment)
+	}
+	return own
+}
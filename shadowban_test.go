@@ -0,0 +1,40 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOwnCommentsCookieUsesOwnKey(t *testing.T) {
+	t.Setenv("FORM_TOKEN_SECRET", "basis")
+	cookies, err := NewOwnCommentsCookie()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Eigene Signatur gilt
+	rec := httptest.NewRecorder()
+	cookies.Add(rec, httptest.NewRequest("POST", "/api/comments", nil), 7)
+	r := httptest.NewRequest("GET", "/api/comments", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	if ids := cookies.IDs(r); !reflect.DeepEqual(ids, []int{7}) {
+		t.Fatalf("IDs %v, erwartet [7]", ids)
+	}
+
+	// Mit FORM_TOKEN_SECRET selbst signierte Cookies gelten nicht
+	payload := []byte("7,8")
+	mac := hmac.New(sha256.New, []byte("basis"))
+	mac.Write(payload)
+	encoding := base64.RawURLEncoding
+	forged := httptest.NewRequest("GET", "/api/comments", nil)
+	forged.Header.Set("Cookie", ownCommentsCookieName+"="+encoding.EncodeToString(payload)+"."+encoding.EncodeToString(mac.Sum(nil)))
+	if ids := cookies.IDs(forged); len(ids) != 0 {
+		t.Errorf("Cookie mit FORM_TOKEN_SECRET akzeptiert: %v", ids)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SpamCheck ist ein Baustein der Spam-Pipeline. Check liefert einen Score-Beitrag
+// und eine Begründung (0 und "" wenn nichts auffällt).
+type SpamCheck interface {
+	Name() string
+	Check(c *Comment) (float64, string)
+}
+
+// SpamResult ist das Ergebnis der Pipeline für einen Kommentar
+type SpamResult struct {
+	Score   float64
+	Reasons []string
+	Status  CommentStatus
+}
+
+// SpamPipeline bewertet neue Kommentare und entscheidet über den Startstatus
+type SpamPipeline struct {
+	checks []SpamCheck
+	// ab spamThreshold wird als Spam markiert
+	spamThreshold float64
+	// unter approveBelow wird direkt freigegeben (0 = nie)
+	approveBelow float64
+}
+
+// NewSpamPipeline erstellt die eingebaute Pipeline aus der Umgebung.
+// Liefert nil, wenn SPAM_ENABLED=false.
+func NewSpamPipeline(store CommentStore) *SpamPipeline {
+	if !getEnvAsBool("SPAM_ENABLED", true) {
+		return nil
+	}
+
+	return &SpamPipeline{
+		checks: []SpamCheck{
+			&linkCountCheck{maxLinks: getEnvAsInt("SPAM_MAX_LINKS", 2)},
+			&blocklistWordCheck{words: splitList(getEnv("SPAM_BLOCKLIST_WORDS", ""))},
+			&blocklistDomainCheck{domains: splitList(getEnv("SPAM_BLOCKLIST_DOMAINS", ""))},
+			&repeatedTextCheck{store: store},
+			&allCapsCheck{},
+			&spamEmailDomainCheck{domains: splitList(getEnv("SPAM_EMAIL_DOMAINS", defaultSpamEmailDomains))},
+		},
+		spamThreshold: getEnvAsFloat("SPAM_THRESHOLD", 5),
+		approveBelow:  getEnvAsFloat("SPAM_APPROVE_BELOW", 0),
+	}
+}
+
+// AddCheck hängt einen weiteren Check an die Pipeline an
+func (p *SpamPipeline) AddCheck(check SpamCheck) {
+	p.checks = append(p.checks, check)
+}
+
+// Evaluate führt alle Checks aus und bestimmt den Startstatus
+func (p *SpamPipeline) Evaluate(c *Comment) SpamResult {
+	result := SpamResult{Status: StatusPending}
+	if p == nil {
+		return result
+	}
+
+	for _, check := range p.checks {
+		score, reason := check.Check(c)
+		if score == 0 {
+			continue
+		}
+		result.Score += score
+		result.Reasons = append(result.Reasons, fmt.Sprintf("%s: %s (%+.1f)", check.Name(), reason, score))
+	}
+
+	switch {
+	case result.Score >= p.spamThreshold:
+		result.Status = StatusSpam
+	case result.Score < p.approveBelow:
+		result.Status = StatusApproved
+	}
+
+	log.Printf("🛡️  Spam-Score %.1f -> %s %v", result.Score, result.Status, result.Reasons)
+	return result
+}
+
+// defaultSpamEmailDomains sind bekannte Wegwerf-Adressen
+const defaultSpamEmailDomains = "mailinator.com,guerrillamail.com,10minutemail.com,trashmail.com,yopmail.com,tempmail.com,sharklasers.com,getnada.com"
+
+// splitList teilt eine kommagetrennte Liste (klein geschrieben, ohne Leereinträge)
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// linkPattern findet Links im Kommentartext
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+// extractLinkHosts liefert die Hosts aller Links im Text
+func extractLinkHosts(text string) []string {
+	var hosts []string
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			hosts = append(hosts, strings.ToLower(u.Hostname()))
+		}
+	}
+	return hosts
+}
+
+// linkCountCheck bewertet Kommentare mit vielen Links
+type linkCountCheck struct {
+	maxLinks int
+}
+
+func (c *linkCountCheck) Name() string { return "links" }
+
+func (c *linkCountCheck) Check(comment *Comment) (float64, string) {
+	links := len(linkPattern.FindAllString(comment.Text, -1))
+	if links <= c.maxLinks {
+		return 0, ""
+	}
+	return 2 + float64(links-c.maxLinks-1), fmt.Sprintf("%d Links", links)
+}
+
+// blocklistWordCheck bewertet gesperrte Wörter in Text und Name
+type blocklistWordCheck struct {
+	words []string
+}
+
+func (c *blocklistWordCheck) Name() string { return "wörter" }
+
+func (c *blocklistWordCheck) Check(comment *Comment) (float64, string) {
+	content := strings.ToLower(comment.Username + " " + comment.Text)
+
+	var hits []string
+	for _, word := range c.words {
+		if strings.Contains(content, word) {
+			hits = append(hits, word)
+		}
+	}
+	if len(hits) == 0 {
+		return 0, ""
+	}
+	return 3 * float64(len(hits)), strings.Join(hits, ", ")
+}
+
+// blocklistDomainCheck bewertet Links auf gesperrte Domains (inkl. Subdomains)
+type blocklistDomainCheck struct {
+	domains []string
+}
+
+func (c *blocklistDomainCheck) Name() string { return "domains" }
+
+func (c *blocklistDomainCheck) Check(comment *Comment) (float64, string) {
+	var hits []string
+	for _, host := range extractLinkHosts(comment.Text) {
+		for _, domain := range c.domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				hits = append(hits, host)
+			}
+		}
+	}
+	if len(hits) == 0 {
+		return 0, ""
+	}
+	return 5 * float64(len(hits)), strings.Join(hits, ", ")
+}
+
+// repeatedTextCheck erkennt Wiederholungen innerhalb des Textes und bereits
+// gepostete identische Texte (im selben Post über den Store, postübergreifend
+// über die Hashes der letzten Texte dieser Instanz)
+type repeatedTextCheck struct {
+	store CommentStore
+
+	mu     sync.Mutex
+	recent map[[sha256.Size]byte]string // Text-Hash -> Post-ID
+	order  [][sha256.Size]byte          // Reihenfolge zum Verdrängen der ältesten
+}
+
+// recentCommentWindow ist die Anzahl der letzten Kommentare für den Duplikat-Vergleich
+const recentCommentWindow = 100
+
+func (c *repeatedTextCheck) Name() string { return "wiederholung" }
+
+func (c *repeatedTextCheck) Check(comment *Comment) (float64, string) {
+	normalized := normalizeText(comment.Text)
+
+	if c.store != nil && comment.PostID != "" {
+		page, err := c.store.ListByPost(comment.PostID, true, CommentQuery{Limit: recentCommentWindow, Sort: SortNewest})
+		if err == nil {
+			for _, existing := range page.Items {
+				if existing.ID != comment.ID && normalizeText(existing.Text) == normalized {
+					c.remember(normalized, comment.PostID)
+					return 4, fmt.Sprintf("identisch mit Kommentar #%d", existing.ID)
+				}
+			}
+		}
+	}
+	if postID, seen := c.remember(normalized, comment.PostID); seen {
+		return 4, fmt.Sprintf("identisch mit einem Kommentar zu %s", postID)
+	}
+
+	words := strings.Fields(normalized)
+	if len(words) < 10 {
+		return 0, ""
+	}
+	unique := make(map[string]bool, len(words))
+	for _, word := range words {
+		unique[word] = true
+	}
+	if ratio := float64(len(unique)) / float64(len(words)); ratio < 0.3 {
+		return 2, fmt.Sprintf("%d von %d Wörtern verschieden", len(unique), len(words))
+	}
+	return 0, ""
+}
+
+// remember merkt sich den Text und meldet, zu welchem Post er zuletzt kam
+func (c *repeatedTextCheck) remember(normalized, postID string) (string, bool) {
+	hash := sha256.Sum256([]byte(normalized))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.recent == nil {
+		c.recent = make(map[[sha256.Size]byte]string, recentCommentWindow)
+	}
+	previous, seen := c.recent[hash]
+	if !seen {
+		if len(c.order) >= recentCommentWindow {
+			delete(c.recent, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, hash)
+	}
+	c.recent[hash] = postID
+	return previous, seen
+}
+
+// normalizeText vereinheitlicht Groß-/Kleinschreibung und Leerraum für Vergleiche
+func normalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// allCapsCheck bewertet überwiegend groß geschriebene Texte
+type allCapsCheck struct{}
+
+func (c *allCapsCheck) Name() string { return "großschreibung" }
+
+func (c *allCapsCheck) Check(comment *Comment) (float64, string) {
+	letters, upper := 0, 0
+	for _, r := range comment.Text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	if letters < 20 || float64(upper)/float64(letters) < 0.7 {
+		return 0, ""
+	}
+	return 1.5, fmt.Sprintf("%d%% Großbuchstaben", upper*100/letters)
+}
+
+// spamEmailDomainCheck bewertet bekannte Spam-/Wegwerf-Domains der E-Mail-Adresse
+type spamEmailDomainCheck struct {
+	domains []string
+}
+
+func (c *spamEmailDomainCheck) Name() string { return "e-mail" }
+
+func (c *spamEmailDomainCheck) Check(comment *Comment) (float64, string) {
+	at := strings.LastIndex(comment.MailAddress, "@")
+	if at < 0 {
+		return 0, ""
+	}
+	domain := strings.ToLower(strings.TrimSpace(comment.MailAddress[at+1:]))
+	for _, blocked := range c.domains {
+		if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+			return 3, domain
+		}
+	}
+	return 0, ""
+}
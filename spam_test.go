@@ -0,0 +1,141 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// listAllStore schlägt fehl, sobald die Spam-Prüfung alle Kommentare lädt
+type listAllStore struct {
+	CommentStore
+	t *testing.T
+}
+
+func (s *listAllStore) ListAll(includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	s.t.Error("ListAll im Hot Path der Spam-Prüfung")
+	return s.CommentStore.ListAll(includeInactive, q)
+}
+
+func TestSpamChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  SpamCheck
+		c      Comment
+		score  float64
+		reason string
+	}{
+		{"links unter Grenze", &linkCountCheck{maxLinks: 2}, Comment{Text: "siehe https://a.example und www.b.example"}, 0, ""},
+		{"links über Grenze", &linkCountCheck{maxLinks: 2}, Comment{Text: "https://a.example https://b.example https://c.example https://d.example"}, 3, "4 Links"},
+		{"wörter ohne Treffer", &blocklistWordCheck{words: []string{"casino"}}, Comment{Username: "Anna", Text: "Schöner Artikel"}, 0, ""},
+		{"wörter in Text und Name", &blocklistWordCheck{words: []string{"casino", "viagra"}}, Comment{Username: "Viagra Shop", Text: "Bestes CASINO"}, 6, "casino, viagra"},
+		{"domains ohne Treffer", &blocklistDomainCheck{domains: []string{"spam.example"}}, Comment{Text: "https://nospam.example/x"}, 0, ""},
+		{"domains mit Subdomain", &blocklistDomainCheck{domains: []string{"spam.example"}}, Comment{Text: "https://www.spam.example/x"}, 5, "www.spam.example"},
+		{"wiederholung normaler Text", &repeatedTextCheck{}, Comment{PostID: "post-a", Text: "eins zwei drei vier fünf sechs sieben acht neun zehn"}, 0, ""},
+		{"wiederholung kurz", &repeatedTextCheck{}, Comment{PostID: "post-a", Text: "ja ja ja ja"}, 0, ""},
+		{"wiederholung innerhalb des Textes", &repeatedTextCheck{}, Comment{PostID: "post-a", Text: strings.Repeat("kaufen jetzt ", 6)}, 2, "2 von 12 Wörtern verschieden"},
+		{"großschreibung kurz", &allCapsCheck{}, Comment{Text: "HALLO WELT"}, 0, ""},
+		{"großschreibung normal", &allCapsCheck{}, Comment{Text: "Das ist ein ganz normaler Satz mit Inhalt"}, 0, ""},
+		{"großschreibung", &allCapsCheck{}, Comment{Text: "DAS IST EIN GANZ LAUTER SATZ MIT INHALT"}, 1.5, "100% Großbuchstaben"},
+		{"e-mail normal", &spamEmailDomainCheck{domains: []string{"mailinator.com"}}, Comment{MailAddress: "anna@example.com"}, 0, ""},
+		{"e-mail ohne @", &spamEmailDomainCheck{domains: []string{"mailinator.com"}}, Comment{MailAddress: "kaputt"}, 0, ""},
+		{"e-mail Wegwerf-Domain", &spamEmailDomainCheck{domains: []string{"mailinator.com"}}, Comment{MailAddress: "bot@EU.Mailinator.com"}, 3, "eu.mailinator.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason := tt.check.Check(&tt.c)
+			if score != tt.score || reason != tt.reason {
+				t.Errorf("Check = %v, %q, erwartet %v, %q", score, reason, tt.score, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRepeatedTextCheck(t *testing.T) {
+	memory := NewMemoryStore()
+	existing := newContractComment("post-a", StatusRejected, 0)
+	existing.Text = "Tolles   Angebot"
+	mustCreate(t, memory, existing)
+	check := &repeatedTextCheck{store: &listAllStore{CommentStore: memory, t: t}}
+
+	tests := []struct {
+		name   string
+		postID string
+		text   string
+		score  float64
+		reason string
+	}{
+		{"neuer Text", "post-b", "Schöner Artikel", 0, ""},
+		{"gleicher Post, auch abgelehnt", "post-a", "tolles angebot", 4, "identisch mit Kommentar #" + strconv.Itoa(existing.ID)},
+		{"anderer Post, schon gesehen", "post-c", "TOLLES ANGEBOT", 4, "identisch mit einem Kommentar zu post-a"},
+		{"anderer Post, neuer Text schon gesehen", "post-c", "Schöner Artikel", 4, "identisch mit einem Kommentar zu post-b"},
+		{"anderer Text", "post-c", "Noch ein Gedanke", 0, ""},
+	}
+	for _, tt := range tests {
+		score, reason := check.Check(&Comment{PostID: tt.postID, Text: tt.text})
+		if score != tt.score || reason != tt.reason {
+			t.Errorf("%s: Check = %v, %q, erwartet %v, %q", tt.name, score, reason, tt.score, tt.reason)
+		}
+	}
+}
+
+func TestRepeatedTextCheckForgetsOldTexts(t *testing.T) {
+	check := &repeatedTextCheck{}
+	check.Check(&Comment{PostID: "post-a", Text: "der erste Text"})
+	for i := 0; i < recentCommentWindow; i++ {
+		check.Check(&Comment{PostID: "post-a", Text: "Text " + strings.Repeat("x", i+1)})
+	}
+	if len(check.recent) != recentCommentWindow || len(check.order) != recentCommentWindow {
+		t.Fatalf("%d Hashes gemerkt, erwartet %d", len(check.recent), recentCommentWindow)
+	}
+	if score, reason := check.Check(&Comment{PostID: "post-b", Text: "der erste Text"}); score != 0 {
+		t.Errorf("verdrängter Text gemeldet: %q", reason)
+	}
+}
+
+// fixedCheck liefert immer denselben Score
+type fixedCheck float64
+
+func (c fixedCheck) Name() string { return "fest" }
+
+func (c fixedCheck) Check(*Comment) (float64, string) {
+	if c == 0 {
+		return 0, ""
+	}
+	return float64(c), "test"
+}
+
+func TestSpamPipelineThreshold(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       []float64
+		approveBelow float64
+		status       CommentStatus
+		reasons      int
+	}{
+		{"ohne Auffälligkeiten", []float64{0, 0}, 0, StatusPending, 0},
+		{"unter der Schwelle", []float64{2, 2.5}, 0, StatusPending, 2},
+		{"genau die Schwelle", []float64{2, 3}, 0, StatusSpam, 2},
+		{"über der Schwelle", []float64{5, 1}, 0, StatusSpam, 2},
+		{"freigegeben unter approveBelow", []float64{0, 0}, 1, StatusApproved, 0},
+		{"approveBelow erreicht", []float64{1}, 1, StatusPending, 1},
+		{"negativer Beitrag", []float64{6, -2}, 0, StatusPending, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &SpamPipeline{spamThreshold: 5, approveBelow: tt.approveBelow}
+			for _, score := range tt.scores {
+				p.AddCheck(fixedCheck(score))
+			}
+			result := p.Evaluate(&Comment{Text: "Hallo"})
+			if result.Status != tt.status || len(result.Reasons) != tt.reasons {
+				t.Errorf("Evaluate = %+v, erwartet %s mit %d Begründungen", result, tt.status, tt.reasons)
+			}
+		})
+	}
+
+	var disabled *SpamPipeline
+	if result := disabled.Evaluate(&Comment{Text: "Hallo"}); result.Status != StatusPending {
+		t.Errorf("ohne Pipeline %s, erwartet pending", result.Status)
+	}
+}
@@ -314,6 +314,19 @@ window.CommentWidget = (function() {
         container.innerHTML = commentsHTML;
     }
 
+    // Formular-Token holen (einmal verwendbar, frühestens nach FORM_MIN_AGE gültig)
+    async function loadFormToken(container) {
+        try {
+            const response = await fetch(`${config.apiUrl}/form-token`);
+            if (response.ok) {
+                const data = await response.json();
+                container.dataset.formToken = data.form_token || '';
+            }
+        } catch (error) {
+            console.error('Fehler beim Laden des Formular-Tokens:', error);
+        }
+    }
+
     // Kommentar absenden
     async function submitComment(postId, formData, container) {
         const submitBtn = container.querySelector('.comment-submit-btn');
@@ -326,7 +339,8 @@ window.CommentWidget = (function() {
             post_id: postId,
             username: formData.get('username'),
             mailaddress: formData.get('mailaddress'),
-            text: formData.get('text')
+            text: formData.get('text'),
+            form_token: container.dataset.formToken || ''
         };
 
         try {
@@ -350,6 +364,8 @@ window.CommentWidget = (function() {
             console.error('Fehler beim Absenden:', error);
             showMessage(container, 'Verbindungsfehler. Bitte versuche es später erneut.', 'error');
         } finally {
+            // Das Token ist verbraucht, für den nächsten Kommentar ein neues holen
+            loadFormToken(container);
             submitBtn.disabled = false;
             submitBtn.textContent = 'Kommentar absenden';
         }
@@ -375,7 +391,8 @@ window.CommentWidget = (function() {
             await submitComment(postId, formData, widget);
         });
 
-        // Kommentare initial laden
+        // Formular-Token und Kommentare initial laden
+        loadFormToken(widget);
         loadComments(postId, widget);
 
         // Auto-refresh alle 60 Sekunden
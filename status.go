@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CommentStatus ist der Moderationsstatus eines Kommentars
+type CommentStatus string
+
+// Moderationsstatus
+const (
+	StatusPending  CommentStatus = "pending"  // noch nicht geprüft
+	StatusApproved CommentStatus = "approved" // freigegeben und öffentlich sichtbar
+	StatusRejected CommentStatus = "rejected" // abgelehnt
+	StatusSpam     CommentStatus = "spam"     // als Spam markiert
+	StatusDeleted  CommentStatus = "deleted"  // im Papierkorb (wiederherstellbar bis zum Purge)
+)
+
+// allStatuses enthält alle Status in Anzeigereihenfolge
+var allStatuses = []CommentStatus{StatusPending, StatusApproved, StatusRejected, StatusSpam, StatusDeleted}
+
+// Fehler bei Statuswechseln
+var (
+	ErrInvalidStatus     = errors.New("ungültiger Status")
+	ErrInvalidTransition = errors.New("statuswechsel nicht erlaubt")
+)
+
+// statusTransitions legt fest, welche Wechsel erlaubt sind.
+// Geprüfte Kommentare können nicht zurück auf pending, aus deleted (Papierkorb)
+// geht es nur über RestoreComment zurück.
+var statusTransitions = map[CommentStatus][]CommentStatus{
+	StatusPending:  {StatusApproved, StatusRejected, StatusSpam, StatusDeleted},
+	StatusApproved: {StatusRejected, StatusSpam, StatusDeleted},
+	StatusRejected: {StatusApproved, StatusSpam, StatusDeleted},
+	StatusSpam:     {StatusApproved, StatusRejected, StatusDeleted},
+	StatusDeleted:  {},
+}
+
+// systemTransitions sind zusätzliche Wechsel, die nur automatische Aktionen
+// auslösen dürfen: gemeldete Kommentare gehen zurück in die Warteschlange
+var systemTransitions = map[CommentStatus][]CommentStatus{
+	StatusApproved: {StatusPending},
+}
+
+// parseCommentStatus prüft einen Status aus Request oder Speicher
+func parseCommentStatus(value string) (CommentStatus, error) {
+	status := CommentStatus(value)
+	if _, ok := statusTransitions[status]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidStatus, value)
+	}
+	return status, nil
+}
+
+// canTransition prüft, ob von from nach to gewechselt werden darf (gleicher Status ist erlaubt)
+func canTransition(from, to CommentStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// canSystemTransition prüft einen Wechsel durch eine automatische Aktion
+func canSystemTransition(from, to CommentStatus) bool {
+	if canTransition(from, to) {
+		return true
+	}
+	for _, allowed := range systemTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// statusFromActive leitet den Status von Daten ohne status-Feld ab
+func statusFromActive(active bool) CommentStatus {
+	if active {
+		return StatusApproved
+	}
+	return StatusPending
+}
+
+// statusForActive übersetzt das alte active-Flag in einen Statuswechsel:
+// true gibt frei, false lehnt freigegebene Kommentare ab und lässt andere unverändert
+func statusForActive(current CommentStatus, active bool) CommentStatus {
+	if active {
+		return StatusApproved
+	}
+	if current == StatusApproved {
+		return StatusRejected
+	}
+	return current
+}
+
+// setStatus setzt Status und die Kompatibilitätsansicht Active
+func (c *Comment) setStatus(status CommentStatus) {
+	c.Status = status
+	c.Active = status == StatusApproved
+}
+
+// moderate setzt einen neuen Status und pflegt die Papierkorb-Felder:
+// beim Löschen werden Zeitpunkt und vorheriger Status gemerkt, sonst geleert
+func (c *Comment) moderate(status CommentStatus, now time.Time) {
+	switch {
+	case status == StatusDeleted && c.Status != StatusDeleted:
+		c.DeletedAt = now.UTC().Format(time.RFC3339)
+		c.DeletedFrom = c.Status
+	case status != StatusDeleted:
+		c.DeletedAt = ""
+		c.DeletedFrom = ""
+	}
+	c.setStatus(status)
+}
+
+// restoreStatus liefert den Status, den ein Kommentar beim Wiederherstellen bekommt
+func (c *Comment) restoreStatus() CommentStatus {
+	if c.DeletedFrom == "" || c.DeletedFrom == StatusDeleted {
+		// Vor Einführung des Papierkorbs gelöscht: erneut prüfen lassen
+		return StatusPending
+	}
+	return c.DeletedFrom
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Vertrag des CommentStore: dieselben Tests laufen gegen jedes Backend
+// (siehe store_memory_test.go, store_postgres_test.go, store_valkey_test.go)
+
+// contractBase ist der Zeitpunkt der Testkommentare (ganze Sekunden wie in RFC 3339)
+var contractBase = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// testCommentStore führt die Vertragstests aus; newStore liefert pro Test einen leeren Store
+func testCommentStore(t *testing.T, newStore func(t *testing.T) CommentStore) {
+	t.Run("CreateGet", func(t *testing.T) { contractCreateGet(t, newStore(t)) })
+	t.Run("Pagination", func(t *testing.T) { contractPagination(t, newStore(t)) })
+	t.Run("Filters", func(t *testing.T) { contractFilters(t, newStore(t)) })
+	t.Run("StatusTransitions", func(t *testing.T) { contractStatusTransitions(t, newStore(t)) })
+	t.Run("Counts", func(t *testing.T) { contractCounts(t, newStore(t)) })
+	t.Run("Delete", func(t *testing.T) { contractDelete(t, newStore(t)) })
+	t.Run("PostIDCollisions", func(t *testing.T) { contractPostIDCollisions(t, newStore(t)) })
+}
+
+// newContractComment baut einen Kommentar, offset verschiebt den Zeitpunkt
+func newContractComment(postID string, status CommentStatus, offset time.Duration) *Comment {
+	c := &Comment{
+		PostID:      postID,
+		Username:    "Anna",
+		MailAddress: "anna@example.com",
+		Text:        "Hallo Welt",
+		CreatedAt:   contractBase.Add(offset).Format(time.RFC3339),
+	}
+	c.setStatus(status)
+	return c
+}
+
+// mustCreate speichert einen Kommentar oder bricht den Test ab
+func mustCreate(t *testing.T, store CommentStore, c *Comment) *Comment {
+	t.Helper()
+	if err := store.Create(c); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if c.ID <= 0 {
+		t.Fatalf("Create vergibt keine ID: %d", c.ID)
+	}
+	return c
+}
+
+func contractCreateGet(t *testing.T, store CommentStore) {
+	parent := mustCreate(t, store, newContractComment("post-a", StatusApproved, 0))
+	reply := newContractComment("post-a", StatusSpam, time.Minute)
+	reply.ParentID = parent.ID
+	reply.SpamScore = 0.75
+	reply.SpamReasons = []string{"link-anzahl: 5", "stoppwort: casino"}
+	reply.DecisionReason = "vertrauenswürdig: manuell freigeschaltet"
+	reply.IP = "192.0.2.10"
+	reply.UserAgent = "Mozilla/5.0"
+	reply.Shadowed = true
+	reply.EmailVerified = true
+	mustCreate(t, store, reply)
+
+	if reply.ID <= parent.ID {
+		t.Errorf("IDs nicht aufsteigend: %d nach %d", reply.ID, parent.ID)
+	}
+
+	got, err := store.Get(reply.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !reflect.DeepEqual(got, reply) {
+		t.Errorf("Get liefert\n%+v\nerwartet\n%+v", got, reply)
+	}
+
+	// Änderungen am Ergebnis dürfen den Store nicht verändern
+	got.Text = "geändert"
+	if again, _ := store.Get(reply.ID); again.Text != reply.Text {
+		t.Errorf("Get liefert keine Kopie: %q", again.Text)
+	}
+
+	if _, err := store.Get(reply.ID + 100); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Get unbekannte ID: %v, erwartet ErrCommentNotFound", err)
+	}
+}
+
+// collectPages blättert mit limit durch eine Liste und liefert alle Kommentare
+func collectPages(t *testing.T, limit int, list func(q CommentQuery) (*CommentPage, error), q CommentQuery) ([]*Comment, int) {
+	t.Helper()
+	q.Limit = limit
+	var all []*Comment
+	total := -1
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("Cursor endet nicht")
+		}
+		page, err := list(q)
+		if err != nil {
+			t.Fatalf("Seite %d: %v", pages, err)
+		}
+		if len(page.Items) > limit {
+			t.Fatalf("Seite %d hat %d Einträge, limit %d", pages, len(page.Items), limit)
+		}
+		if total == -1 {
+			total = page.Total
+		}
+		all = append(all, page.Items...)
+		if page.NextCursor == "" {
+			return all, total
+		}
+		if q.Cursor, err = decodePageCursor(page.NextCursor); err != nil {
+			t.Fatalf("Cursor %q: %v", page.NextCursor, err)
+		}
+	}
+}
+
+// checkOrder prüft, dass jede ID genau einmal und in Zeitreihenfolge vorkommt
+func checkOrder(t *testing.T, comments []*Comment, want map[int]bool, sort string) {
+	t.Helper()
+	seen := make(map[int]bool, len(comments))
+	for i, c := range comments {
+		if seen[c.ID] {
+			t.Errorf("Kommentar %d doppelt", c.ID)
+		}
+		seen[c.ID] = true
+		if !want[c.ID] {
+			t.Errorf("Kommentar %d unerwartet in der Liste", c.ID)
+		}
+		if i == 0 {
+			continue
+		}
+		prev, cur := commentTime(comments[i-1]), commentTime(c)
+		if (sort == SortNewest && cur.After(prev)) || (sort != SortNewest && cur.Before(prev)) {
+			t.Errorf("Reihenfolge %s verletzt: %d (%s) nach %d (%s)", sort, c.ID, c.CreatedAt, comments[i-1].ID, comments[i-1].CreatedAt)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("%d Kommentare geliefert, erwartet %d", len(seen), len(want))
+	}
+}
+
+func contractPagination(t *testing.T, store CommentStore) {
+	postA := map[int]bool{}
+	all := map[int]bool{}
+	// Je zwei Kommentare teilen sich eine Sekunde, der Cursor muss das auflösen
+	for i := 0; i < 7; i++ {
+		c := mustCreate(t, store, newContractComment("post-a", StatusApproved, time.Duration(i/2)*time.Second))
+		postA[c.ID] = true
+		all[c.ID] = true
+	}
+	for i := 0; i < 3; i++ {
+		c := mustCreate(t, store, newContractComment("post-b", StatusApproved, time.Duration(i)*time.Hour))
+		all[c.ID] = true
+	}
+
+	byPost := func(q CommentQuery) (*CommentPage, error) { return store.ListByPost("post-a", false, q) }
+	listAll := func(q CommentQuery) (*CommentPage, error) { return store.ListAll(false, q) }
+
+	for _, sort := range []string{SortOldest, SortNewest} {
+		for _, limit := range []int{1, 2, 3, 50} {
+			comments, total := collectPages(t, limit, byPost, CommentQuery{Sort: sort})
+			checkOrder(t, comments, postA, sort)
+			if total != len(postA) {
+				t.Errorf("ListByPost %s/%d: total %d, erwartet %d", sort, limit, total, len(postA))
+			}
+
+			comments, total = collectPages(t, limit, listAll, CommentQuery{Sort: sort})
+			checkOrder(t, comments, all, sort)
+			if total != len(all) {
+				t.Errorf("ListAll %s/%d: total %d, erwartet %d", sort, limit, total, len(all))
+			}
+		}
+	}
+
+	// Limit 0 liefert alles ohne Cursor
+	page, err := store.ListByPost("post-a", false, CommentQuery{Sort: SortOldest})
+	if err != nil {
+		t.Fatalf("ListByPost ohne Limit: %v", err)
+	}
+	if len(page.Items) != len(postA) || page.NextCursor != "" {
+		t.Errorf("ohne Limit: %d Einträge, Cursor %q", len(page.Items), page.NextCursor)
+	}
+
+	if page, err := store.ListByPost("unbekannt", false, CommentQuery{Limit: 10}); err != nil || len(page.Items) != 0 || page.Total != 0 {
+		t.Errorf("unbekannter Post: %+v, %v", page, err)
+	}
+}
+
+func contractFilters(t *testing.T, store CommentStore) {
+	ids := map[CommentStatus]int{}
+	for i, status := range allStatuses {
+		ids[status] = mustCreate(t, store, newContractComment("post-a", status, time.Duration(i)*time.Minute)).ID
+	}
+
+	public, err := store.ListByPost("post-a", false, CommentQuery{})
+	if err != nil {
+		t.Fatalf("ListByPost: %v", err)
+	}
+	if len(public.Items) != 1 || public.Items[0].ID != ids[StatusApproved] {
+		t.Errorf("öffentliche Liste enthält %v, erwartet nur #%d", commentIDs(public.Items), ids[StatusApproved])
+	}
+
+	everything, err := store.ListAll(true, CommentQuery{})
+	if err != nil {
+		t.Fatalf("ListAll: %v", err)
+	}
+	if len(everything.Items) != len(allStatuses) {
+		t.Errorf("includeInactive liefert %d, erwartet %d", len(everything.Items), len(allStatuses))
+	}
+
+	for _, status := range allStatuses {
+		page, err := store.ListAll(false, CommentQuery{Status: status})
+		if err != nil {
+			t.Fatalf("ListAll status=%s: %v", status, err)
+		}
+		if len(page.Items) != 1 || page.Items[0].ID != ids[status] {
+			t.Errorf("status=%s liefert %v, erwartet #%d", status, commentIDs(page.Items), ids[status])
+		}
+	}
+
+	// before/after sind exklusiv
+	page, err := store.ListAll(true, CommentQuery{
+		After:  contractBase,
+		Before: contractBase.Add(3 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("ListAll before/after: %v", err)
+	}
+	if want := []int{ids[StatusApproved], ids[StatusRejected]}; !reflect.DeepEqual(commentIDs(page.Items), want) {
+		t.Errorf("before/after liefert %v, erwartet %v", commentIDs(page.Items), want)
+	}
+}
+
+// commentIDs liefert die IDs einer Liste
+func commentIDs(comments []*Comment) []int {
+	ids := make([]int, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func contractStatusTransitions(t *testing.T, store CommentStore) {
+	a := mustCreate(t, store, newContractComment("post-a", StatusPending, 0))
+	b := mustCreate(t, store, newContractComment("post-a", StatusPending, time.Second))
+
+	if err := store.UpdateStatus(a.ID, StatusApproved); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	if got, _ := store.Get(a.ID); got.Status != StatusApproved || !got.Active {
+		t.Errorf("nach UpdateStatus: status %s, active %v", got.Status, got.Active)
+	}
+	if err := store.UpdateStatus(a.ID+100, StatusApproved); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("UpdateStatus unbekannte ID: %v", err)
+	}
+
+	// Papierkorb: Zeitpunkt und vorheriger Status werden gemerkt
+	now := contractBase.Add(time.Hour)
+	approved, _ := store.Get(a.ID)
+	pending, _ := store.Get(b.ID)
+	ops := []ModerationOp{newModerationOp(approved, StatusDeleted, now), newModerationOp(pending, StatusSpam, now)}
+	if err := store.Moderate(ops); err != nil {
+		t.Fatalf("Moderate: %v", err)
+	}
+	deleted, _ := store.Get(a.ID)
+	if deleted.Status != StatusDeleted || deleted.Active || deleted.DeletedFrom != StatusApproved || deleted.DeletedAt != now.Format(time.RFC3339) {
+		t.Errorf("nach Löschen: %+v", deleted)
+	}
+	if spam, _ := store.Get(b.ID); spam.Status != StatusSpam {
+		t.Errorf("Bulk-Wechsel nicht gespeichert: %s", spam.Status)
+	}
+
+	// Wiederherstellen leert die Papierkorb-Felder
+	if err := store.Moderate([]ModerationOp{newModerationOp(deleted, deleted.restoreStatus(), now)}); err != nil {
+		t.Fatalf("Moderate (wiederherstellen): %v", err)
+	}
+	restored, _ := store.Get(a.ID)
+	if restored.Status != StatusApproved || !restored.Active || restored.DeletedAt != "" || restored.DeletedFrom != "" {
+		t.Errorf("nach Wiederherstellen: %+v", restored)
+	}
+
+	// Alle oder keiner: eine unbekannte ID verhindert auch die übrigen Wechsel
+	missing := newContractComment("post-a", StatusPending, 0)
+	missing.ID = b.ID + 100
+	ops = []ModerationOp{newModerationOp(restored, StatusRejected, now), newModerationOp(missing, StatusApproved, now)}
+	if err := store.Moderate(ops); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Moderate mit unbekannter ID: %v, erwartet ErrCommentNotFound", err)
+	}
+	if got, _ := store.Get(a.ID); got.Status != StatusApproved {
+		t.Errorf("Moderate nicht atomar: status %s", got.Status)
+	}
+
+	// Veralteter Stand: ein anderer Moderator war schneller, nichts wird überschrieben
+	stale, _ := store.Get(b.ID)
+	if err := store.UpdateStatus(b.ID, StatusApproved); err != nil {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+	ops = []ModerationOp{newModerationOp(restored, StatusRejected, now), newModerationOp(stale, StatusRejected, now)}
+	if err := store.Moderate(ops); !errors.Is(err, ErrModerationConflict) {
+		t.Errorf("Moderate mit veraltetem Stand: %v, erwartet ErrModerationConflict", err)
+	}
+	if got, _ := store.Get(b.ID); got.Status != StatusApproved {
+		t.Errorf("veralteter Stand hat gewonnen: status %s", got.Status)
+	}
+	if got, _ := store.Get(a.ID); got.Status != StatusApproved {
+		t.Errorf("Moderate trotz Konflikt teilweise gespeichert: status %s", got.Status)
+	}
+}
+
+func contractCounts(t *testing.T, store CommentStore) {
+	counts, err := store.Counts()
+	if err != nil {
+		t.Fatalf("Counts (leer): %v", err)
+	}
+	if counts.Total != 0 || len(counts.ByStatus) != len(allStatuses) {
+		t.Errorf("leerer Store: %+v", counts)
+	}
+
+	want := map[CommentStatus]int{StatusPending: 2, StatusApproved: 3, StatusRejected: 1, StatusSpam: 1, StatusDeleted: 1}
+	i := 0
+	for status, n := range want {
+		for j := 0; j < n; j++ {
+			mustCreate(t, store, newContractComment("post-a", status, time.Duration(i)*time.Second))
+			i++
+		}
+	}
+
+	counts, err = store.Counts()
+	if err != nil {
+		t.Fatalf("Counts: %v", err)
+	}
+	if counts.Total != 8 || counts.Active != 3 || counts.Inactive != 5 {
+		t.Errorf("Counts: total %d, active %d, inactive %d", counts.Total, counts.Active, counts.Inactive)
+	}
+	if !reflect.DeepEqual(counts.ByStatus, want) {
+		t.Errorf("ByStatus %v, erwartet %v", counts.ByStatus, want)
+	}
+}
+
+func contractDelete(t *testing.T, store CommentStore) {
+	// Nur aus dem Papierkorb
+	live := mustCreate(t, store, newContractComment("post-a", StatusApproved, 0))
+	if err := store.Delete(live.ID); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("Delete ohne Papierkorb: %v, erwartet ErrNotInTrash", err)
+	}
+	if _, err := store.Get(live.ID); err != nil {
+		t.Errorf("Kommentar außerhalb des Papierkorbs gelöscht: %v", err)
+	}
+
+	c := mustCreate(t, store, newContractComment("post-a", StatusDeleted, time.Minute))
+	if err := store.Delete(c.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(c.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Get nach Delete: %v", err)
+	}
+	if page, _ := store.ListByPost("post-a", true, CommentQuery{}); len(page.Items) != 1 || page.Items[0].ID != live.ID {
+		t.Errorf("gelöschter Kommentar noch gelistet: %v", commentIDs(page.Items))
+	}
+	if err := store.Delete(c.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Delete unbekannte ID: %v", err)
+	}
+}
+
+// Post-IDs, die wie Index-Suffixe aussehen, dürfen keine fremden Kommentare liefern
+func contractPostIDCollisions(t *testing.T, store CommentStore) {
+	approved := mustCreate(t, store, newContractComment("foo", StatusApproved, 0))
+	hidden := mustCreate(t, store, newContractComment("foo:active", StatusPending, time.Minute))
+	pending := mustCreate(t, store, newContractComment("x", StatusPending, 2*time.Minute))
+	lookalike := mustCreate(t, store, newContractComment("x:status:pending", StatusApproved, 3*time.Minute))
+	mustCreate(t, store, newContractComment("x:status:spam", StatusSpam, 4*time.Minute))
+
+	cases := []struct {
+		postID          string
+		includeInactive bool
+		status          CommentStatus
+		want            []int
+	}{
+		{"foo", false, "", []int{approved.ID}},
+		{"foo:active", false, "", []int{}},
+		{"foo:active", true, "", []int{hidden.ID}},
+		{"x", true, StatusPending, []int{pending.ID}},
+		{"x", false, StatusSpam, []int{}},
+		{"x:status:pending", false, "", []int{lookalike.ID}},
+		{"x:status:pending", true, StatusPending, []int{}},
+	}
+	for _, tc := range cases {
+		page, err := store.ListByPost(tc.postID, tc.includeInactive, CommentQuery{Status: tc.status})
+		if err != nil {
+			t.Fatalf("ListByPost %q: %v", tc.postID, err)
+		}
+		if got := commentIDs(page.Items); !reflect.DeepEqual(got, tc.want) || page.Total != len(tc.want) {
+			t.Errorf("ListByPost %q (inaktive %v, status %q): %v (total %d), erwartet %v",
+				tc.postID, tc.includeInactive, tc.status, got, page.Total, tc.want)
+		}
+	}
+}
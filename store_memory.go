@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemoryStore hält Kommentare im Prozessspeicher (Entwicklung, Tests, Einzelinstanz)
+type MemoryStore struct {
+	mutex        sync.RWMutex
+	comments     map[int]*Comment
+	nextID       int
+	bans         map[int]*BanRule
+	nextBanID    int
+	reports      map[int]*Report
+	nextReportID int
+	audit        []*AuditEntry
+	trust        map[string]*AuthorTrust
+
+	subscriptions      map[int]*Subscription
+	nextSubscriptionID int
+	webhooks           map[int]*Webhook
+	nextWebhookID      int
+}
+
+// NewMemoryStore erstellt einen leeren MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		comments: make(map[int]*Comment),
+		bans:     make(map[int]*BanRule),
+		reports:  make(map[int]*Report),
+		trust:    make(map[string]*AuthorTrust),
+
+		subscriptions: make(map[int]*Subscription),
+		webhooks:      make(map[int]*Webhook),
+	}
+}
+
+// Ping ist für den MemoryStore immer erfolgreich
+func (s *MemoryStore) Ping() error {
+	return nil
+}
+
+// Close hat für den MemoryStore nichts zu tun
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// copyComment liefert eine Kopie, damit Aufrufer den Store nicht verändern
+func copyComment(c *Comment) *Comment {
+	clone := *c
+	clone.Children = nil
+	clone.SpamReasons = append([]string(nil), c.SpamReasons...)
+	return &clone
+}
+
+// Create vergibt eine neue ID und speichert den Kommentar
+func (s *MemoryStore) Create(comment *Comment) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextID++
+	comment.ID = s.nextID
+	s.comments[comment.ID] = copyComment(comment)
+	return nil
+}
+
+// Get holt einen Kommentar anhand der ID
+func (s *MemoryStore) Get(id int) (*Comment, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return nil, fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+	return copyComment(comment), nil
+}
+
+// list sammelt Kopien aller passenden Kommentare (postID "" = alle Posts)
+func (s *MemoryStore) list(postID string, includeInactive bool, q CommentQuery) []*Comment {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	comments := make([]*Comment, 0, len(s.comments))
+	for _, c := range s.comments {
+		if postID != "" && c.PostID != postID {
+			continue
+		}
+		if q.includes(c, includeInactive) {
+			comments = append(comments, copyComment(c))
+		}
+	}
+	return comments
+}
+
+// ListByPost holt eine Seite von Kommentaren eines Blog-Posts
+func (s *MemoryStore) ListByPost(postID string, includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	return paginateComments(s.list(postID, includeInactive, q), q), nil
+}
+
+// ListAll holt eine Seite aller Kommentare
+func (s *MemoryStore) ListAll(includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	return paginateComments(s.list("", includeInactive, q), q), nil
+}
+
+// UpdateStatus setzt den Moderationsstatus eines Kommentars
+func (s *MemoryStore) UpdateStatus(id int, status CommentStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+	comment.setStatus(status)
+	return nil
+}
+
+// Delete löscht einen Kommentar aus dem Papierkorb
+func (s *MemoryStore) Delete(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	comment, ok := s.comments[id]
+	if !ok {
+		return fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+	if comment.Status != StatusDeleted {
+		return fmt.Errorf("kommentar %d: %w", id, ErrNotInTrash)
+	}
+	delete(s.comments, id)
+	return nil
+}
+
+// Moderate prüft erst alle IDs und übernimmt dann Status und Papierkorb-Felder unter einem Lock
+func (s *MemoryStore) Moderate(ops []ModerationOp) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, op := range ops {
+		stored, ok := s.comments[op.Comment.ID]
+		if !ok {
+			return fmt.Errorf("kommentar %d: %w", op.Comment.ID, ErrCommentNotFound)
+		}
+		if stored.Status != op.Previous {
+			return fmt.Errorf("kommentar %d: %w", op.Comment.ID, ErrModerationConflict)
+		}
+	}
+	for _, op := range ops {
+		stored := s.comments[op.Comment.ID]
+		stored.setStatus(op.Comment.Status)
+		stored.DeletedAt = op.Comment.DeletedAt
+		stored.DeletedFrom = op.Comment.DeletedFrom
+	}
+	return nil
+}
+
+// Counts zählt alle und aktive Kommentare
+func (s *MemoryStore) Counts() (CommentCounts, error) {
+	return countComments(s.list("", true, CommentQuery{})), nil
+}
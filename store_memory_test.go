@@ -0,0 +1,9 @@
+package main
+
+import "testing"
+
+func TestMemoryStoreContract(t *testing.T) {
+	testCommentStore(t, func(t *testing.T) CommentStore {
+		return NewMemoryStore()
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// NewPostgresStore verbindet sich mit PostgreSQL und spielt die Migrationen ein
+func NewPostgresStore(dsn string) (*SQLStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("postgres konnte nicht geöffnet werden: %w", err)
+	}
+
+	db.SetMaxOpenConns(getEnvAsInt("POSTGRES_MAX_CONNS", 10))
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("postgres nicht erreichbar: %w", err)
+	}
+
+	migrations, err := fs.Sub(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &SQLStore{
+		db: db,
+		dialect: sqlDialect{
+			name:           "postgres",
+			numberedParams: true,
+			// Transaktions-Lock, wird beim Commit/Rollback automatisch freigegeben
+			migrationLock: "SELECT pg_advisory_xact_lock(7301)",
+			migrations:    migrations,
+		},
+	}
+
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
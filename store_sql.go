@@ -0,0 +1,405 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlDialect kapselt die Unterschiede zwischen den SQL-Datenbanken
+type sqlDialect struct {
+	name string
+	// numberedParams: $1, $2, ... statt ? als Platzhalter
+	numberedParams bool
+	// migrationLock wird zu Beginn jeder Migrations-Transaktion ausgeführt,
+	// damit parallel startende Instanzen nicht gleichzeitig migrieren
+	migrationLock string
+	migrations    fs.FS
+}
+
+// SQLStore speichert Kommentare in einer SQL-Datenbank (database/sql)
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqlDialect
+}
+
+// commentColumns ist die Spaltenliste für scanComment
+const commentColumns = "id, post_id, parent_id, username, mailaddress, text, status, spam_score, spam_reasons, ip, user_agent, shadowed, created_at, deleted_at, deleted_from, decision_reason, email_verified"
+
+// rebind ersetzt ?-Platzhalter für Dialekte mit nummerierten Parametern
+func (s *SQLStore) rebind(query string) string {
+	if !s.dialect.numberedParams {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// migrate spielt alle noch nicht angewendeten Migrationen (NNNN_name.sql) der Reihe nach ein
+func (s *SQLStore) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("fehler beim Anlegen von schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(s.dialect.migrations, "*.sql")
+	if err != nil {
+		return fmt.Errorf("fehler beim Lesen der Migrationen: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.SplitN(path.Base(file), "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("ungültiger Migrationsname %s", file)
+		}
+
+		applied, err := s.applyMigration(file, version)
+		if err != nil {
+			return fmt.Errorf("migration %s fehlgeschlagen: %w", file, err)
+		}
+		if applied {
+			log.Printf("✅ %s-Migration angewendet: %s", s.dialect.name, file)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration führt eine Migration in einer Transaktion aus, sofern sie noch nicht angewendet wurde
+func (s *SQLStore) applyMigration(file string, version int) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if s.dialect.migrationLock != "" {
+		if _, err := tx.Exec(s.dialect.migrationLock); err != nil {
+			return false, err
+		}
+	}
+
+	var count int
+	err = tx.QueryRow(s.rebind("SELECT COUNT(*) FROM schema_migrations WHERE version = ?"), version).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	script, err := fs.ReadFile(s.dialect.migrations, file)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(string(script)); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(s.rebind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"),
+		version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Ping prüft die Datenbankverbindung
+func (s *SQLStore) Ping() error {
+	return s.db.Ping()
+}
+
+// Close schließt die Datenbankverbindung
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner deckt *sql.Row und *sql.Rows ab
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanComment liest eine Zeile im Format von commentColumns (plus optionale Zusatzspalten)
+func scanComment(row rowScanner, extra ...interface{}) (*Comment, error) {
+	var c Comment
+	var status, spamReasons, deletedFrom string
+	dest := append([]interface{}{&c.ID, &c.PostID, &c.ParentID, &c.Username, &c.MailAddress, &c.Text, &status, &c.SpamScore, &spamReasons, &c.IP, &c.UserAgent, &c.Shadowed, &c.CreatedAt, &c.DeletedAt, &deletedFrom, &c.DecisionReason, &c.EmailVerified}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	c.SpamReasons = decodeSpamReasons(spamReasons)
+	c.DeletedFrom = CommentStatus(deletedFrom)
+	c.setStatus(CommentStatus(status))
+	return &c, nil
+}
+
+// Create vergibt eine neue ID und speichert den Kommentar
+func (s *SQLStore) Create(comment *Comment) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Kommentars: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(s.rebind(`INSERT INTO comments
+		(post_id, parent_id, username, mailaddress, text, active, status, spam_score, spam_reasons, ip, user_agent, shadowed, created_at, created_unix, deleted_at, deleted_from, decision_reason, email_verified)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		comment.PostID, comment.ParentID, comment.Username, comment.MailAddress, comment.Text,
+		comment.Active, string(comment.Status), comment.SpamScore, encodeSpamReasons(comment.SpamReasons),
+		comment.IP, comment.UserAgent, comment.Shadowed, comment.CreatedAt, commentTime(comment).Unix(),
+		comment.DeletedAt, string(comment.DeletedFrom), comment.DecisionReason, comment.EmailVerified,
+	).Scan(&comment.ID)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Kommentars: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Get holt einen Kommentar anhand der ID
+func (s *SQLStore) Get(id int) (*Comment, error) {
+	row := s.db.QueryRow(s.rebind("SELECT "+commentColumns+" FROM comments WHERE id = ?"), id)
+	comment, err := scanComment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden des Kommentars %d: %w", id, err)
+	}
+	return comment, nil
+}
+
+// list lädt eine Seite von Kommentaren (postID "" = alle Posts)
+func (s *SQLStore) list(postID string, includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	var where []string
+	var args []interface{}
+
+	if postID != "" {
+		where = append(where, "post_id = ?")
+		args = append(args, postID)
+	}
+	if q.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, string(q.Status))
+	} else if !includeInactive {
+		where = append(where, "active = ?")
+		args = append(args, true)
+	}
+	if !q.After.IsZero() {
+		where = append(where, "created_unix > ?")
+		args = append(args, q.After.Unix())
+	}
+	if !q.Before.IsZero() {
+		where = append(where, "created_unix < ?")
+		args = append(args, q.Before.Unix())
+	}
+
+	page := &CommentPage{Items: []*Comment{}}
+
+	// Gesamtzahl ohne Cursor
+	countQuery := "SELECT COUNT(*) FROM comments"
+	if len(where) > 0 {
+		countQuery += " WHERE " + strings.Join(where, " AND ")
+	}
+	if err := s.db.QueryRow(s.rebind(countQuery), args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("fehler beim Zählen der Kommentare: %w", err)
+	}
+
+	order := "ASC"
+	compare := ">"
+	if q.Sort == SortNewest {
+		order = "DESC"
+		compare = "<"
+	}
+
+	if q.Cursor != nil {
+		cursorID, err := strconv.Atoi(q.Cursor.ID)
+		if err != nil {
+			return nil, fmt.Errorf("ungültiger cursor")
+		}
+		where = append(where, fmt.Sprintf("(created_unix %s ? OR (created_unix = ? AND id %s ?))", compare, compare))
+		args = append(args, q.Cursor.Score, q.Cursor.Score, cursorID)
+	}
+
+	query := "SELECT " + commentColumns + ", created_unix FROM comments"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY created_unix %s, id %s", order, order)
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Kommentare: %w", err)
+	}
+	defer rows.Close()
+
+	var lastScore int64
+	for rows.Next() {
+		var score int64
+		c, err := scanComment(rows, &score)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Laden der Kommentare: %w", err)
+		}
+
+		if q.Limit > 0 && len(page.Items) == q.Limit {
+			last := page.Items[len(page.Items)-1]
+			page.NextCursor = (&pageCursor{Score: lastScore, ID: strconv.Itoa(last.ID)}).encode()
+			break
+		}
+		page.Items = append(page.Items, c)
+		lastScore = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Kommentare: %w", err)
+	}
+
+	return page, nil
+}
+
+// ListByPost holt eine Seite von Kommentaren eines Blog-Posts
+func (s *SQLStore) ListByPost(postID string, includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	return s.list(postID, includeInactive, q)
+}
+
+// ListAll holt eine Seite aller Kommentare
+func (s *SQLStore) ListAll(includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	return s.list("", includeInactive, q)
+}
+
+// execOne führt ein UPDATE/DELETE in einer Transaktion aus und prüft, ob genau ein Kommentar betroffen war
+func (s *SQLStore) execOne(id int, query string, args ...interface{}) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(s.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus setzt den Moderationsstatus eines Kommentars
+func (s *SQLStore) UpdateStatus(id int, status CommentStatus) error {
+	err := s.execOne(id, "UPDATE comments SET status = ?, active = ? WHERE id = ?", string(status), status == StatusApproved, id)
+	if err != nil && !errors.Is(err, ErrCommentNotFound) {
+		return fmt.Errorf("fehler beim Aktualisieren des Status: %w", err)
+	}
+	return err
+}
+
+// Delete löscht einen Kommentar aus dem Papierkorb. Die Bedingung auf den Status
+// sitzt im DELETE selbst, ein parallel wiederhergestellter Kommentar bleibt also.
+func (s *SQLStore) Delete(id int) error {
+	err := s.execOne(id, "DELETE FROM comments WHERE id = ? AND status = ?", id, string(StatusDeleted))
+	if errors.Is(err, ErrCommentNotFound) {
+		var exists int
+		switch lookupErr := s.db.QueryRow(s.rebind("SELECT 1 FROM comments WHERE id = ?"), id).Scan(&exists); {
+		case lookupErr == nil:
+			return fmt.Errorf("kommentar %d: %w", id, ErrNotInTrash)
+		case !errors.Is(lookupErr, sql.ErrNoRows):
+			return fmt.Errorf("fehler beim Löschen des Kommentars: %w", lookupErr)
+		}
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen des Kommentars: %w", err)
+	}
+	return nil
+}
+
+// Moderate führt alle Statuswechsel in einer Transaktion aus
+func (s *SQLStore) Moderate(ops []ModerationOp) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("fehler bei der Moderation: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, op := range ops {
+		// Nur schreiben, wenn der Status noch der beim Laden ist
+		c := op.Comment
+		result, err := tx.Exec(s.rebind("UPDATE comments SET status = ?, active = ?, deleted_at = ?, deleted_from = ? WHERE id = ? AND status = ?"),
+			string(c.Status), c.Active, c.DeletedAt, string(c.DeletedFrom), c.ID, string(op.Previous))
+		if err != nil {
+			return fmt.Errorf("fehler bei der Moderation: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			var count int
+			if err := tx.QueryRow(s.rebind("SELECT COUNT(*) FROM comments WHERE id = ?"), c.ID).Scan(&count); err != nil {
+				return fmt.Errorf("fehler bei der Moderation: %w", err)
+			}
+			if count == 0 {
+				return fmt.Errorf("kommentar %d: %w", c.ID, ErrCommentNotFound)
+			}
+			return fmt.Errorf("kommentar %d: %w", c.ID, ErrModerationConflict)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("fehler bei der Moderation: %w", err)
+	}
+	return nil
+}
+
+// Counts zählt alle, aktive und je Status
+func (s *SQLStore) Counts() (CommentCounts, error) {
+	counts := newCommentCounts()
+
+	rows, err := s.db.Query("SELECT status, COUNT(*) FROM comments GROUP BY status")
+	if err != nil {
+		return counts, fmt.Errorf("fehler beim Zählen der Kommentare: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return counts, fmt.Errorf("fehler beim Zählen der Kommentare: %w", err)
+		}
+		counts.ByStatus[CommentStatus(status)] = n
+		counts.Total += n
+	}
+	if err := rows.Err(); err != nil {
+		return counts, fmt.Errorf("fehler beim Zählen der Kommentare: %w", err)
+	}
+
+	counts.Active = counts.ByStatus[StatusApproved]
+	counts.Inactive = counts.Total - counts.Active
+	return counts, nil
+}
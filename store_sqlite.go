@@ -0,0 +1,54 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// NewSQLiteStore öffnet (bzw. erstellt) die SQLite-Datei und spielt die Migrationen ein.
+// Der Treiber benötigt CGO (CGO_ENABLED=1).
+func NewSQLiteStore(dbPath string) (*SQLStore, error) {
+	if dir := filepath.Dir(dbPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("sqlite-verzeichnis konnte nicht erstellt werden: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", dbPath)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite konnte nicht geöffnet werden: %w", err)
+	}
+
+	// SQLite erlaubt nur einen Schreiber, eine Verbindung vermeidet "database is locked"
+	db.SetMaxOpenConns(1)
+
+	migrations, err := fs.Sub(sqliteMigrations, "migrations/sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	store := &SQLStore{
+		db: db,
+		dialect: sqlDialect{
+			name:       "sqlite",
+			migrations: migrations,
+		},
+	}
+
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
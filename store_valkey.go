@@ -0,0 +1,954 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ValkeyStore speichert Kommentare als Hashes in Valkey/Redis
+type ValkeyStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewValkeyStore erstellt einen neuen ValkeyStore
+func NewValkeyStore(redisAddr, redisPassword string, redisDB int) *ValkeyStore {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: redisPassword,
+		DB:       redisDB,
+	})
+
+	return &ValkeyStore{
+		client: rdb,
+		ctx:    context.Background(),
+	}
+}
+
+// Ping prüft die Verbindung zu Valkey
+func (s *ValkeyStore) Ping() error {
+	return s.client.Ping(s.ctx).Err()
+}
+
+// Close schließt die Verbindung
+func (s *ValkeyStore) Close() error {
+	return s.client.Close()
+}
+
+// generateCommentID generiert eine neue Kommentar-ID
+func (s *ValkeyStore) generateCommentID() (int, error) {
+	id, err := s.client.Incr(s.ctx, "comment_counter").Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// Create speichert einen neuen Kommentar atomar als Hash und indiziert ihn
+func (s *ValkeyStore) Create(comment *Comment) error {
+	id, err := s.generateCommentID()
+	if err != nil {
+		return fmt.Errorf("fehler beim Generieren der ID: %w", err)
+	}
+	comment.ID = id
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.ctx, commentKey(id), commentToHash(comment))
+	s.indexComment(pipe, comment)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern des Kommentars: %w", err)
+	}
+
+	return nil
+}
+
+// commentKey liefert den Hash-Key eines Kommentars
+func commentKey(id int) string {
+	return fmt.Sprintf("comment:%d", id)
+}
+
+// commentToHash wandelt einen Kommentar in Hash-Felder um
+func commentToHash(c *Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"post_id":         c.PostID,
+		"parent_id":       c.ParentID,
+		"username":        c.Username,
+		"mailaddress":     c.MailAddress,
+		"text":            c.Text,
+		"active":          strconv.FormatBool(c.Active),
+		"status":          string(c.Status),
+		"spam_score":      strconv.FormatFloat(c.SpamScore, 'f', -1, 64),
+		"spam_reasons":    encodeSpamReasons(c.SpamReasons),
+		"decision_reason": c.DecisionReason,
+		"email_verified":  strconv.FormatBool(c.EmailVerified),
+		"ip":              c.IP,
+		"user_agent":      c.UserAgent,
+		"shadowed":        strconv.FormatBool(c.Shadowed),
+		"created_at":      c.CreatedAt,
+		"deleted_at":      c.DeletedAt,
+		"deleted_from":    string(c.DeletedFrom),
+	}
+}
+
+// commentFromHash baut einen Kommentar aus den Hash-Feldern
+func commentFromHash(id int, fields map[string]string) (*Comment, error) {
+	if fields["post_id"] == "" {
+		return nil, fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+
+	parentID, _ := strconv.Atoi(fields["parent_id"])
+
+	// Kommentare von vor Einführung des Status leiten ihn aus active ab
+	status, err := parseCommentStatus(fields["status"])
+	if err != nil {
+		status = statusFromActive(fields["active"] == "true")
+	}
+
+	comment := &Comment{
+		ID:             id,
+		PostID:         fields["post_id"],
+		ParentID:       parentID,
+		Username:       fields["username"],
+		MailAddress:    fields["mailaddress"],
+		Text:           fields["text"],
+		SpamReasons:    decodeSpamReasons(fields["spam_reasons"]),
+		DecisionReason: fields["decision_reason"],
+		EmailVerified:  fields["email_verified"] == "true",
+		IP:             fields["ip"],
+		UserAgent:      fields["user_agent"],
+		Shadowed:       fields["shadowed"] == "true",
+		CreatedAt:      fields["created_at"],
+		DeletedAt:      fields["deleted_at"],
+		DeletedFrom:    CommentStatus(fields["deleted_from"]),
+	}
+	comment.SpamScore, _ = strconv.ParseFloat(fields["spam_score"], 64)
+	comment.setStatus(status)
+	return comment, nil
+}
+
+// Get holt einen Kommentar anhand der ID
+func (s *ValkeyStore) Get(id int) (*Comment, error) {
+	fields, err := s.client.HGetAll(s.ctx, commentKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("kommentar nicht gefunden: %w", err)
+	}
+
+	if len(fields) == 0 {
+		// Bis zur Migration auch das alte Key-pro-Feld-Layout lesen
+		if s.legacyLayoutActive() {
+			return s.getLegacyComment(id)
+		}
+		return nil, fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+
+	return commentFromHash(id, fields)
+}
+
+// UpdateStatus setzt den Moderationsstatus eines Kommentars
+func (s *ValkeyStore) UpdateStatus(id int, status CommentStatus) error {
+	comment, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	comment.setStatus(status)
+
+	exists, err := s.client.Exists(s.ctx, commentKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Aktualisieren des Status: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	if exists == 0 {
+		// Noch nicht migrierter Kommentar im alten Format: das alte Layout kennt
+		// keinen Status, daher direkt als Hash speichern
+		pipe.HSet(s.ctx, commentKey(id), commentToHash(comment))
+		for _, field := range legacyCommentFields {
+			pipe.Del(s.ctx, legacyCommentKey(id, field))
+		}
+	} else {
+		pipe.HSet(s.ctx, commentKey(id), "status", string(status), "active", strconv.FormatBool(comment.Active))
+	}
+	s.indexComment(pipe, comment)
+
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Aktualisieren des Status: %w", err)
+	}
+
+	return nil
+}
+
+// listCommentIDs sammelt die IDs aller gespeicherten Kommentare per SCAN.
+// Wird nur noch für den Index-Neuaufbau und als Fallback ohne Index genutzt.
+func (s *ValkeyStore) listCommentIDs() ([]int, error) {
+	keys, err := s.scanKeys("comment:*")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	var ids []int
+	for _, key := range keys {
+		id, err := strconv.Atoi(strings.TrimPrefix(key, "comment:"))
+		if err != nil {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if s.legacyLayoutActive() {
+		legacyIDs, err := s.listLegacyCommentIDs()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range legacyIDs {
+			if !seen[id] {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// Sorted-Set-Indizes (Score = created_at als Unix-Zeit)
+const (
+	indexAllKey     = "comments:index:all"
+	indexActiveKey  = "comments:index:active"
+	indexVersionKey = "comment_index_version"
+	indexVersion    = 3 // 2: Status-Indizes, 3: Post-ID kodiert
+
+	// rebuildKeyPrefix: RebuildIndex baut den neuen Index unter diesem Präfix auf
+	rebuildKeyPrefix = "comments:rebuild:"
+)
+
+// postKeyPart kodiert eine Post-ID für Key-Namen. Ohne Kodierung landet z.B.
+// der Post "foo:active" im Index der aktiven Kommentare von "foo".
+func postKeyPart(postID string) string {
+	return url.QueryEscape(postID)
+}
+
+// postIndexKey liefert den Index-Key eines Posts (alle oder nur aktive Kommentare)
+func postIndexKey(postID string, activeOnly bool) string {
+	if activeOnly {
+		return "comments:index:post:" + postKeyPart(postID) + ":active"
+	}
+	return "comments:index:post:" + postKeyPart(postID)
+}
+
+// statusIndexKey liefert den Index-Key eines Status (postID "" = alle Posts)
+func statusIndexKey(postID string, status CommentStatus) string {
+	if postID == "" {
+		return "comments:index:status:" + string(status)
+	}
+	return "comments:index:post:" + postKeyPart(postID) + ":status:" + string(status)
+}
+
+// listIndexKey wählt den passenden Index für eine Abfrage (postID "" = alle Posts)
+func listIndexKey(postID string, includeInactive bool, q CommentQuery) string {
+	if q.Status != "" {
+		return statusIndexKey(postID, q.Status)
+	}
+	if postID != "" {
+		return postIndexKey(postID, !includeInactive)
+	}
+	if includeInactive {
+		return indexAllKey
+	}
+	return indexActiveKey
+}
+
+// indexComment trägt einen Kommentar in alle passenden Indizes ein
+func (s *ValkeyStore) indexComment(pipe redis.Pipeliner, c *Comment) {
+	s.indexCommentAs(pipe, c, "")
+}
+
+// indexCommentAs trägt einen Kommentar in die Indizes mit dem Präfix prefix ein
+// ("" = aktive Indizes, rebuildKeyPrefix = Neuaufbau)
+func (s *ValkeyStore) indexCommentAs(pipe redis.Pipeliner, c *Comment, prefix string) {
+	member := redis.Z{Score: float64(commentTime(c).Unix()), Member: c.ID}
+
+	pipe.ZAdd(s.ctx, prefix+indexAllKey, member)
+	pipe.ZAdd(s.ctx, prefix+postIndexKey(c.PostID, false), member)
+	if c.Active {
+		pipe.ZAdd(s.ctx, prefix+indexActiveKey, member)
+		pipe.ZAdd(s.ctx, prefix+postIndexKey(c.PostID, true), member)
+	} else {
+		pipe.ZRem(s.ctx, prefix+indexActiveKey, c.ID)
+		pipe.ZRem(s.ctx, prefix+postIndexKey(c.PostID, true), c.ID)
+	}
+
+	for _, status := range allStatuses {
+		if status == c.Status {
+			pipe.ZAdd(s.ctx, prefix+statusIndexKey("", status), member)
+			pipe.ZAdd(s.ctx, prefix+statusIndexKey(c.PostID, status), member)
+		} else {
+			pipe.ZRem(s.ctx, prefix+statusIndexKey("", status), c.ID)
+			pipe.ZRem(s.ctx, prefix+statusIndexKey(c.PostID, status), c.ID)
+		}
+	}
+}
+
+// unindexComment entfernt einen Kommentar aus allen Indizes
+func (s *ValkeyStore) unindexComment(pipe redis.Pipeliner, c *Comment) {
+	pipe.ZRem(s.ctx, indexAllKey, c.ID)
+	pipe.ZRem(s.ctx, indexActiveKey, c.ID)
+	pipe.ZRem(s.ctx, postIndexKey(c.PostID, false), c.ID)
+	pipe.ZRem(s.ctx, postIndexKey(c.PostID, true), c.ID)
+	for _, status := range allStatuses {
+		pipe.ZRem(s.ctx, statusIndexKey("", status), c.ID)
+		pipe.ZRem(s.ctx, statusIndexKey(c.PostID, status), c.ID)
+	}
+}
+
+// indexReady prüft, ob die Indizes aufgebaut wurden
+func (s *ValkeyStore) indexReady() bool {
+	v, err := s.client.Get(s.ctx, indexVersionKey).Int()
+	return err == nil && v >= indexVersion
+}
+
+// EnsureIndex markiert den Index bei einer leeren Datenbank als aufgebaut,
+// baut einen veralteten Index automatisch neu auf und warnt, wenn vorhandene
+// Daten noch gar nicht indiziert sind ('rebuild-index')
+func (s *ValkeyStore) EnsureIndex() error {
+	if s.indexReady() {
+		return nil
+	}
+
+	if v, err := s.client.Get(s.ctx, indexVersionKey).Int(); err == nil && v < indexVersion {
+		log.Printf("🔄 Kommentar-Index veraltet (Version %d), baue neu auf...", v)
+		indexed, err := s.RebuildIndex()
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Kommentar-Index neu aufgebaut (%d Kommentare)", indexed)
+		return nil
+	}
+
+	existing, err := s.client.Exists(s.ctx, "comment_counter").Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Prüfen des Index: %w", err)
+	}
+	if existing == 0 {
+		return s.client.Set(s.ctx, indexVersionKey, indexVersion, 0).Err()
+	}
+
+	log.Println("⚠️  Kommentar-Index fehlt. Bitte einmalig './server rebuild-index' ausführen.")
+	return nil
+}
+
+// getCommentsByIDs lädt mehrere Kommentare in einem Pipeline-Roundtrip
+func (s *ValkeyStore) getCommentsByIDs(ids []string) ([]*Comment, error) {
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, idStr := range ids {
+		cmds[i] = pipe.HGetAll(s.ctx, "comment:"+idStr)
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("fehler beim Laden der Kommentare: %w", err)
+	}
+
+	comments := make([]*Comment, 0, len(ids))
+	for i, idStr := range ids {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+
+		var comment *Comment
+		if fields := cmds[i].Val(); len(fields) > 0 {
+			comment, err = commentFromHash(id, fields)
+		} else {
+			// Noch nicht migrierte Kommentare einzeln laden
+			comment, err = s.Get(id)
+		}
+		if err != nil {
+			log.Printf("Fehler beim Laden des Kommentars %d: %v", id, err)
+			continue
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+// listIndexed lädt eine Seite von Kommentaren aus dem passenden Index (postID "" = alle Posts)
+func (s *ValkeyStore) listIndexed(postID string, includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	key := listIndexKey(postID, includeInactive, q)
+	rangeMin, rangeMax := q.scoreRange()
+
+	total, err := s.client.ZCount(s.ctx, key, rangeMin, rangeMax).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen des Index: %w", err)
+	}
+
+	// Ab dem Cursor weiterlesen (inklusive, gleiche Scores werden unten übersprungen)
+	if q.Cursor != nil {
+		bound := strconv.FormatInt(q.Cursor.Score, 10)
+		if q.Sort == SortNewest {
+			rangeMax = bound
+		} else {
+			rangeMin = bound
+		}
+	}
+
+	args := redis.ZRangeArgs{
+		Key:     key,
+		Start:   rangeMin,
+		Stop:    rangeMax,
+		ByScore: true,
+	}
+	if q.Sort == SortNewest {
+		// go-redis vertauscht Start/Stop bei Rev selbst
+		args.Rev = true
+	}
+
+	var members []redis.Z
+	for {
+		if q.Limit > 0 {
+			args.Count = int64(q.Limit + 1)
+		}
+		batch, err := s.client.ZRangeArgsWithScores(s.ctx, args).Result()
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen des Index: %w", err)
+		}
+
+		for _, z := range batch {
+			if !q.Cursor.passed(int64(z.Score), fmt.Sprint(z.Member), q.Sort) {
+				continue
+			}
+			members = append(members, z)
+		}
+
+		if q.Limit == 0 || len(batch) < int(args.Count) || len(members) > q.Limit {
+			break
+		}
+		args.Offset += int64(len(batch))
+	}
+
+	page := &CommentPage{Total: int(total)}
+	if q.Limit > 0 && len(members) > q.Limit {
+		last := members[q.Limit-1]
+		page.NextCursor = (&pageCursor{Score: int64(last.Score), ID: fmt.Sprint(last.Member)}).encode()
+		members = members[:q.Limit]
+	}
+
+	ids := make([]string, len(members))
+	for i, z := range members {
+		ids[i] = fmt.Sprint(z.Member)
+	}
+
+	comments, err := s.getCommentsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Der Index entscheidet nicht allein: ein falsch einsortierter Eintrag darf
+	// keinen fremden oder nicht freigegebenen Kommentar ausliefern
+	page.Items = make([]*Comment, 0, len(comments))
+	for _, comment := range comments {
+		if (postID != "" && comment.PostID != postID) || !q.includes(comment, includeInactive) {
+			log.Printf("⚠️  Kommentar %d passt nicht zum Index %s, übersprungen", comment.ID, key)
+			continue
+		}
+		page.Items = append(page.Items, comment)
+	}
+
+	return page, nil
+}
+
+// scanComments ist der langsame Fallback ohne Index (postID "" = alle Posts)
+func (s *ValkeyStore) scanComments(postID string, includeInactive bool, q CommentQuery) ([]*Comment, error) {
+	log.Println("⚠️  Kommentar-Index fehlt, nutze SCAN. Bitte 'rebuild-index' ausführen.")
+
+	ids, err := s.listCommentIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []*Comment
+	for _, id := range ids {
+		comment, err := s.Get(id)
+		if err != nil {
+			log.Printf("Fehler beim Laden des Kommentars %d: %v", id, err)
+			continue
+		}
+
+		if postID != "" && comment.PostID != postID {
+			continue
+		}
+
+		// Nur aktive Kommentare einschließen, außer explizit anders gewünscht
+		if q.includes(comment, includeInactive) {
+			comments = append(comments, comment)
+		}
+	}
+
+	return comments, nil
+}
+
+// ListByPost holt eine Seite von Kommentaren eines Blog-Posts
+func (s *ValkeyStore) ListByPost(postID string, includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	log.Printf("Suche Kommentare für PostID: '%s'", postID)
+
+	if !s.indexReady() {
+		comments, err := s.scanComments(postID, includeInactive, q)
+		if err != nil {
+			return nil, err
+		}
+		return paginateComments(comments, q), nil
+	}
+
+	page, err := s.listIndexed(postID, includeInactive, q)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Gefundene Kommentare für PostID '%s': %d von %d", postID, len(page.Items), page.Total)
+	return page, nil
+}
+
+// ListAll holt eine Seite aller Kommentare
+func (s *ValkeyStore) ListAll(includeInactive bool, q CommentQuery) (*CommentPage, error) {
+	if !s.indexReady() {
+		comments, err := s.scanComments("", includeInactive, q)
+		if err != nil {
+			return nil, err
+		}
+		return paginateComments(comments, q), nil
+	}
+
+	return s.listIndexed("", includeInactive, q)
+}
+
+// Counts zählt alle, aktive und je Status über die Indizes
+func (s *ValkeyStore) Counts() (CommentCounts, error) {
+	if !s.indexReady() {
+		comments, err := s.scanComments("", true, CommentQuery{})
+		if err != nil {
+			return CommentCounts{}, err
+		}
+		return countComments(comments), nil
+	}
+
+	pipe := s.client.Pipeline()
+	total := pipe.ZCard(s.ctx, indexAllKey)
+	active := pipe.ZCard(s.ctx, indexActiveKey)
+	byStatus := make(map[CommentStatus]*redis.IntCmd, len(allStatuses))
+	for _, status := range allStatuses {
+		byStatus[status] = pipe.ZCard(s.ctx, statusIndexKey("", status))
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return CommentCounts{}, fmt.Errorf("fehler beim Zählen der Kommentare: %w", err)
+	}
+
+	counts := newCommentCounts()
+	counts.Total = int(total.Val())
+	counts.Active = int(active.Val())
+	counts.Inactive = int(total.Val() - active.Val())
+	for status, cmd := range byStatus {
+		counts.ByStatus[status] = int(cmd.Val())
+	}
+	return counts, nil
+}
+
+// Delete löscht einen Kommentar aus dem Papierkorb. Der Kommentar wird per WATCH
+// beobachtet, ein zwischen Prüfung und Löschen wiederhergestellter bleibt erhalten.
+// (Kommentare im alten Format kennen keinen Papierkorb.)
+func (s *ValkeyStore) Delete(id int) error {
+	for attempt := 0; attempt < maxModerationAttempts; attempt++ {
+		err := s.client.Watch(s.ctx, func(tx *redis.Tx) error {
+			return s.deleteWatched(tx, id)
+		}, commentKey(id))
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("fehler beim Löschen des Kommentars: %w", ErrModerationConflict)
+}
+
+// deleteWatched prüft den beobachteten Kommentar und löscht ihn samt Index-Einträgen
+func (s *ValkeyStore) deleteWatched(tx *redis.Tx, id int) error {
+	fields, err := tx.HGetAll(s.ctx, commentKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen des Kommentars: %w", err)
+	}
+	if len(fields) == 0 {
+		if s.legacyLayoutActive() {
+			if _, err := s.getLegacyComment(id); err == nil {
+				return fmt.Errorf("kommentar %d: %w", id, ErrNotInTrash)
+			}
+		}
+		return fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+	}
+	comment, err := commentFromHash(id, fields)
+	if err != nil {
+		return err
+	}
+	if comment.Status != StatusDeleted {
+		return fmt.Errorf("kommentar %d: %w", id, ErrNotInTrash)
+	}
+
+	_, err = tx.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(s.ctx, commentKey(id))
+		s.unindexComment(pipe, comment)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.TxFailedErr) {
+		return fmt.Errorf("fehler beim Löschen des Kommentars: %w", err)
+	}
+	return err
+}
+
+// maxModerationAttempts begrenzt die Versuche, wenn ein beobachteter Kommentar
+// während der Transaktion geändert wurde
+const maxModerationAttempts = 3
+
+// Moderate schreibt alle Statuswechsel in einer MULTI/EXEC-Transaktion. Die Kommentare
+// werden per WATCH beobachtet und erst danach gelesen: ändert sie jemand vor dem EXEC,
+// wird neu gelesen, hat einer nicht mehr den Status op.Previous, gibt es ErrModerationConflict.
+func (s *ValkeyStore) Moderate(ops []ModerationOp) error {
+	legacy := s.legacyLayoutActive()
+
+	keys := make([]string, 0, len(ops))
+	for _, op := range ops {
+		keys = append(keys, commentKey(op.Comment.ID))
+		if legacy {
+			keys = append(keys, legacyCommentKey(op.Comment.ID, "active"))
+		}
+	}
+
+	for attempt := 0; attempt < maxModerationAttempts; attempt++ {
+		err := s.client.Watch(s.ctx, func(tx *redis.Tx) error {
+			return s.moderateWatched(tx, ops, legacy)
+		}, keys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("fehler bei der Moderation: %w", ErrModerationConflict)
+}
+
+// moderateWatched prüft den aktuellen Stand der beobachteten Kommentare und schreibt die Wechsel
+func (s *ValkeyStore) moderateWatched(tx *redis.Tx, ops []ModerationOp, legacy bool) error {
+	current := make([]*Comment, len(ops))
+	for i, op := range ops {
+		id := op.Comment.ID
+		fields, err := tx.HGetAll(s.ctx, commentKey(id)).Result()
+		if err != nil {
+			return fmt.Errorf("fehler bei der Moderation: %w", err)
+		}
+
+		var comment *Comment
+		switch {
+		case len(fields) > 0:
+			comment, err = commentFromHash(id, fields)
+		case legacy:
+			comment, err = s.getLegacyComment(id)
+		default:
+			err = fmt.Errorf("kommentar %d: %w", id, ErrCommentNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		if comment.Status != op.Previous {
+			return fmt.Errorf("kommentar %d: %w", id, ErrModerationConflict)
+		}
+
+		comment.setStatus(op.Comment.Status)
+		comment.DeletedAt = op.Comment.DeletedAt
+		comment.DeletedFrom = op.Comment.DeletedFrom
+		current[i] = comment
+	}
+
+	_, err := tx.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		for _, comment := range current {
+			// Vollständig schreiben, damit auch Kommentare im alten Format als Hash landen
+			pipe.HSet(s.ctx, commentKey(comment.ID), commentToHash(comment))
+			if legacy {
+				for _, field := range legacyCommentFields {
+					pipe.Del(s.ctx, legacyCommentKey(comment.ID, field))
+				}
+			}
+			s.indexComment(pipe, comment)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.TxFailedErr) {
+		return fmt.Errorf("fehler bei der Moderation: %w", err)
+	}
+	return err
+}
+
+// RebuildIndex baut alle Sorted-Set-Indizes aus den gespeicherten Kommentaren neu auf.
+// Der neue Index entsteht unter rebuildKeyPrefix und ersetzt den alten erst am Ende
+// per RENAME in einer Transaktion, Leser sehen also nie einen leeren oder halben Index.
+func (s *ValkeyStore) RebuildIndex() (int, error) {
+	// Reste eines abgebrochenen Neuaufbaus entfernen
+	leftovers, err := s.scanKeys(rebuildKeyPrefix + "*")
+	if err != nil {
+		return 0, err
+	}
+	if len(leftovers) > 0 {
+		if err := s.client.Del(s.ctx, leftovers...).Err(); err != nil {
+			return 0, fmt.Errorf("fehler beim Löschen der Index-Keys: %w", err)
+		}
+	}
+
+	ids, err := s.listCommentIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	// Post je Kommentar merken, um nach dem Tausch gelöschte Kommentare auszutragen
+	posts := make(map[int]string, len(ids))
+	pipe := s.client.Pipeline()
+	for _, id := range ids {
+		comment, err := s.Get(id)
+		if err != nil {
+			log.Printf("⚠️  Kommentar %d übersprungen: %v", id, err)
+			continue
+		}
+		s.indexCommentAs(pipe, comment, rebuildKeyPrefix)
+		posts[id] = comment.PostID
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return 0, fmt.Errorf("fehler beim Schreiben des Index: %w", err)
+	}
+
+	if err := s.swapRebuiltIndex(); err != nil {
+		return 0, err
+	}
+
+	// Was während des Aufbaus geschrieben wurde, landete nur im alten Index:
+	// neue, geänderte und gelöschte Kommentare im neuen nachziehen
+	return s.reindexAfterSwap(posts)
+}
+
+// swapRebuiltIndex ersetzt die aktiven Indizes atomar durch die neu aufgebauten.
+// Alte Index-Keys ohne Gegenstück (z.B. Posts ohne Kommentare) entfallen.
+func (s *ValkeyStore) swapRebuiltIndex() error {
+	rebuilt, err := s.scanKeys(rebuildKeyPrefix + "*")
+	if err != nil {
+		return err
+	}
+	current, err := s.scanKeys("comments:index:*")
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	replaced := make(map[string]bool, len(rebuilt))
+	for _, key := range rebuilt {
+		target := strings.TrimPrefix(key, rebuildKeyPrefix)
+		replaced[target] = true
+		pipe.Rename(s.ctx, key, target)
+	}
+	for _, key := range current {
+		if !replaced[key] {
+			pipe.Del(s.ctx, key)
+		}
+	}
+	pipe.Set(s.ctx, indexVersionKey, indexVersion, 0)
+
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Austauschen des Index: %w", err)
+	}
+	return nil
+}
+
+// reindexAfterSwap trägt alle Kommentare erneut in die aktiven Indizes ein und
+// entfernt die, die seit dem Aufbau gelöscht wurden (posts: ID -> Post beim Aufbau)
+func (s *ValkeyStore) reindexAfterSwap(posts map[int]string) (int, error) {
+	ids, err := s.listCommentIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	pipe := s.client.Pipeline()
+	for _, id := range ids {
+		comment, err := s.Get(id)
+		if err != nil {
+			continue
+		}
+		s.indexComment(pipe, comment)
+		delete(posts, id)
+		indexed++
+	}
+	for id, postID := range posts {
+		s.unindexComment(pipe, &Comment{ID: id, PostID: postID})
+	}
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return 0, fmt.Errorf("fehler beim Schreiben des Index: %w", err)
+	}
+
+	return indexed, nil
+}
+
+// scanKeys sammelt alle Keys zu einem Muster per SCAN (ohne die Duplikate, die SCAN liefern kann)
+func (s *ValkeyStore) scanKeys(pattern string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	var cursor uint64
+	for {
+		batch, next, err := s.client.Scan(s.ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Suchen der Keys %s: %w", pattern, err)
+		}
+		for _, key := range batch {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// Altes Speicherformat: ein String-Key pro Feld (comments/{id}/<feld>)
+var legacyCommentFields = []string{"post_id", "parent_id", "username", "mailaddress", "text", "active", "created_at"}
+
+// schemaVersionKey markiert, dass die Migration auf Hashes gelaufen ist
+const (
+	schemaVersionKey  = "comment_schema_version"
+	schemaVersionHash = 2
+)
+
+func legacyCommentKey(id int, field string) string {
+	return fmt.Sprintf("comments/%d/%s", id, field)
+}
+
+// legacyLayoutActive prüft, ob das alte Speicherformat noch gelesen werden muss
+func (s *ValkeyStore) legacyLayoutActive() bool {
+	v, err := s.client.Get(s.ctx, schemaVersionKey).Int()
+	if err != nil {
+		return true
+	}
+	return v < schemaVersionHash
+}
+
+// EnsureSchema setzt die Schema-Version, wenn keine Kommentare im alten Format
+// vorhanden sind (z.B. bei einer neuen Datenbank). Sonst würde jeder Zugriff auf
+// eine unbekannte ID auch die alten Keys abfragen. Liegen noch alte Kommentare
+// vor, bleibt es bei der Warnung ('migrate').
+func (s *ValkeyStore) EnsureSchema() error {
+	if !s.legacyLayoutActive() {
+		return nil
+	}
+
+	ids, err := s.listLegacyCommentIDs()
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		log.Printf("⚠️  %d Kommentare im alten Format. Bitte einmalig './server migrate' ausführen.", len(ids))
+		return nil
+	}
+
+	if err := s.client.Set(s.ctx, schemaVersionKey, schemaVersionHash, 0).Err(); err != nil {
+		return fmt.Errorf("fehler beim Setzen der Schema-Version: %w", err)
+	}
+	return nil
+}
+
+// getLegacyComment liest einen Kommentar im alten Key-pro-Feld-Format
+func (s *ValkeyStore) getLegacyComment(id int) (*Comment, error) {
+	pipe := s.client.Pipeline()
+	cmds := make(map[string]*redis.StringCmd, len(legacyCommentFields))
+	for _, field := range legacyCommentFields {
+		cmds[field] = pipe.Get(s.ctx, legacyCommentKey(id, field))
+	}
+
+	// Fehlende Felder (z.B. parent_id) liefern redis.Nil
+	_, err := pipe.Exec(s.ctx)
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("kommentar nicht gefunden: %w", err)
+	}
+
+	fields := make(map[string]string, len(cmds))
+	for field, cmd := range cmds {
+		if value, err := cmd.Result(); err == nil {
+			fields[field] = value
+		}
+	}
+
+	return commentFromHash(id, fields)
+}
+
+// listLegacyCommentIDs sammelt die IDs von Kommentaren im alten Format
+func (s *ValkeyStore) listLegacyCommentIDs() ([]int, error) {
+	keys, err := s.scanKeys("comments/*/post_id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, key := range keys {
+		// ID aus dem Key extrahieren (comments/9/post_id -> 9)
+		parts := strings.Split(key, "/")
+		if len(parts) < 3 {
+			continue
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// MigrateLegacyComments wandelt alle Kommentare im alten Key-pro-Feld-Format
+// in Hashes um und entfernt die alten Keys. Bereits migrierte Kommentare
+// werden übersprungen, der Lauf kann also gefahrlos wiederholt werden.
+func (s *ValkeyStore) MigrateLegacyComments() (int, error) {
+	ids, err := s.listLegacyCommentIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, id := range ids {
+		comment, err := s.getLegacyComment(id)
+		if err != nil {
+			log.Printf("⚠️  Kommentar %d übersprungen: %v", id, err)
+			continue
+		}
+
+		// Bereits vorhandene Hashes nicht überschreiben
+		exists, err := s.client.Exists(s.ctx, commentKey(id)).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("fehler beim Prüfen von Kommentar %d: %w", id, err)
+		}
+
+		tx := s.client.TxPipeline()
+		if exists == 0 {
+			tx.HSet(s.ctx, commentKey(id), commentToHash(comment))
+		}
+		for _, field := range legacyCommentFields {
+			tx.Del(s.ctx, legacyCommentKey(id, field))
+		}
+		if _, err := tx.Exec(s.ctx); err != nil {
+			return migrated, fmt.Errorf("fehler beim Migrieren von Kommentar %d: %w", id, err)
+		}
+		migrated++
+	}
+
+	if err := s.client.Set(s.ctx, schemaVersionKey, schemaVersionHash, 0).Err(); err != nil {
+		return migrated, fmt.Errorf("fehler beim Setzen der Schema-Version: %w", err)
+	}
+
+	return migrated, nil
+}
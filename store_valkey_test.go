@@ -0,0 +1,221 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Integrationstest gegen ein echtes Valkey/Redis, z.B.
+//   VALKEY_TEST_ADDR=localhost:6379 go test -run Valkey
+// Achtung: die Datenbank VALKEY_TEST_DB (Standard 15) wird vor jedem Test geleert.
+
+// newValkeyTestStore liefert einen Store auf einer leeren Testdatenbank
+func newValkeyTestStore(t *testing.T) *ValkeyStore {
+	t.Helper()
+
+	addr := os.Getenv("VALKEY_TEST_ADDR")
+	if addr == "" {
+		t.Skip("VALKEY_TEST_ADDR nicht gesetzt")
+	}
+	db, err := strconv.Atoi(getEnv("VALKEY_TEST_DB", "15"))
+	if err != nil {
+		t.Fatalf("VALKEY_TEST_DB: %v", err)
+	}
+
+	store := NewValkeyStore(addr, os.Getenv("VALKEY_TEST_PASSWORD"), db)
+	t.Cleanup(func() { store.Close() })
+	if err := store.client.FlushDB(store.ctx).Err(); err != nil {
+		t.Fatalf("FLUSHDB: %v", err)
+	}
+	if err := store.EnsureSchema(); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	if err := store.EnsureIndex(); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+	return store
+}
+
+func TestValkeyStoreContract(t *testing.T) {
+	testCommentStore(t, func(t *testing.T) CommentStore {
+		return newValkeyTestStore(t)
+	})
+}
+
+func TestValkeyRebuildIndex(t *testing.T) {
+	store := newValkeyTestStore(t)
+
+	for i := 0; i < 5; i++ {
+		mustCreate(t, store, newContractComment("post-a", StatusApproved, 0))
+	}
+	pending := mustCreate(t, store, newContractComment("post-b", StatusPending, 0))
+	before, err := store.Counts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Index beschädigen: Eintrag fehlt, verwaister Post-Index, Rest eines abgebrochenen Neuaufbaus
+	store.client.ZRem(store.ctx, indexAllKey, pending.ID)
+	store.client.ZAdd(store.ctx, postIndexKey("geloescht", false), redis.Z{Score: 1, Member: 99})
+	store.client.ZAdd(store.ctx, rebuildKeyPrefix+indexAllKey, redis.Z{Score: 1, Member: 98})
+
+	indexed, err := store.RebuildIndex()
+	if err != nil {
+		t.Fatalf("RebuildIndex: %v", err)
+	}
+	if indexed != 6 {
+		t.Fatalf("%d Kommentare indiziert, erwartet 6", indexed)
+	}
+	if !store.indexReady() {
+		t.Fatal("Index nach dem Neuaufbau nicht bereit")
+	}
+
+	after, err := store.Counts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Total != before.Total || after.Active != before.Active || after.ByStatus[StatusPending] != 1 {
+		t.Fatalf("Counts nach Neuaufbau %+v, vorher %+v", after, before)
+	}
+
+	for _, pattern := range []string{rebuildKeyPrefix + "*", postIndexKey("geloescht", false)} {
+		keys, err := store.scanKeys(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) > 0 {
+			t.Fatalf("Keys %v nach dem Neuaufbau übrig", keys)
+		}
+	}
+
+	page, err := store.ListByPost("post-a", false, CommentQuery{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || len(page.Items) != 5 {
+		t.Fatalf("post-a: %d von %d, erwartet 5", len(page.Items), page.Total)
+	}
+}
+
+// TestValkeyRebuildIndexKeepsReaders spielt RebuildIndex schrittweise durch: Leser
+// sehen während des Aufbaus den alten Index, Schreibzugriffe gehen beim Tausch nicht verloren
+func TestValkeyEnsureSchema(t *testing.T) {
+	store := newValkeyTestStore(t)
+	if store.legacyLayoutActive() {
+		t.Fatal("neue Datenbank liest weiter das alte Format")
+	}
+
+	// Kommentar im alten Format: die Version bleibt offen, bis migriert wurde
+	store.client.Del(store.ctx, schemaVersionKey)
+	store.client.Set(store.ctx, legacyCommentKey(7, "post_id"), "post-a", 0)
+	store.client.Set(store.ctx, legacyCommentKey(7, "text"), "alt", 0)
+	if err := store.EnsureSchema(); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	if !store.legacyLayoutActive() {
+		t.Fatal("Schema-Version trotz alter Kommentare gesetzt")
+	}
+	if c, err := store.Get(7); err != nil || c.Text != "alt" {
+		t.Fatalf("alter Kommentar: %+v, %v", c, err)
+	}
+
+	if _, err := store.MigrateLegacyComments(); err != nil {
+		t.Fatalf("MigrateLegacyComments: %v", err)
+	}
+	if store.legacyLayoutActive() {
+		t.Fatal("Schema-Version nach der Migration nicht gesetzt")
+	}
+}
+
+// Ein Index aus Version 2 (Post-ID unkodiert im Key) wird beim Start neu aufgebaut
+func TestValkeyEnsureIndexUpgrade(t *testing.T) {
+	store := newValkeyTestStore(t)
+
+	approved := mustCreate(t, store, newContractComment("foo", StatusApproved, 0))
+	hidden := mustCreate(t, store, newContractComment("foo:active", StatusPending, time.Minute))
+
+	// Stand von Version 2 nachstellen: der Post "foo:active" teilt sich einen Key mit "foo"
+	rawKey := "comments:index:post:foo:active"
+	store.client.ZAdd(store.ctx, rawKey, redis.Z{Score: 1, Member: approved.ID}, redis.Z{Score: 2, Member: hidden.ID})
+	store.client.Set(store.ctx, indexVersionKey, 2, 0)
+
+	if err := store.EnsureIndex(); err != nil {
+		t.Fatalf("EnsureIndex: %v", err)
+	}
+	if !store.indexReady() {
+		t.Fatal("Index nach dem Upgrade nicht bereit")
+	}
+
+	for postID, want := range map[string]int{"foo": approved.ID, "foo:active": hidden.ID} {
+		page, err := store.ListByPost(postID, postID != "foo", CommentQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := commentIDs(page.Items); len(got) != 1 || got[0] != want || page.Total != 1 {
+			t.Fatalf("%s liefert %v (total %d), erwartet [%d]", postID, got, page.Total, want)
+		}
+	}
+}
+
+func TestValkeyRebuildIndexKeepsReaders(t *testing.T) {
+	store := newValkeyTestStore(t)
+	for i := 0; i < 3; i++ {
+		mustCreate(t, store, newContractComment("post-a", StatusApproved, 0))
+	}
+
+	// Den Aufbau anhalten, bevor der neue Index getauscht wird
+	ids, err := store.listCommentIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts := make(map[int]string)
+	pipe := store.client.Pipeline()
+	for _, id := range ids {
+		comment, err := store.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.indexCommentAs(pipe, comment, rebuildKeyPrefix)
+		posts[id] = comment.PostID
+	}
+	if _, err := pipe.Exec(store.ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := store.ListByPost("post-a", false, CommentQuery{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !store.indexReady() || page.Total != 3 {
+		t.Fatalf("Leser sehen während des Aufbaus %d Kommentare (bereit: %v)", page.Total, store.indexReady())
+	}
+
+	// Während des Aufbaus angelegte und gelöschte Kommentare werden nachgezogen
+	late := mustCreate(t, store, newContractComment("post-a", StatusApproved, 0))
+	if err := store.UpdateStatus(ids[0], StatusDeleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.swapRebuiltIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.reindexAfterSwap(posts); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err = store.ListByPost("post-a", false, CommentQuery{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := commentIDs(page.Items)
+	if page.Total != 3 || !slices.Contains(got, late.ID) || slices.Contains(got, ids[0]) {
+		t.Fatalf("nach dem Tausch %v (%d), erwartet 3 mit #%d ohne #%d", got, page.Total, late.ID, ids[0])
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// SubscriptionScope legt fest, worüber ein Leser benachrichtigt wird
+type SubscriptionScope string
+
+const (
+	ScopeNone    SubscriptionScope = ""        // kein Abo
+	ScopeReplies SubscriptionScope = "replies" // Antworten auf den eigenen Kommentar
+	ScopePost    SubscriptionScope = "post"    // alle neuen Kommentare des Posts
+)
+
+// Fehler bei Abos
+var (
+	ErrInvalidScope         = errors.New("ungültiges abo (erlaubt: replies, post)")
+	ErrSubscriptionNotFound = errors.New("abo nicht gefunden")
+)
+
+// Subscription ist ein Abo eines Lesers. Benachrichtigt wird erst nach der
+// Bestätigung über den Link in der E-Mail (Double-Opt-In).
+type Subscription struct {
+	ID          int               `json:"id"`
+	Email       string            `json:"email"`
+	PostID      string            `json:"post_id"`
+	CommentID   int               `json:"comment_id,omitempty"` // eigener Kommentar bei replies
+	Scope       SubscriptionScope `json:"scope"`
+	Confirmed   bool              `json:"confirmed"`
+	CreatedAt   string            `json:"created_at"`
+	ConfirmedAt string            `json:"confirmed_at,omitempty"`
+}
+
+// SubscriptionStore speichert die Abos im gewählten Storage-Backend
+type SubscriptionStore interface {
+	// CreateSubscription vergibt eine neue ID und speichert das Abo
+	CreateSubscription(sub *Subscription) error
+	GetSubscription(id int) (*Subscription, error)
+	ConfirmSubscription(id int, confirmedAt string) error
+	DeleteSubscription(id int) error
+	// ListPostSubscriptions liefert alle Abos eines Posts (auch unbestätigte)
+	ListPostSubscriptions(postID string) ([]*Subscription, error)
+}
+
+// subscriptionStore liefert den SubscriptionStore des Backends oder nil
+func subscriptionStore(store CommentStore) SubscriptionStore {
+	subscriptions, _ := store.(SubscriptionStore)
+	return subscriptions
+}
+
+// parseSubscriptionScope prüft den Wert aus dem Request
+func parseSubscriptionScope(value string) (SubscriptionScope, error) {
+	switch scope := SubscriptionScope(value); scope {
+	case ScopeNone, ScopeReplies, ScopePost:
+		return scope, nil
+	default:
+		return "", ErrInvalidScope
+	}
+}
+
+// validMailAddress lässt nur eine nackte Adresse ohne Namen oder Zeilenumbrüche
+// zu, da sie als Empfänger im E-Mail-Header landet
+func validMailAddress(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// Subscriptions verwaltet Abos und reiht die E-Mails an Leser in die Warteschlange ein
+type Subscriptions struct {
+	store   SubscriptionStore
+	queue   MailQueue
+	links   *ModerationLinks
+	apiBase string
+	// postURL ist die Vorlage für Links zum Post ({post_id} wird ersetzt, leer = kein Link)
+	postURL string
+}
+
+// NewSubscriptions liefert nil, wenn SUBSCRIPTIONS_ENABLED=false ist, kein
+// SMTP-Server konfiguriert ist oder das Backend keine Abos speichern kann
+func NewSubscriptions(store CommentStore, mailer *SMTPMailer, queue MailQueue, links *ModerationLinks) *Subscriptions {
+	subscriptions := subscriptionStore(store)
+	if !getEnvAsBool("SUBSCRIPTIONS_ENABLED", true) || mailer == nil || subscriptions == nil {
+		return nil
+	}
+
+	return &Subscriptions{
+		store:   subscriptions,
+		queue:   queue,
+		links:   links,
+		apiBase: publicAPIBase(),
+		postURL: getEnv("POST_URL_TEMPLATE", ""),
+	}
+}
+
+// signSubscription liefert die Signatur für Bestätigen bzw. Abbestellen.
+// Die Links laufen nicht ab, ein Abo muss sich immer abbestellen lassen.
+func (s *Subscriptions) signSubscription(id int, action string) string {
+	mac := hmac.New(sha256.New, s.links.secret)
+	fmt.Fprintf(mac, "subscription:%d:%s", id, action)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// linkURL liefert den signierten Link (action: confirm oder unsubscribe)
+func (s *Subscriptions) linkURL(id int, action string) string {
+	return fmt.Sprintf("%s/subscriptions/%d/%s?sig=%s", s.apiBase, id, action, s.signSubscription(id, action))
+}
+
+// VerifyLink prüft die Signatur eines Links
+func (s *Subscriptions) VerifyLink(id int, action, sig string) bool {
+	return hmac.Equal([]byte(sig), []byte(s.signSubscription(id, action)))
+}
+
+// Subscribe legt ein unbestätigtes Abo für den Autor eines neuen Kommentars an
+// und verschickt die Bestätigungs-E-Mail. Für Spam wird nichts angelegt, damit
+// niemand über das Formular E-Mails an fremde Adressen auslösen kann.
+func (s *Subscriptions) Subscribe(comment *Comment, scope SubscriptionScope) {
+	if s == nil || scope == ScopeNone || comment.Status == StatusSpam {
+		return
+	}
+
+	email := normalizeEmail(comment.MailAddress)
+	if scope == ScopePost {
+		// Ein Post-Abo pro Adresse reicht, auch die Bestätigung nur einmal schicken
+		existing, err := s.store.ListPostSubscriptions(comment.PostID)
+		if err != nil {
+			log.Printf("⚠️  Abos zu %s nicht lesbar: %v", comment.PostID, err)
+			return
+		}
+		for _, sub := range existing {
+			if sub.Email == email && sub.Scope == ScopePost {
+				return
+			}
+		}
+	}
+
+	sub := &Subscription{
+		Email:     email,
+		PostID:    comment.PostID,
+		Scope:     scope,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if scope == ScopeReplies {
+		sub.CommentID = comment.ID
+	}
+	if err := s.store.CreateSubscription(sub); err != nil {
+		log.Printf("⚠️  Abo für Kommentar #%d nicht gespeichert: %v", comment.ID, err)
+		return
+	}
+
+	what := "neue Kommentare zu " + sub.PostID
+	if scope == ScopeReplies {
+		what = fmt.Sprintf("Antworten auf deinen Kommentar zu %s", sub.PostID)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hallo %s,\n\n", comment.Username)
+	fmt.Fprintf(&b, "du möchtest per E-Mail über %s benachrichtigt werden.\n", what)
+	fmt.Fprintf(&b, "Bitte bestätige das Abo über diesen Link:\n\n%s\n\n", s.linkURL(sub.ID, "confirm"))
+	b.WriteString("Wenn du das nicht warst, ignoriere diese E-Mail einfach. Ohne Bestätigung bekommst du keine weiteren E-Mails.\n")
+
+	s.enqueue(&OutboundMail{
+		To:      sub.Email,
+		Subject: "Bitte bestätige dein Abo: " + sub.PostID,
+		Body:    b.String(),
+	})
+	log.Printf("🔔 Abo #%d (%s) für %s angelegt, Bestätigung ausstehend", sub.ID, sub.Scope, sub.PostID)
+}
+
+// Confirm bestätigt ein Abo (mehrfaches Bestätigen ist kein Fehler)
+func (s *Subscriptions) Confirm(id int) (*Subscription, error) {
+	sub, err := s.store.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Confirmed {
+		return sub, nil
+	}
+	sub.Confirmed = true
+	sub.ConfirmedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := s.store.ConfirmSubscription(id, sub.ConfirmedAt); err != nil {
+		return nil, err
+	}
+	log.Printf("🔔 Abo #%d bestätigt", id)
+	return sub, nil
+}
+
+// Unsubscribe löscht ein Abo
+func (s *Subscriptions) Unsubscribe(id int) error {
+	if err := s.store.DeleteSubscription(id); err != nil {
+		return err
+	}
+	log.Printf("🔕 Abo #%d abbestellt", id)
+	return nil
+}
+
+// CommentApproved benachrichtigt die Abonnenten eines Posts über einen gerade
+// freigegebenen Kommentar: Post-Abos immer, Antwort-Abos bei direkten Antworten.
+// Jede Adresse bekommt höchstens eine E-Mail, der Autor selbst keine.
+func (s *Subscriptions) CommentApproved(comment *Comment, previous CommentStatus) {
+	if s == nil || comment.Status != StatusApproved || previous == StatusApproved {
+		return
+	}
+
+	subs, err := s.store.ListPostSubscriptions(comment.PostID)
+	if err != nil {
+		log.Printf("⚠️  Abos zu %s nicht lesbar: %v", comment.PostID, err)
+		return
+	}
+
+	author := normalizeEmail(comment.MailAddress)
+	notified := make(map[string]bool)
+	queued := 0
+	for _, sub := range subs {
+		if !sub.Confirmed || sub.Email == author || notified[sub.Email] {
+			continue
+		}
+		reply := sub.Scope == ScopeReplies && sub.CommentID == comment.ParentID && comment.ParentID != 0
+		if sub.Scope != ScopePost && !reply {
+			continue
+		}
+		notified[sub.Email] = true
+
+		subject := fmt.Sprintf("Neuer Kommentar zu %s von %s", comment.PostID, comment.Username)
+		if reply {
+			subject = fmt.Sprintf("%s hat auf deinen Kommentar zu %s geantwortet", comment.Username, comment.PostID)
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s schreibt:\n\n%s\n\n", comment.Username, comment.Text)
+		if s.postURL != "" {
+			fmt.Fprintf(&b, "Zum Beitrag: %s\n\n", strings.ReplaceAll(s.postURL, "{post_id}", comment.PostID))
+		}
+		unsubscribe := s.linkURL(sub.ID, "unsubscribe")
+		fmt.Fprintf(&b, "Keine E-Mails mehr zu diesem Abo: %s\n", unsubscribe)
+
+		s.enqueue(&OutboundMail{
+			To:      sub.Email,
+			Subject: subject,
+			Body:    b.String(),
+			// RFC 8058: Mailprogramme können direkt per POST abbestellen
+			Headers: map[string]string{
+				"List-Unsubscribe":      "<" + unsubscribe + ">",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			},
+		})
+		queued++
+	}
+	if queued > 0 {
+		log.Printf("🔔 Kommentar #%d: %d Benachrichtigung(en) eingereiht", comment.ID, queued)
+	}
+}
+
+// enqueue reiht eine E-Mail ein, Fehler werden nur geloggt
+func (s *Subscriptions) enqueue(mail *OutboundMail) {
+	if err := s.queue.Enqueue(mail); err != nil {
+		log.Printf("⚠️  E-Mail nicht eingereiht: %v", err)
+	}
+}
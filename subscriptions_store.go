@@ -0,0 +1,272 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Abos werden im selben Backend wie die Kommentare gespeichert
+
+// subscriptionKey liefert den Hash-Key eines Abos
+func subscriptionKey(id int) string {
+	return fmt.Sprintf("subscription:%d", id)
+}
+
+// subscriptionsPostKey liefert das Set mit den Abo-IDs eines Posts
+func subscriptionsPostKey(postID string) string {
+	return "subscriptions:post:" + postID
+}
+
+// CreateSubscription vergibt eine ID (subscription_counter) und speichert das Abo
+func (s *ValkeyStore) CreateSubscription(sub *Subscription) error {
+	id, err := s.client.Incr(s.ctx, "subscription_counter").Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Generieren der ID: %w", err)
+	}
+	sub.ID = int(id)
+
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.ctx, subscriptionKey(sub.ID), map[string]interface{}{
+		"email":        sub.Email,
+		"post_id":      sub.PostID,
+		"comment_id":   sub.CommentID,
+		"scope":        string(sub.Scope),
+		"confirmed":    strconv.FormatBool(sub.Confirmed),
+		"created_at":   sub.CreatedAt,
+		"confirmed_at": sub.ConfirmedAt,
+	})
+	pipe.SAdd(s.ctx, subscriptionsPostKey(sub.PostID), sub.ID)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern des Abos: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription holt ein Abo anhand der ID
+func (s *ValkeyStore) GetSubscription(id int) (*Subscription, error) {
+	fields, err := s.client.HGetAll(s.ctx, subscriptionKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden des Abos %d: %w", id, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+
+	commentID, _ := strconv.Atoi(fields["comment_id"])
+	return &Subscription{
+		ID:          id,
+		Email:       fields["email"],
+		PostID:      fields["post_id"],
+		CommentID:   commentID,
+		Scope:       SubscriptionScope(fields["scope"]),
+		Confirmed:   fields["confirmed"] == "true",
+		CreatedAt:   fields["created_at"],
+		ConfirmedAt: fields["confirmed_at"],
+	}, nil
+}
+
+// ConfirmSubscription markiert ein Abo als bestätigt
+func (s *ValkeyStore) ConfirmSubscription(id int, confirmedAt string) error {
+	if _, err := s.GetSubscription(id); err != nil {
+		return err
+	}
+	if err := s.client.HSet(s.ctx, subscriptionKey(id), "confirmed", "true", "confirmed_at", confirmedAt).Err(); err != nil {
+		return fmt.Errorf("fehler beim Speichern des Abos: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription entfernt ein Abo samt Eintrag im Post-Set
+func (s *ValkeyStore) DeleteSubscription(id int) error {
+	sub, err := s.GetSubscription(id)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Del(s.ctx, subscriptionKey(id))
+	pipe.SRem(s.ctx, subscriptionsPostKey(sub.PostID), id)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Löschen des Abos: %w", err)
+	}
+	return nil
+}
+
+// ListPostSubscriptions liefert die Abos aus subscriptions:post:{postID}
+func (s *ValkeyStore) ListPostSubscriptions(postID string) ([]*Subscription, error) {
+	ids, err := s.client.SMembers(s.ctx, subscriptionsPostKey(postID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Abos: %w", err)
+	}
+
+	subs := make([]*Subscription, 0, len(ids))
+	for _, value := range ids {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		sub, err := s.GetSubscription(id)
+		if errors.Is(err, ErrSubscriptionNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs, nil
+}
+
+// subscriptionColumns ist die Spaltenliste für scanSubscription
+const subscriptionColumns = "id, email, post_id, comment_id, scope, confirmed, created_at, confirmed_at"
+
+// scanSubscription liest eine Zeile mit subscriptionColumns
+func scanSubscription(row rowScanner) (*Subscription, error) {
+	var sub Subscription
+	var scope string
+	if err := row.Scan(&sub.ID, &sub.Email, &sub.PostID, &sub.CommentID, &scope, &sub.Confirmed, &sub.CreatedAt, &sub.ConfirmedAt); err != nil {
+		return nil, err
+	}
+	sub.Scope = SubscriptionScope(scope)
+	return &sub, nil
+}
+
+// CreateSubscription speichert ein neues Abo
+func (s *SQLStore) CreateSubscription(sub *Subscription) error {
+	err := s.db.QueryRow(s.rebind(`INSERT INTO subscriptions
+		(email, post_id, comment_id, scope, confirmed, created_at, confirmed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		sub.Email, sub.PostID, sub.CommentID, string(sub.Scope), sub.Confirmed, sub.CreatedAt, sub.ConfirmedAt,
+	).Scan(&sub.ID)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Abos: %w", err)
+	}
+	return nil
+}
+
+// GetSubscription holt ein Abo anhand der ID
+func (s *SQLStore) GetSubscription(id int) (*Subscription, error) {
+	sub, err := scanSubscription(s.db.QueryRow(s.rebind("SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden des Abos %d: %w", id, err)
+	}
+	return sub, nil
+}
+
+// ConfirmSubscription markiert ein Abo als bestätigt
+func (s *SQLStore) ConfirmSubscription(id int, confirmedAt string) error {
+	result, err := s.db.Exec(s.rebind("UPDATE subscriptions SET confirmed = ?, confirmed_at = ? WHERE id = ?"), true, confirmedAt, id)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Abos: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+	return nil
+}
+
+// DeleteSubscription entfernt ein Abo
+func (s *SQLStore) DeleteSubscription(id int) error {
+	result, err := s.db.Exec(s.rebind("DELETE FROM subscriptions WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen des Abos: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+	return nil
+}
+
+// ListPostSubscriptions liefert alle Abos eines Posts
+func (s *SQLStore) ListPostSubscriptions(postID string) ([]*Subscription, error) {
+	rows, err := s.db.Query(s.rebind("SELECT "+subscriptionColumns+" FROM subscriptions WHERE post_id = ? ORDER BY id"), postID)
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Abos: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []*Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen der Abos: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// CreateSubscription vergibt eine neue ID und speichert das Abo
+func (s *MemoryStore) CreateSubscription(sub *Subscription) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextSubscriptionID++
+	sub.ID = s.nextSubscriptionID
+	clone := *sub
+	s.subscriptions[sub.ID] = &clone
+	return nil
+}
+
+// GetSubscription holt ein Abo anhand der ID
+func (s *MemoryStore) GetSubscription(id int) (*Subscription, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+	clone := *sub
+	return &clone, nil
+}
+
+// ConfirmSubscription markiert ein Abo als bestätigt
+func (s *MemoryStore) ConfirmSubscription(id int, confirmedAt string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+	sub.Confirmed = true
+	sub.ConfirmedAt = confirmedAt
+	return nil
+}
+
+// DeleteSubscription entfernt ein Abo
+func (s *MemoryStore) DeleteSubscription(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.subscriptions[id]; !ok {
+		return fmt.Errorf("abo %d: %w", id, ErrSubscriptionNotFound)
+	}
+	delete(s.subscriptions, id)
+	return nil
+}
+
+// ListPostSubscriptions liefert alle Abos eines Posts
+func (s *MemoryStore) ListPostSubscriptions(postID string) ([]*Subscription, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var subs []*Subscription
+	for _, sub := range s.subscriptions {
+		if sub.PostID == postID {
+			clone := *sub
+			subs = append(subs, &clone)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs, nil
+}
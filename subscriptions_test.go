@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestSubscriptions baut Abos auf MemoryStore und MemoryMailQueue samt
+// Router für die Links aus den E-Mails
+func newTestSubscriptions(t *testing.T) (*CommentService, *MemoryMailQueue, http.Handler) {
+	t.Helper()
+	t.Setenv("FORM_TOKEN_SECRET", "basis")
+	memory := NewMemoryStore()
+	queue := NewMemoryMailQueue()
+	links := &ModerationLinks{secret: []byte("test-secret")}
+	subscriptions := &Subscriptions{store: memory, queue: queue, links: links, apiBase: "https://blog.example/api/comments"}
+	cs := &CommentService{store: memory, subscriptions: subscriptions}
+
+	own, err := NewOwnCommentsCookie()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := &CommentHandler{service: cs, own: own, links: links}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/comments/subscriptions/{id}/{action:confirm|unsubscribe}", handler.SubscriptionLinkHandler).Methods("GET", "POST")
+	return cs, queue, router
+}
+
+// queuedMails entnimmt alle eingereihten E-Mails
+func queuedMails(t *testing.T, queue MailQueue) []*OutboundMail {
+	t.Helper()
+	var mails []*OutboundMail
+	for {
+		mail, err := queue.Dequeue()
+		if err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		if mail == nil {
+			return mails
+		}
+		mails = append(mails, mail)
+	}
+}
+
+// subscriptionLink sucht den Abo-Link für action im Text der E-Mail
+func subscriptionLink(t *testing.T, body, action string) *url.URL {
+	t.Helper()
+	for _, field := range strings.Fields(body) {
+		if strings.Contains(field, "/subscriptions/") && strings.Contains(field, "/"+action+"?") {
+			link, err := url.Parse(field)
+			if err != nil {
+				t.Fatalf("ungültiger Link %q: %v", field, err)
+			}
+			return link
+		}
+	}
+	t.Fatalf("kein %s-Link in der E-Mail:\n%s", action, body)
+	return nil
+}
+
+// followLink ruft einen Link aus einer E-Mail auf
+func followLink(router http.Handler, method string, link *url.URL) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, link.RequestURI(), nil))
+	return rec
+}
+
+func TestSubscriptionDoubleOptIn(t *testing.T) {
+	cs, queue, router := newTestSubscriptions(t)
+	input := CommentInput{PostID: "post-a", Username: "Anna", MailAddress: "Anna@Example.com", Text: "Hallo", Notify: ScopePost}
+	if _, err := cs.CreateComment(input); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	mails := queuedMails(t, queue)
+	if len(mails) != 1 || mails[0].To != "anna@example.com" {
+		t.Fatalf("E-Mails %+v, erwartet eine Bestätigung an anna@example.com", mails)
+	}
+	confirm := subscriptionLink(t, mails[0].Body, "confirm")
+
+	// Ein zweites Post-Abo derselben Adresse löst keine weitere Bestätigung aus
+	if _, err := cs.CreateComment(input); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if mails := queuedMails(t, queue); len(mails) != 0 {
+		t.Errorf("%d weitere Bestätigungen, erwartet keine", len(mails))
+	}
+
+	approve := func(username string) {
+		t.Helper()
+		c := newContractComment("post-a", StatusPending, 0)
+		c.Username = username
+		c.MailAddress = strings.ToLower(username) + "@example.com"
+		mustCreate(t, cs.store, c)
+		if _, err := cs.UpdateCommentStatus(c.ID, StatusApproved, Moderator{Name: "mod"}); err != nil {
+			t.Fatalf("UpdateCommentStatus: %v", err)
+		}
+	}
+
+	// Unbestätigt keine Benachrichtigung
+	approve("Bernd")
+	if mails := queuedMails(t, queue); len(mails) != 0 {
+		t.Fatalf("unbestätigtes Abo benachrichtigt: %+v", mails)
+	}
+
+	// Der Link mit falscher Signatur oder für eine andere Aktion gilt nicht
+	id, err := strconv.Atoi(path.Base(path.Dir(confirm.Path)))
+	if err != nil {
+		t.Fatalf("keine Abo-ID in %s", confirm.Path)
+	}
+	forged := *confirm
+	forged.RawQuery = url.Values{"sig": {cs.subscriptions.signSubscription(id, "unsubscribe")}}.Encode()
+	for _, link := range []*url.URL{&forged, {Path: confirm.Path}} {
+		if rec := followLink(router, http.MethodPost, link); rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s: %d, erwartet 403", link, rec.Code)
+		}
+	}
+
+	// GET zeigt nur die Bestätigungsseite (Mailprogramme rufen Links vorab auf)
+	if rec := followLink(router, http.MethodGet, confirm); rec.Code != http.StatusOK {
+		t.Fatalf("GET confirm: %d", rec.Code)
+	}
+	approve("Carla")
+	if mails := queuedMails(t, queue); len(mails) != 0 {
+		t.Fatal("Abo schon durch GET bestätigt")
+	}
+
+	rec := followLink(router, http.MethodPost, confirm)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST confirm: %d", rec.Code)
+	}
+	verified := false
+	for _, cookie := range rec.Result().Cookies() {
+		verified = verified || cookie.Name == verifiedEmailCookieName
+	}
+	if !verified {
+		t.Error("nach der Bestätigung kein Cookie mit der nachgewiesenen Adresse")
+	}
+	if rec := followLink(router, http.MethodPost, confirm); rec.Code != http.StatusOK {
+		t.Errorf("erneute Bestätigung: %d", rec.Code)
+	}
+
+	approve("Dora")
+	mails = queuedMails(t, queue)
+	if len(mails) != 1 || mails[0].To != "anna@example.com" {
+		t.Fatalf("E-Mails %+v, erwartet eine Benachrichtigung", mails)
+	}
+	unsubscribe := subscriptionLink(t, mails[0].Body, "unsubscribe")
+	if header := mails[0].Headers["List-Unsubscribe"]; header != "<"+unsubscribe.String()+">" {
+		t.Errorf("List-Unsubscribe %q, erwartet den Link aus der E-Mail", header)
+	}
+
+	// Abbestellen per One-Click-POST, ein zweiter Aufruf ist kein Fehler
+	for i := 0; i < 2; i++ {
+		if rec := followLink(router, http.MethodPost, unsubscribe); rec.Code != http.StatusOK {
+			t.Fatalf("POST unsubscribe (%d.): %d", i+1, rec.Code)
+		}
+	}
+	approve("Emil")
+	if mails := queuedMails(t, queue); len(mails) != 0 {
+		t.Errorf("nach dem Abbestellen noch %d E-Mails", len(mails))
+	}
+	if rec := followLink(router, http.MethodPost, confirm); rec.Code != http.StatusNotFound {
+		t.Errorf("Bestätigung nach dem Abbestellen: %d, erwartet 404", rec.Code)
+	}
+}
+
+func TestSubscriptionSkipsSpam(t *testing.T) {
+	cs, queue, _ := newTestSubscriptions(t)
+	input := CommentInput{PostID: "post-a", Username: "Bot", MailAddress: "opfer@example.com", Text: "Casino", Notify: ScopeReplies}
+	input.Ban = &BanRule{ID: 1, Type: BanIP, Value: "203.0.113.7", Action: BanSpam}
+	if _, err := cs.CreateComment(input); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	if mails := queuedMails(t, queue); len(mails) != 0 {
+		t.Errorf("Spam löst E-Mail an %s aus", mails[0].To)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DeleteComment verschiebt einen Kommentar in den Papierkorb (Status deleted).
+// Endgültig gelöscht wird er erst durch PurgeComment bzw. den Purge-Worker.
+func (cs *CommentService) DeleteComment(id int, by Moderator) error {
+	_, err := cs.UpdateCommentStatus(id, StatusDeleted, by)
+	return err
+}
+
+// RestoreComment holt einen Kommentar mit seinem vorherigen Status aus dem Papierkorb
+func (cs *CommentService) RestoreComment(id int, by Moderator) (*Comment, error) {
+	comment, err := cs.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Status != StatusDeleted {
+		return nil, fmt.Errorf("kommentar %d: %w", id, ErrNotInTrash)
+	}
+
+	op := newModerationOp(comment, comment.restoreStatus(), time.Now())
+	if err := cs.store.Moderate([]ModerationOp{op}); err != nil {
+		return nil, err
+	}
+	cs.afterModeration(op, by)
+	log.Printf("♻️  Kommentar #%d wiederhergestellt (%s)", id, op.Comment.Status)
+	return op.Comment, nil
+}
+
+// ListTrash liefert eine Seite der Kommentare im Papierkorb
+func (cs *CommentService) ListTrash(q CommentQuery) (*CommentPage, error) {
+	q.Status = StatusDeleted
+	return cs.store.ListAll(true, q)
+}
+
+// PurgeComment löscht einen Kommentar aus dem Papierkorb endgültig inkl. seiner Meldungen.
+// Ob er noch im Papierkorb liegt, prüft der Store beim Löschen (parallel wiederhergestellt).
+func (cs *CommentService) PurgeComment(id int, by Moderator) error {
+	comment, err := cs.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if comment.Status != StatusDeleted {
+		return fmt.Errorf("kommentar %d: %w", id, ErrNotInTrash)
+	}
+
+	if err := cs.store.Delete(id); err != nil {
+		return err
+	}
+	cs.recordAudit(AuditDelete, comment, comment.Status, "", by)
+	if cs.reports != nil {
+		if err := cs.reports.DeleteCommentReports(id); err != nil {
+			log.Printf("⚠️  Meldungen zu Kommentar #%d nicht gelöscht: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// PurgeTrash löscht alle Kommentare, die länger als retention im Papierkorb liegen.
+// Kommentare ohne deleted_at (vor Einführung des Papierkorbs gelöscht) bleiben liegen.
+func (cs *CommentService) PurgeTrash(retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+
+	q := CommentQuery{Limit: maxPageLimit, Sort: SortOldest, Status: StatusDeleted}
+	for {
+		page, err := cs.store.ListAll(true, q)
+		if err != nil {
+			return purged, err
+		}
+		for _, comment := range page.Items {
+			deletedAt, err := time.Parse(time.RFC3339, comment.DeletedAt)
+			if err != nil || deletedAt.After(cutoff) {
+				continue
+			}
+			// Inzwischen gelöscht oder wiederhergestellt: überspringen
+			err = cs.PurgeComment(comment.ID, systemModerator)
+			if errors.Is(err, ErrCommentNotFound) || errors.Is(err, ErrNotInTrash) {
+				continue
+			}
+			if err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if page.NextCursor == "" {
+			return purged, nil
+		}
+		cursor, err := decodePageCursor(page.NextCursor)
+		if err != nil {
+			return purged, err
+		}
+		q.Cursor = cursor
+	}
+}
+
+// startTrashPurge löscht im Intervall abgelaufene Kommentare aus dem Papierkorb
+// (TRASH_RETENTION, 0 = nie). Mehrere Instanzen stören sich nicht, bereits
+// gelöschte Kommentare werden übersprungen.
+func startTrashPurge(cs *CommentService) {
+	retention := getEnvAsDuration("TRASH_RETENTION", 30*24*time.Hour)
+	if retention <= 0 {
+		log.Println("🗑️  Papierkorb: automatisches Löschen deaktiviert")
+		return
+	}
+	interval := getEnvAsDuration("TRASH_PURGE_INTERVAL", time.Hour)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := cs.PurgeTrash(retention)
+			if err != nil {
+				log.Printf("❌ Papierkorb-Purge fehlgeschlagen: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("🗑️  Papierkorb: %d Kommentar(e) endgültig gelöscht", purged)
+			}
+		}
+	}()
+
+	log.Printf("🗑️  Papierkorb: endgültiges Löschen nach %v (Prüfung alle %v)", retention, interval)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// restoringStore stellt einen Kommentar wieder her, bevor das Löschen greift
+type restoringStore struct {
+	CommentStore
+	restore func(id int)
+}
+
+func (s *restoringStore) Delete(id int) error {
+	s.restore(id)
+	return s.CommentStore.Delete(id)
+}
+
+func TestRestoreRunsModerationHooks(t *testing.T) {
+	d, memory, queue := newTestDispatcher(t)
+	mustCreateWebhook(t, memory, &Webhook{URL: "https://example.com/a", Events: []WebhookEvent{EventCommentApproved}})
+	cs := &CommentService{store: memory, audit: memory, trust: memory, webhooks: d}
+
+	c := newContractComment("post-a", StatusPending, 0)
+	c.EmailVerified = true
+	mustCreate(t, memory, c)
+	if _, err := cs.UpdateCommentStatus(c.ID, StatusApproved, Moderator{Name: "mod"}); err != nil {
+		t.Fatalf("UpdateCommentStatus: %v", err)
+	}
+	if err := cs.DeleteComment(c.ID, Moderator{Name: "mod"}); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	restored, err := cs.RestoreComment(c.ID, Moderator{Name: "mod"})
+	if err != nil {
+		t.Fatalf("RestoreComment: %v", err)
+	}
+	if restored.Status != StatusApproved {
+		t.Fatalf("wiederhergestellt als %s, erwartet approved", restored.Status)
+	}
+
+	entries, _, err := memory.ListAudit(AuditFilter{CommentID: c.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Action != AuditRestore || entries[0].OldStatus != StatusDeleted {
+		t.Errorf("Audit-Log %+v, erwartet zuletzt restore aus deleted", entries[0])
+	}
+
+	// Freigabe und Wiederherstellung lösen comment.approved aus, gezählt wird die Freigabe nur einmal
+	due, _ := queue.Due(time.Now().Add(time.Hour), 100)
+	if len(due) != 2 {
+		t.Errorf("%d Zustellungen, erwartet 2", len(due))
+	}
+	trust, err := memory.GetTrust(normalizeEmail(c.MailAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trust.Approved != 1 {
+		t.Errorf("Vertrauen zählt %d Freigaben, erwartet 1", trust.Approved)
+	}
+}
+
+func TestPurgeKeepsRestoredComment(t *testing.T) {
+	memory := NewMemoryStore()
+	c := mustCreate(t, memory, newContractComment("post-a", StatusDeleted, 0))
+
+	// Zwischen Laden und Löschen holt ein Moderator den Kommentar zurück
+	store := &restoringStore{CommentStore: memory, restore: func(id int) {
+		if err := memory.UpdateStatus(id, StatusApproved); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	cs := &CommentService{store: store}
+
+	if err := cs.PurgeComment(c.ID, systemModerator); !errors.Is(err, ErrNotInTrash) {
+		t.Fatalf("PurgeComment: %v, erwartet ErrNotInTrash", err)
+	}
+	if got, err := memory.Get(c.ID); err != nil || got.Status != StatusApproved {
+		t.Fatalf("wiederhergestellter Kommentar: %+v, %v", got, err)
+	}
+}
+
+func TestPurgeTrashSkipsRestored(t *testing.T) {
+	memory := NewMemoryStore()
+	old := newContractComment("post-a", StatusDeleted, 0)
+	old.DeletedAt = time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	mustCreate(t, memory, old)
+	restored := newContractComment("post-a", StatusDeleted, time.Minute)
+	restored.DeletedAt = old.DeletedAt
+	mustCreate(t, memory, restored)
+
+	store := &restoringStore{CommentStore: memory, restore: func(id int) {
+		if id == restored.ID {
+			memory.UpdateStatus(id, StatusApproved)
+		}
+	}}
+	cs := &CommentService{store: store}
+
+	purged, err := cs.PurgeTrash(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("PurgeTrash: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("%d gelöscht, erwartet 1", purged)
+	}
+	if _, err := memory.Get(old.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("abgelaufener Kommentar noch da: %v", err)
+	}
+	if _, err := memory.Get(restored.ID); err != nil {
+		t.Errorf("wiederhergestellter Kommentar gelöscht: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TrustOverride setzt die automatische Bewertung eines Autors außer Kraft
+type TrustOverride string
+
+const (
+	TrustAuto      TrustOverride = ""          // nach Zählern
+	TrustTrusted   TrustOverride = "trusted"   // immer direkt freigeben
+	TrustUntrusted TrustOverride = "untrusted" // nie automatisch freigeben
+)
+
+// Fehler beim Vertrauen
+var (
+	ErrInvalidTrust = errors.New("ungültige vertrauenseinstellung")
+	// ErrTrustUnsupported: das Storage-Backend kann keine Vertrauensdaten speichern
+	ErrTrustUnsupported = errors.New("vertrauensdaten werden vom storage-backend nicht unterstützt")
+)
+
+// AuthorTrust zählt die Moderationsentscheidungen zu einer E-Mail-Adresse.
+// Die Zähler wachsen nur: eine Ablehnung bleibt, bis ein Override gesetzt wird.
+type AuthorTrust struct {
+	Email     string        `json:"email"`
+	Approved  int           `json:"approved"` // von Moderatoren freigegeben
+	Rejected  int           `json:"rejected"` // von Moderatoren abgelehnt oder als Spam markiert
+	Override  TrustOverride `json:"override"`
+	Trusted   bool          `json:"trusted"` // berechnet, nicht gespeichert
+	UpdatedAt string        `json:"updated_at,omitempty"`
+}
+
+// TrustStore speichert die Vertrauensdaten im gewählten Storage-Backend
+type TrustStore interface {
+	// GetTrust liefert den Eintrag zu email (leerer Eintrag, wenn unbekannt)
+	GetTrust(email string) (*AuthorTrust, error)
+	ListTrust() ([]*AuthorTrust, error)
+	// AddTrustCounts erhöht die Zähler atomar
+	AddTrustCounts(email string, approved, rejected int) error
+	SetTrustOverride(email string, override TrustOverride) error
+}
+
+// trustStore liefert den TrustStore des Backends oder nil
+func trustStore(store CommentStore) TrustStore {
+	trust, _ := store.(TrustStore)
+	return trust
+}
+
+// normalizeEmail ist der Schlüssel der Vertrauensdaten
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// parseTrustOverride prüft einen Override aus dem Request
+func parseTrustOverride(value string) (TrustOverride, error) {
+	switch override := TrustOverride(value); override {
+	case TrustAuto, TrustTrusted, TrustUntrusted:
+		return override, nil
+	default:
+		return "", fmt.Errorf("%w: override muss trusted, untrusted oder leer sein", ErrInvalidTrust)
+	}
+}
+
+// evaluate setzt Trusted nach Override bzw. Schwelle (0 = nur per Override)
+func (t *AuthorTrust) evaluate(threshold int) {
+	switch t.Override {
+	case TrustTrusted:
+		t.Trusted = true
+	case TrustUntrusted:
+		t.Trusted = false
+	default:
+		t.Trusted = threshold > 0 && t.Approved >= threshold && t.Rejected == 0
+	}
+}
+
+// verifiedEmailCookieName ist das Cookie mit der nachgewiesenen E-Mail-Adresse
+const verifiedEmailCookieName = "comments_verified"
+
+// signVerifiedEmail signiert die Adresse getrennt von den Kommentar-IDs, damit
+// sich die beiden Cookies nicht gegenseitig ersetzen lassen
+func (c *OwnCommentsCookie) signVerifiedEmail(email string) []byte {
+	return c.sign([]byte("verified:" + email))
+}
+
+// VerifiedEmail liefert die nachgewiesene Adresse aus dem Cookie ("" bei
+// fehlendem oder ungültigem Cookie)
+func (c *OwnCommentsCookie) VerifiedEmail(r *http.Request) string {
+	if c == nil {
+		return ""
+	}
+	cookie, err := r.Cookie(verifiedEmailCookieName)
+	if err != nil {
+		return ""
+	}
+
+	encoding := base64.RawURLEncoding
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	email, err := encoding.DecodeString(parts[0])
+	if err != nil || len(email) == 0 {
+		return ""
+	}
+	signature, err := encoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, c.signVerifiedEmail(string(email))) {
+		return ""
+	}
+	return string(email)
+}
+
+// SetVerifiedEmail merkt sich nach einem bestätigten Double-Opt-In, dass dieser
+// Browser Zugriff auf die Adresse hat
+func (c *OwnCommentsCookie) SetVerifiedEmail(w http.ResponseWriter, r *http.Request, email string) {
+	if c == nil {
+		return
+	}
+	email = normalizeEmail(email)
+	encoding := base64.RawURLEncoding
+	setWidgetCookie(w, r, &http.Cookie{
+		Name:     verifiedEmailCookieName,
+		Value:    encoding.EncodeToString([]byte(email)) + "." + encoding.EncodeToString(c.signVerifiedEmail(email)),
+		Path:     "/",
+		MaxAge:   int(c.maxAge.Seconds()),
+		HttpOnly: true,
+	})
+}
+
+// trustDecision liefert den Startstatus für einen Kommentar, den die Spam-Pipeline
+// als pending eingestuft hat, samt Begründung ("" = keine Entscheidung)
+func (cs *CommentService) trustDecision(comment *Comment) (CommentStatus, string) {
+	// Die Adresse im Formular kann jeder eintippen, vertraut wird nur nachgewiesenen
+	if cs.trust == nil || !comment.EmailVerified {
+		return comment.Status, ""
+	}
+	trust, err := cs.trust.GetTrust(normalizeEmail(comment.MailAddress))
+	if err != nil {
+		log.Printf("⚠️  Vertrauensdaten nicht lesbar: %v", err)
+		return comment.Status, ""
+	}
+	trust.evaluate(cs.trustThreshold)
+
+	switch {
+	case trust.Override == TrustTrusted:
+		return StatusApproved, "vertrauenswürdig: manuell freigeschaltet"
+	case trust.Trusted:
+		return StatusApproved, fmt.Sprintf("vertrauenswürdig: %d freigegebene kommentare, keine ablehnungen", trust.Approved)
+	}
+	return comment.Status, ""
+}
+
+// trackTrust zählt Moderationsentscheidungen für den Autor eines Kommentars.
+// Kommentare ohne nachgewiesene Adresse zählen nicht, sonst könnte jemand unter
+// fremder Adresse Ablehnungen sammeln.
+func (cs *CommentService) trackTrust(comment *Comment, previous CommentStatus) {
+	if cs.trust == nil || !comment.EmailVerified || comment.Status == previous {
+		return
+	}
+
+	approved, rejected := 0, 0
+	switch comment.Status {
+	case StatusApproved:
+		approved = 1
+	case StatusRejected, StatusSpam:
+		rejected = 1
+	default:
+		return
+	}
+	if err := cs.trust.AddTrustCounts(normalizeEmail(comment.MailAddress), approved, rejected); err != nil {
+		log.Printf("⚠️  Vertrauensdaten für Kommentar #%d nicht aktualisiert: %v", comment.ID, err)
+	}
+}
+
+// GetTrust liefert die Vertrauensdaten eines Autors
+func (cs *CommentService) GetTrust(email string) (*AuthorTrust, error) {
+	if cs.trust == nil {
+		return nil, ErrTrustUnsupported
+	}
+	trust, err := cs.trust.GetTrust(normalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+	trust.evaluate(cs.trustThreshold)
+	return trust, nil
+}
+
+// ListTrust liefert alle Autoren mit Vertrauensdaten
+func (cs *CommentService) ListTrust() ([]*AuthorTrust, error) {
+	if cs.trust == nil {
+		return nil, ErrTrustUnsupported
+	}
+	entries, err := cs.trust.ListTrust()
+	if err != nil {
+		return nil, err
+	}
+	for _, trust := range entries {
+		trust.evaluate(cs.trustThreshold)
+	}
+	return entries, nil
+}
+
+// SetTrustOverride setzt oder entfernt (TrustAuto) den Override eines Autors
+func (cs *CommentService) SetTrustOverride(email string, override TrustOverride) (*AuthorTrust, error) {
+	if cs.trust == nil {
+		return nil, ErrTrustUnsupported
+	}
+	email = normalizeEmail(email)
+	if email == "" {
+		return nil, fmt.Errorf("%w: email fehlt", ErrInvalidTrust)
+	}
+	if err := cs.trust.SetTrustOverride(email, override); err != nil {
+		return nil, err
+	}
+	log.Printf("🤝 Vertrauen für %s: %q", email, override)
+	return cs.GetTrust(email)
+}
+
+// trustTimestamp ist der Zeitstempel für updated_at
+func trustTimestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
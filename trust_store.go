@@ -0,0 +1,212 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Vertrauensdaten liegen im selben Backend wie die Kommentare
+
+// trustKey liefert den Hash-Key der Vertrauensdaten einer E-Mail-Adresse
+func trustKey(email string) string {
+	return "trust:" + email
+}
+
+// GetTrust liest den Hash trust:{email}
+func (s *ValkeyStore) GetTrust(email string) (*AuthorTrust, error) {
+	fields, err := s.client.HGetAll(s.ctx, trustKey(email)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Vertrauensdaten: %w", err)
+	}
+	return trustFromHash(email, fields), nil
+}
+
+// trustFromHash baut einen Eintrag aus den Hash-Feldern
+func trustFromHash(email string, fields map[string]string) *AuthorTrust {
+	approved, _ := strconv.Atoi(fields["approved"])
+	rejected, _ := strconv.Atoi(fields["rejected"])
+	return &AuthorTrust{
+		Email:     email,
+		Approved:  approved,
+		Rejected:  rejected,
+		Override:  TrustOverride(fields["override"]),
+		UpdatedAt: fields["updated_at"],
+	}
+}
+
+// ListTrust liefert alle Einträge aus trust:index, sortiert nach E-Mail
+func (s *ValkeyStore) ListTrust() ([]*AuthorTrust, error) {
+	emails, err := s.client.SMembers(s.ctx, "trust:index").Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Vertrauensdaten: %w", err)
+	}
+	sort.Strings(emails)
+
+	entries := make([]*AuthorTrust, 0, len(emails))
+	for _, email := range emails {
+		trust, err := s.GetTrust(email)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, trust)
+	}
+	return entries, nil
+}
+
+// AddTrustCounts erhöht die Zähler per HINCRBY
+func (s *ValkeyStore) AddTrustCounts(email string, approved, rejected int) error {
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(s.ctx, trustKey(email), "approved", int64(approved))
+	pipe.HIncrBy(s.ctx, trustKey(email), "rejected", int64(rejected))
+	pipe.HSet(s.ctx, trustKey(email), "updated_at", trustTimestamp())
+	pipe.SAdd(s.ctx, "trust:index", email)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern der Vertrauensdaten: %w", err)
+	}
+	return nil
+}
+
+// SetTrustOverride setzt das Feld override
+func (s *ValkeyStore) SetTrustOverride(email string, override TrustOverride) error {
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.ctx, trustKey(email), "override", string(override), "updated_at", trustTimestamp())
+	pipe.SAdd(s.ctx, "trust:index", email)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern der Vertrauensdaten: %w", err)
+	}
+	return nil
+}
+
+// trustColumns ist die Spaltenliste für scanTrust
+const trustColumns = "email, approved, rejected, override, updated_at"
+
+// scanTrust liest eine Zeile mit trustColumns
+func scanTrust(row rowScanner) (*AuthorTrust, error) {
+	var trust AuthorTrust
+	var override string
+	if err := row.Scan(&trust.Email, &trust.Approved, &trust.Rejected, &override, &trust.UpdatedAt); err != nil {
+		return nil, err
+	}
+	trust.Override = TrustOverride(override)
+	return &trust, nil
+}
+
+// GetTrust liest eine Zeile aus author_trust
+func (s *SQLStore) GetTrust(email string) (*AuthorTrust, error) {
+	trust, err := scanTrust(s.db.QueryRow(s.rebind("SELECT "+trustColumns+" FROM author_trust WHERE email = ?"), email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &AuthorTrust{Email: email}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Vertrauensdaten: %w", err)
+	}
+	return trust, nil
+}
+
+// ListTrust liefert alle Einträge, sortiert nach E-Mail
+func (s *SQLStore) ListTrust() ([]*AuthorTrust, error) {
+	rows, err := s.db.Query("SELECT " + trustColumns + " FROM author_trust ORDER BY email")
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Vertrauensdaten: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*AuthorTrust{}
+	for rows.Next() {
+		trust, err := scanTrust(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen der Vertrauensdaten: %w", err)
+		}
+		entries = append(entries, trust)
+	}
+	return entries, rows.Err()
+}
+
+// AddTrustCounts erhöht die Zähler per Upsert
+func (s *SQLStore) AddTrustCounts(email string, approved, rejected int) error {
+	_, err := s.db.Exec(s.rebind(`INSERT INTO author_trust (email, approved, rejected, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (email) DO UPDATE SET
+			approved = author_trust.approved + excluded.approved,
+			rejected = author_trust.rejected + excluded.rejected,
+			updated_at = excluded.updated_at`),
+		email, approved, rejected, trustTimestamp())
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern der Vertrauensdaten: %w", err)
+	}
+	return nil
+}
+
+// SetTrustOverride setzt override per Upsert
+func (s *SQLStore) SetTrustOverride(email string, override TrustOverride) error {
+	_, err := s.db.Exec(s.rebind(`INSERT INTO author_trust (email, override, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (email) DO UPDATE SET override = excluded.override, updated_at = excluded.updated_at`),
+		email, string(override), trustTimestamp())
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern der Vertrauensdaten: %w", err)
+	}
+	return nil
+}
+
+// GetTrust liefert eine Kopie des Eintrags
+func (s *MemoryStore) GetTrust(email string) (*AuthorTrust, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if trust, ok := s.trust[email]; ok {
+		clone := *trust
+		return &clone, nil
+	}
+	return &AuthorTrust{Email: email}, nil
+}
+
+// ListTrust liefert alle Einträge, sortiert nach E-Mail
+func (s *MemoryStore) ListTrust() ([]*AuthorTrust, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entries := make([]*AuthorTrust, 0, len(s.trust))
+	for _, trust := range s.trust {
+		clone := *trust
+		entries = append(entries, &clone)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Email < entries[j].Email })
+	return entries, nil
+}
+
+// AddTrustCounts erhöht die Zähler
+func (s *MemoryStore) AddTrustCounts(email string, approved, rejected int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	trust := s.trustEntry(email)
+	trust.Approved += approved
+	trust.Rejected += rejected
+	trust.UpdatedAt = trustTimestamp()
+	return nil
+}
+
+// SetTrustOverride setzt den Override
+func (s *MemoryStore) SetTrustOverride(email string, override TrustOverride) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	trust := s.trustEntry(email)
+	trust.Override = override
+	trust.UpdatedAt = trustTimestamp()
+	return nil
+}
+
+// trustEntry liefert den Eintrag zu email und legt ihn bei Bedarf an (Lock muss gehalten werden)
+func (s *MemoryStore) trustEntry(email string) *AuthorTrust {
+	trust, ok := s.trust[email]
+	if !ok {
+		trust = &AuthorTrust{Email: email}
+		s.trust[email] = trust
+	}
+	return trust
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestTrustService baut einen CommentService mit Vertrauensdaten auf MemoryStore
+func newTestTrustService(threshold int) (*CommentService, *MemoryStore) {
+	memory := NewMemoryStore()
+	return &CommentService{store: memory, trust: memory, trustThreshold: threshold}, memory
+}
+
+func TestTrustCountsOnlyVerified(t *testing.T) {
+	cs, memory := newTestTrustService(2)
+	mod := Moderator{Name: "mod"}
+
+	moderate := func(verified bool, mail string, status CommentStatus) {
+		t.Helper()
+		c := newContractComment("post-a", StatusPending, 0)
+		c.MailAddress = mail
+		c.EmailVerified = verified
+		mustCreate(t, memory, c)
+		if _, err := cs.UpdateCommentStatus(c.ID, status, mod); err != nil {
+			t.Fatalf("UpdateCommentStatus: %v", err)
+		}
+	}
+
+	// Unter fremder Adresse gesammelte Ablehnungen zählen nicht
+	moderate(false, "anna@example.com", StatusRejected)
+	moderate(false, "anna@example.com", StatusSpam)
+	moderate(false, "anna@example.com", StatusApproved)
+	trust, err := cs.GetTrust("anna@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trust.Approved != 0 || trust.Rejected != 0 {
+		t.Fatalf("unbestätigte Adresse gezählt: %+v", trust)
+	}
+
+	// Bestätigte Adressen zählen, unabhängig von Groß-/Kleinschreibung
+	moderate(true, "Anna@Example.com", StatusApproved)
+	moderate(true, " anna@example.com", StatusApproved)
+	trust, _ = cs.GetTrust("ANNA@example.com")
+	if trust.Approved != 2 || trust.Rejected != 0 || !trust.Trusted {
+		t.Fatalf("Vertrauen %+v, erwartet 2 Freigaben und vertrauenswürdig", trust)
+	}
+
+	moderate(true, "anna@example.com", StatusSpam)
+	trust, _ = cs.GetTrust("anna@example.com")
+	if trust.Rejected != 1 || trust.Trusted {
+		t.Errorf("Vertrauen %+v, erwartet 1 Ablehnung und nicht mehr vertrauenswürdig", trust)
+	}
+}
+
+func TestTrustDecision(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		approved  int
+		rejected  int
+		override  TrustOverride
+		verified  bool
+		want      CommentStatus
+	}{
+		{"Schwelle erreicht", 2, 2, 0, TrustAuto, true, StatusApproved},
+		{"Schwelle nicht bestätigt", 2, 5, 0, TrustAuto, false, StatusPending},
+		{"unter der Schwelle", 2, 1, 0, TrustAuto, true, StatusPending},
+		{"mit Ablehnung", 2, 5, 1, TrustAuto, true, StatusPending},
+		{"ohne Schwelle", 0, 5, 0, TrustAuto, true, StatusPending},
+		{"manuell vertraut", 0, 0, 3, TrustTrusted, true, StatusApproved},
+		{"manuell vertraut, nicht bestätigt", 0, 0, 0, TrustTrusted, false, StatusPending},
+		{"manuell ausgeschlossen", 2, 5, 0, TrustUntrusted, true, StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, memory := newTestTrustService(tt.threshold)
+			if err := memory.AddTrustCounts("anna@example.com", tt.approved, tt.rejected); err != nil {
+				t.Fatal(err)
+			}
+			if err := memory.SetTrustOverride("anna@example.com", tt.override); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := cs.CreateComment(CommentInput{PostID: "post-a", Username: "Anna", MailAddress: "Anna@example.com", Text: "Hallo", EmailVerified: tt.verified})
+			if err != nil {
+				t.Fatalf("CreateComment: %v", err)
+			}
+			if c.Status != tt.want || (c.DecisionReason != "") != (tt.want == StatusApproved) {
+				t.Errorf("Status %s (%q), erwartet %s", c.Status, c.DecisionReason, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifiedEmailCookie(t *testing.T) {
+	t.Setenv("FORM_TOKEN_SECRET", "basis")
+	cookies, err := NewOwnCommentsCookie()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	cookies.SetVerifiedEmail(rec, httptest.NewRequest("GET", "/api/comments/subscriptions/confirm", nil), " Anna@Example.com ")
+	r := httptest.NewRequest("POST", "/api/comments", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	if got := cookies.VerifiedEmail(r); got != "anna@example.com" {
+		t.Fatalf("VerifiedEmail = %q, erwartet anna@example.com", got)
+	}
+
+	// Das Cookie mit den Kommentar-IDs gilt nicht als Nachweis einer Adresse
+	rec = httptest.NewRecorder()
+	cookies.Add(rec, httptest.NewRequest("POST", "/api/comments", nil), 7)
+	forged := httptest.NewRequest("POST", "/api/comments", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		cookie.Name = verifiedEmailCookieName
+		forged.AddCookie(cookie)
+	}
+	if got := cookies.VerifiedEmail(forged); got != "" {
+		t.Errorf("VerifiedEmail mit fremdem Cookie = %q", got)
+	}
+	if got := cookies.VerifiedEmail(httptest.NewRequest("POST", "/api/comments", nil)); got != "" {
+		t.Errorf("VerifiedEmail ohne Cookie = %q", got)
+	}
+}
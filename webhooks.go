@@ -0,0 +1,610 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookEvent ist ein Ereignis, das an Webhooks ausgeliefert wird
+type WebhookEvent string
+
+const (
+	EventCommentCreated  WebhookEvent = "comment.created" // neuer Kommentar (pending oder approved)
+	EventCommentApproved WebhookEvent = "comment.approved"
+	EventCommentRejected WebhookEvent = "comment.rejected"
+	EventCommentSpam     WebhookEvent = "comment.spam"    // als Spam eingestuft, auch Sperrliste und Shadow-Ban
+	EventCommentDeleted  WebhookEvent = "comment.deleted" // in den Papierkorb verschoben
+	EventPing            WebhookEvent = "ping"            // Test über die Admin-API, geht an jeden Webhook
+)
+
+// webhookEvents sind die abonnierbaren Ereignisse
+var webhookEvents = []WebhookEvent{EventCommentCreated, EventCommentApproved, EventCommentRejected, EventCommentSpam, EventCommentDeleted}
+
+// statusEvents ordnet Statuswechseln ein Ereignis zu (andere Wechsel lösen keins aus)
+var statusEvents = map[CommentStatus]WebhookEvent{
+	StatusApproved: EventCommentApproved,
+	StatusRejected: EventCommentRejected,
+	StatusSpam:     EventCommentSpam,
+	StatusDeleted:  EventCommentDeleted,
+}
+
+// Fehler bei Webhooks
+var (
+	ErrInvalidWebhook  = errors.New("ungültiger webhook")
+	ErrWebhookNotFound = errors.New("webhook nicht gefunden")
+	// ErrWebhooksUnsupported: das Storage-Backend kann keine Webhooks speichern
+	ErrWebhooksUnsupported = errors.New("webhooks werden vom storage-backend nicht unterstützt")
+	ErrDeliveryNotFound    = errors.New("zustellung nicht gefunden")
+)
+
+// Webhook ist ein vom Admin angelegtes Ziel für Ereignisse
+type Webhook struct {
+	ID          int            `json:"id"`
+	URL         string         `json:"url"`
+	Events      []WebhookEvent `json:"events"`
+	Secret      string         `json:"secret,omitempty"` // nur beim Anlegen in der Antwort
+	Format      WebhookFormat  `json:"format"`
+	Token       string         `json:"token,omitempty"` // Zugangstoken für Matrix, ntfy und Gotify, nie in Antworten
+	Description string         `json:"description,omitempty"`
+	Active      bool           `json:"active"`
+	CreatedAt   string         `json:"created_at"`
+}
+
+// subscribes prüft, ob der Webhook ein Ereignis abonniert hat
+func (wh *Webhook) subscribes(event WebhookEvent) bool {
+	if event == EventPing {
+		return true
+	}
+	for _, subscribed := range wh.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// validate prüft URL, Format und Ereignisse eines Webhooks aus dem Request.
+// Chat-Formate ohne Ereignisse melden neue Kommentare.
+func (wh *Webhook) validate() error {
+	target, err := url.Parse(wh.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url muss eine absolute http(s)-URL sein", ErrInvalidWebhook)
+	}
+	if wh.Format == "" {
+		wh.Format = FormatJSON
+	}
+	if err := wh.Format.validate(wh); err != nil {
+		return err
+	}
+	if len(wh.Events) == 0 && wh.Format != FormatJSON {
+		wh.Events = []WebhookEvent{EventCommentCreated}
+	}
+	if len(wh.Events) == 0 {
+		return fmt.Errorf("%w: mindestens ein event ist erforderlich", ErrInvalidWebhook)
+	}
+	for _, event := range wh.Events {
+		known := false
+		for _, candidate := range webhookEvents {
+			known = known || event == candidate
+		}
+		if !known {
+			return fmt.Errorf("%w: unbekanntes event %q", ErrInvalidWebhook, event)
+		}
+	}
+	return nil
+}
+
+// WebhookStore speichert die Webhooks im gewählten Storage-Backend
+type WebhookStore interface {
+	ListWebhooks() ([]*Webhook, error)
+	GetWebhook(id int) (*Webhook, error)
+	// CreateWebhook vergibt eine neue ID und speichert den Webhook
+	CreateWebhook(wh *Webhook) error
+	UpdateWebhook(wh *Webhook) error
+	DeleteWebhook(id int) error
+}
+
+// webhookStore liefert den WebhookStore des Backends oder nil
+func webhookStore(store CommentStore) WebhookStore {
+	webhooks, _ := store.(WebhookStore)
+	return webhooks
+}
+
+// WebhookDelivery ist eine ausstehende Zustellung eines Ereignisses an einen Webhook
+type WebhookDelivery struct {
+	ID          string          `json:"id"`
+	WebhookID   int             `json:"webhook_id"`
+	Event       WebhookEvent    `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`             // bisherige Versuche
+	NextAttempt int64           `json:"next_attempt"`         // Unix-Zeit des nächsten Versuchs
+	LastError   string          `json:"last_error,omitempty"` // nur in der Dead-Letter-Liste
+	CreatedAt   string          `json:"created_at"`
+	FailedAt    string          `json:"failed_at,omitempty"` // nur in der Dead-Letter-Liste
+}
+
+// WebhookLogEntry protokolliert einen Zustellversuch
+type WebhookLogEntry struct {
+	DeliveryID  string       `json:"delivery_id"`
+	WebhookID   int          `json:"webhook_id"`
+	Event       WebhookEvent `json:"event"`
+	Attempt     int          `json:"attempt"`
+	StatusCode  int          `json:"status_code,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	DurationMs  int64        `json:"duration_ms"`
+	Success     bool         `json:"success"`
+	NextAttempt string       `json:"next_attempt,omitempty"` // leer = kein weiterer Versuch
+	CreatedAt   string       `json:"created_at"`
+}
+
+// WebhookQueue hält ausstehende Zustellungen, Dead-Letter-Liste und Zustell-Log
+// (Valkey, damit Wiederholungen einen Neustart überstehen, sonst In-Memory)
+type WebhookQueue interface {
+	// Schedule plant eine Zustellung für NextAttempt ein
+	Schedule(delivery *WebhookDelivery) error
+	// Due entnimmt bis zu limit fällige Zustellungen
+	Due(now time.Time, limit int) ([]*WebhookDelivery, error)
+	DeadLetter(delivery *WebhookDelivery) error
+	// ListDeadLetters liefert eine Seite der Dead-Letter-Liste (neueste zuerst) und die Gesamtzahl
+	ListDeadLetters(offset, limit int) ([]*WebhookDelivery, int, error)
+	// TakeDeadLetter entfernt eine Zustellung aus der Dead-Letter-Liste und liefert sie
+	TakeDeadLetter(id string) (*WebhookDelivery, error)
+	AppendLog(entry *WebhookLogEntry) error
+	// ListLog liefert die neuesten Einträge, optional nur eines Webhooks (0 = alle)
+	ListLog(webhookID, limit int) ([]*WebhookLogEntry, error)
+}
+
+// webhookPayload ist der Body einer Zustellung im Format json
+type webhookPayload struct {
+	ID             string        `json:"id"`
+	Event          WebhookEvent  `json:"event"`
+	CreatedAt      string        `json:"created_at"`
+	PreviousStatus CommentStatus `json:"previous_status,omitempty"`
+	Comment        *Comment      `json:"comment,omitempty"`
+}
+
+// webhookComment ist der Kommentar im Payload: wie in der Admin-API, aber
+// ohne IP, User-Agent und Antworten, die E-Mail-Adresse nur mit includeEmail
+func webhookComment(c *Comment, includeEmail bool) *Comment {
+	clone := *c
+	clone.IP = ""
+	clone.UserAgent = ""
+	clone.Children = nil
+	if !includeEmail {
+		clone.MailAddress = ""
+	}
+	return &clone
+}
+
+// WebhookDispatcher verteilt Ereignisse an die Webhooks und stellt sie im Hintergrund zu
+type WebhookDispatcher struct {
+	store        WebhookStore
+	queue        WebhookQueue
+	client       *http.Client
+	maxAttempts  int
+	baseDelay    time.Duration // Wartezeit nach dem ersten Fehlschlag, verdoppelt sich
+	maxDelay     time.Duration
+	interval     time.Duration
+	apiBase      string // für Links ins Admin-Panel
+	includeEmail bool   // E-Mail-Adressen mitsenden (WEBHOOK_INCLUDE_EMAIL)
+}
+
+// NewWebhookDispatcher liest die Konfiguration aus der Umgebung.
+// Liefert nil, wenn das Backend keine Webhooks speichern kann.
+func NewWebhookDispatcher(store CommentStore, queue WebhookQueue) *WebhookDispatcher {
+	webhooks := webhookStore(store)
+	if webhooks == nil {
+		return nil
+	}
+
+	return &WebhookDispatcher{
+		store:        webhooks,
+		queue:        queue,
+		client:       &http.Client{Timeout: getEnvAsDuration("WEBHOOK_TIMEOUT", 5*time.Second)},
+		maxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		baseDelay:    getEnvAsDuration("WEBHOOK_RETRY_DELAY", 30*time.Second),
+		maxDelay:     getEnvAsDuration("WEBHOOK_MAX_RETRY_DELAY", 6*time.Hour),
+		interval:     getEnvAsDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+		apiBase:      publicAPIBase(),
+		includeEmail: getEnvAsBool("WEBHOOK_INCLUDE_EMAIL", false),
+	}
+}
+
+// newDeliveryID erzeugt eine zufällige ID, die der Empfänger zur Deduplizierung nutzen kann
+func newDeliveryID() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Emit plant ein Ereignis für alle aktiven Webhooks ein, die es abonniert haben.
+// Fehler werden nur geloggt, die auslösende Aktion ist bereits gespeichert.
+func (d *WebhookDispatcher) Emit(event WebhookEvent, comment *Comment, previous CommentStatus) {
+	if d == nil {
+		return
+	}
+	webhooks, err := d.store.ListWebhooks()
+	if err != nil {
+		log.Printf("⚠️  Webhooks nicht lesbar: %v", err)
+		return
+	}
+	for _, wh := range webhooks {
+		if wh.Active && wh.subscribes(event) {
+			if _, err := d.schedule(wh.ID, event, comment, previous); err != nil {
+				log.Printf("⚠️  Webhook #%d: %s nicht eingeplant: %v", wh.ID, event, err)
+			}
+		}
+	}
+}
+
+// EmitStatus löst das Ereignis zu einem Statuswechsel aus (falls es eins gibt)
+func (d *WebhookDispatcher) EmitStatus(comment *Comment, previous CommentStatus) {
+	if event, ok := statusEvents[comment.Status]; ok && comment.Status != previous {
+		d.Emit(event, comment, previous)
+	}
+}
+
+// schedule baut den Payload und plant die erste Zustellung sofort ein
+func (d *WebhookDispatcher) schedule(webhookID int, event WebhookEvent, comment *Comment, previous CommentStatus) (*WebhookDelivery, error) {
+	id, err := newDeliveryID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
+	body := webhookPayload{
+		ID:             id,
+		Event:          event,
+		CreatedAt:      now.Format(time.RFC3339),
+		PreviousStatus: previous,
+	}
+	if comment != nil {
+		body.Comment = webhookComment(comment, d.includeEmail)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &WebhookDelivery{
+		ID:          id,
+		WebhookID:   webhookID,
+		Event:       event,
+		Payload:     payload,
+		NextAttempt: now.Unix(),
+		CreatedAt:   now.Format(time.RFC3339),
+	}
+	return delivery, d.queue.Schedule(delivery)
+}
+
+// Ping plant ein Test-Ereignis für einen Webhook ein
+func (d *WebhookDispatcher) Ping(id int) (*WebhookDelivery, error) {
+	if _, err := d.store.GetWebhook(id); err != nil {
+		return nil, err
+	}
+	return d.schedule(id, EventPing, nil, "")
+}
+
+// RetryDeadLetter plant eine Zustellung aus der Dead-Letter-Liste neu ein
+func (d *WebhookDispatcher) RetryDeadLetter(id string) (*WebhookDelivery, error) {
+	delivery, err := d.queue.TakeDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.FailedAt = ""
+	delivery.NextAttempt = time.Now().Unix()
+	if err := d.queue.Schedule(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// signWebhook liefert den Wert für X-Webhook-Signature: HMAC-SHA256 über
+// "{timestamp}.{body}", damit abgefangene Requests nicht später wiederholt werden können
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Start stellt fällige Zustellungen im Intervall zu
+func (d *WebhookDispatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			deliveries, err := d.queue.Due(time.Now(), 50)
+			if err != nil {
+				log.Printf("❌ Webhook-Warteschlange: %v", err)
+				continue
+			}
+			for _, delivery := range deliveries {
+				d.deliver(delivery)
+			}
+		}
+	}()
+
+	log.Printf("🪝 Webhooks: bis zu %d Versuche, Wartezeit ab %v (max. %v)", d.maxAttempts, d.baseDelay, d.maxDelay)
+}
+
+// deliver versucht eine Zustellung und plant bei Fehlern mit exponentiellem
+// Backoff neu ein bzw. verschiebt sie nach dem letzten Versuch in die Dead-Letter-Liste
+func (d *WebhookDispatcher) deliver(delivery *WebhookDelivery) {
+	wh, err := d.store.GetWebhook(delivery.WebhookID)
+	if err != nil || !wh.Active {
+		log.Printf("🪝 Zustellung %s verworfen: Webhook #%d gelöscht oder inaktiv", delivery.ID, delivery.WebhookID)
+		return
+	}
+
+	delivery.Attempts++
+	entry := &WebhookLogEntry{
+		DeliveryID: delivery.ID,
+		WebhookID:  delivery.WebhookID,
+		Event:      delivery.Event,
+		Attempt:    delivery.Attempts,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	started := time.Now()
+	entry.StatusCode, err = d.post(wh, delivery)
+	entry.DurationMs = time.Since(started).Milliseconds()
+	entry.Success = err == nil
+
+	switch {
+	case err == nil:
+	case delivery.Attempts >= d.maxAttempts:
+		entry.Error = err.Error()
+		delivery.LastError = err.Error()
+		delivery.FailedAt = entry.CreatedAt
+		if err := d.queue.DeadLetter(delivery); err != nil {
+			log.Printf("❌ Zustellung %s verloren: %v", delivery.ID, err)
+		}
+		log.Printf("☠️  Webhook #%d: %s nach %d Versuchen in der Dead-Letter-Liste: %v", wh.ID, delivery.Event, delivery.Attempts, err)
+	default:
+		entry.Error = err.Error()
+		next := time.Now().Add(d.backoff(delivery.Attempts))
+		delivery.NextAttempt = next.Unix()
+		entry.NextAttempt = next.UTC().Format(time.RFC3339)
+		if err := d.queue.Schedule(delivery); err != nil {
+			log.Printf("❌ Zustellung %s verloren: %v", delivery.ID, err)
+		}
+	}
+
+	if err := d.queue.AppendLog(entry); err != nil {
+		log.Printf("⚠️  Zustell-Log nicht gespeichert: %v", err)
+	}
+}
+
+// backoff liefert die Wartezeit nach attempts Fehlschlägen (30s, 1m, 2m, ... bis maxDelay)
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.baseDelay
+	for i := 1; i < attempts && delay < d.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+	return delay
+}
+
+// signedRequest ist die Zustellung im Format json: der Payload unverändert,
+// signiert mit dem Secret des Webhooks
+func signedRequest(wh *Webhook, delivery *WebhookDelivery) (*http.Request, error) {
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", signWebhook(wh.Secret, timestamp, delivery.Payload))
+	return req, nil
+}
+
+// post schickt die Zustellung im Format des Webhooks; alles außer 2xx gilt als Fehler
+func (d *WebhookDispatcher) post(wh *Webhook, delivery *WebhookDelivery) (int, error) {
+	var req *http.Request
+	var err error
+	if wh.Format == FormatJSON {
+		req, err = signedRequest(wh, delivery)
+	} else {
+		req, err = d.chatRequest(wh, delivery)
+	}
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "comment-system-webhook/"+version)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("http %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhookSecret erzeugt ein Secret, wenn beim Anlegen keins angegeben wurde
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// ListWebhooks liefert alle Webhooks (ohne Secret und Token)
+func (cs *CommentService) ListWebhooks() ([]*Webhook, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	webhooks, err := cs.webhooks.store.ListWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	for _, wh := range webhooks {
+		wh.Secret = ""
+		wh.Token = ""
+	}
+	return webhooks, nil
+}
+
+// GetWebhook liefert einen Webhook (ohne Secret und Token)
+func (cs *CommentService) GetWebhook(id int) (*Webhook, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	wh, err := cs.webhooks.store.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	wh.Secret = ""
+	wh.Token = ""
+	return wh, nil
+}
+
+// ensureSecret erzeugt für Webhooks im Format json ein Secret, falls keins
+// angegeben ist. Liefert true, wenn ein neues erzeugt wurde.
+func ensureSecret(wh *Webhook) (bool, error) {
+	if wh.Secret != "" || wh.Format != FormatJSON {
+		return false, nil
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return false, fmt.Errorf("fehler beim Erzeugen des Webhook-Secrets: %w", err)
+	}
+	wh.Secret = secret
+	return true, nil
+}
+
+// CreateWebhook legt einen Webhook an. Die Antwort enthält das Secret, danach
+// wird es nicht mehr ausgegeben.
+func (cs *CommentService) CreateWebhook(wh *Webhook) (*Webhook, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	if err := wh.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := ensureSecret(wh); err != nil {
+		return nil, err
+	}
+	wh.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	if err := cs.webhooks.store.CreateWebhook(wh); err != nil {
+		return nil, err
+	}
+	log.Printf("🪝 Webhook #%d angelegt: %s (%s, %s)", wh.ID, wh.URL, wh.Format, joinEvents(wh.Events))
+	wh.Token = ""
+	return wh, nil
+}
+
+// UpdateWebhook überschreibt URL, Format, Ereignisse, Beschreibung und
+// Aktiv-Status; Secret und Token nur, wenn neue angegeben sind. Wird ein
+// Chat-Webhook auf json umgestellt, enthält die Antwort das neue Secret.
+func (cs *CommentService) UpdateWebhook(wh *Webhook) (*Webhook, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	existing, err := cs.webhooks.store.GetWebhook(wh.ID)
+	if err != nil {
+		return nil, err
+	}
+	if wh.Secret == "" {
+		wh.Secret = existing.Secret
+	}
+	if wh.Token == "" {
+		wh.Token = existing.Token
+	}
+	if err := wh.validate(); err != nil {
+		return nil, err
+	}
+	generated, err := ensureSecret(wh)
+	if err != nil {
+		return nil, err
+	}
+	wh.CreatedAt = existing.CreatedAt
+
+	if err := cs.webhooks.store.UpdateWebhook(wh); err != nil {
+		return nil, err
+	}
+	if !generated {
+		wh.Secret = ""
+	}
+	wh.Token = ""
+	return wh, nil
+}
+
+// DeleteWebhook löscht einen Webhook; ausstehende Zustellungen werden verworfen
+func (cs *CommentService) DeleteWebhook(id int) error {
+	if cs.webhooks == nil {
+		return ErrWebhooksUnsupported
+	}
+	return cs.webhooks.store.DeleteWebhook(id)
+}
+
+// joinEvents liefert die Ereignisse kommagetrennt (Speicherformat und Logs)
+func joinEvents(events []WebhookEvent) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
+
+// PingWebhook schickt ein Test-Ereignis an einen Webhook
+func (cs *CommentService) PingWebhook(id int) (*WebhookDelivery, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	return cs.webhooks.Ping(id)
+}
+
+// ListWebhookDeliveries liefert das Zustell-Log, optional nur eines Webhooks (0 = alle)
+func (cs *CommentService) ListWebhookDeliveries(webhookID, limit int) ([]*WebhookLogEntry, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	return cs.webhooks.queue.ListLog(webhookID, limit)
+}
+
+// ListDeadLetters liefert eine Seite der endgültig fehlgeschlagenen Zustellungen
+func (cs *CommentService) ListDeadLetters(offset, limit int) ([]*WebhookDelivery, int, error) {
+	if cs.webhooks == nil {
+		return nil, 0, ErrWebhooksUnsupported
+	}
+	return cs.webhooks.queue.ListDeadLetters(offset, limit)
+}
+
+// RetryDeadLetter plant eine fehlgeschlagene Zustellung erneut ein
+func (cs *CommentService) RetryDeadLetter(id string) (*WebhookDelivery, error) {
+	if cs.webhooks == nil {
+		return nil, ErrWebhooksUnsupported
+	}
+	return cs.webhooks.RetryDeadLetter(id)
+}
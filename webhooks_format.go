@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// WebhookFormat legt fest, in welcher Form ein Webhook die Ereignisse bekommt
+type WebhookFormat string
+
+const (
+	FormatJSON   WebhookFormat = "json"   // Payload unverändert, signiert (Standard)
+	FormatSlack  WebhookFormat = "slack"  // Slack Incoming Webhook (auch Mattermost, Rocket.Chat)
+	FormatMatrix WebhookFormat = "matrix" // Matrix Client-Server-API, m.room.message
+	FormatNtfy   WebhookFormat = "ntfy"   // ntfy-Push, url ist das Topic
+	FormatGotify WebhookFormat = "gotify" // Gotify-Push
+)
+
+// maxChatText kürzt den Kommentartext in Chat-Nachrichten (Zeichen)
+const maxChatText = 500
+
+// validate prüft die formatabhängigen Angaben eines Webhooks
+func (f WebhookFormat) validate(wh *Webhook) error {
+	switch f {
+	case FormatJSON, FormatSlack, FormatNtfy:
+		return nil
+	case FormatMatrix:
+		if !strings.HasSuffix(strings.TrimRight(wh.URL, "/"), "/send/m.room.message") {
+			return fmt.Errorf("%w: url muss auf /rooms/{raum}/send/m.room.message enden", ErrInvalidWebhook)
+		}
+		if wh.Token == "" {
+			return fmt.Errorf("%w: matrix braucht ein token (access token)", ErrInvalidWebhook)
+		}
+		return nil
+	case FormatGotify:
+		if wh.Token == "" {
+			return fmt.Errorf("%w: gotify braucht ein token (app token)", ErrInvalidWebhook)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unbekanntes format %q (erlaubt: json, slack, matrix, ntfy, gotify)", ErrInvalidWebhook, f)
+	}
+}
+
+// chatMessage ist eine Benachrichtigung unabhängig vom Chat-Format
+type chatMessage struct {
+	Title   string
+	Text    string // gekürzter Kommentartext
+	Details string // Post, Autor, Status
+	Link    string // Kommentar im Admin-Panel
+	Urgent  bool   // Kommentar wartet auf Freigabe
+}
+
+// newChatMessage baut die Nachricht aus dem gespeicherten Payload einer Zustellung
+func (d *WebhookDispatcher) newChatMessage(wh *Webhook, delivery *WebhookDelivery) (*chatMessage, error) {
+	var payload webhookPayload
+	if err := json.Unmarshal(delivery.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("fehler beim Dekodieren des Payloads: %w", err)
+	}
+
+	c := payload.Comment
+	if c == nil {
+		return &chatMessage{
+			Title: "🪝 Test-Benachrichtigung",
+			Text:  fmt.Sprintf("Webhook #%d (%s) ist eingerichtet.", wh.ID, wh.Format),
+			Link:  strings.TrimSuffix(d.apiBase, "/api/comments") + "/admin/panel",
+		}, nil
+	}
+
+	msg := &chatMessage{
+		Text:    truncateRunes(c.Text, maxChatText),
+		Details: fmt.Sprintf("Post: %s · Autor: %s · Status: %s", c.PostID, c.Username, c.Status),
+		Link:    adminCommentURL(d.apiBase, c.ID),
+	}
+	switch payload.Event {
+	case EventCommentCreated:
+		msg.Title = fmt.Sprintf("💬 Neuer Kommentar von %s zu %s", c.Username, c.PostID)
+		msg.Urgent = c.Status == StatusPending
+	case EventCommentApproved:
+		msg.Title = fmt.Sprintf("✅ Kommentar von %s zu %s freigegeben", c.Username, c.PostID)
+	case EventCommentRejected:
+		msg.Title = fmt.Sprintf("🚫 Kommentar von %s zu %s abgelehnt", c.Username, c.PostID)
+	case EventCommentSpam:
+		msg.Title = fmt.Sprintf("🛑 Kommentar von %s zu %s als Spam eingestuft", c.Username, c.PostID)
+		if c.Shadowed {
+			msg.Title += " (Shadow-Ban)"
+		}
+	case EventCommentDeleted:
+		msg.Title = fmt.Sprintf("🗑️ Kommentar von %s zu %s in den Papierkorb verschoben", c.Username, c.PostID)
+	default:
+		msg.Title = fmt.Sprintf("%s: Kommentar #%d", payload.Event, c.ID)
+	}
+	return msg, nil
+}
+
+// truncateRunes kürzt einen Text auf max Zeichen
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max]) + "…"
+}
+
+// chatRequest baut den Request für einen Webhook im Chat- bzw. Push-Format
+func (d *WebhookDispatcher) chatRequest(wh *Webhook, delivery *WebhookDelivery) (*http.Request, error) {
+	msg, err := d.newChatMessage(wh, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	switch wh.Format {
+	case FormatSlack:
+		return jsonRequest(http.MethodPost, wh.URL, slackBody(msg))
+	case FormatMatrix:
+		// Die Delivery-ID ist die Transaktions-ID: Matrix verwirft so doppelte
+		// Nachrichten, wenn eine Wiederholung schon angekommen war
+		target := strings.TrimRight(wh.URL, "/") + "/" + url.PathEscape(delivery.ID)
+		req, err := jsonRequest(http.MethodPut, target, matrixBody(msg))
+		if err == nil {
+			req.Header.Set("Authorization", "Bearer "+wh.Token)
+		}
+		return req, err
+	case FormatNtfy:
+		base, topic, err := splitNtfyTopic(wh.URL)
+		if err != nil {
+			return nil, err
+		}
+		req, err := jsonRequest(http.MethodPost, base, ntfyBody(msg, topic))
+		if err == nil && wh.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+wh.Token)
+		}
+		return req, err
+	case FormatGotify:
+		target := strings.TrimRight(wh.URL, "/")
+		if !strings.HasSuffix(target, "/message") {
+			target += "/message"
+		}
+		req, err := jsonRequest(http.MethodPost, target, gotifyBody(msg))
+		if err == nil {
+			req.Header.Set("X-Gotify-Key", wh.Token)
+		}
+		return req, err
+	default:
+		return nil, fmt.Errorf("unbekanntes format %q", wh.Format)
+	}
+}
+
+// jsonRequest kodiert body als JSON-Request
+func jsonRequest(method, target string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// slackEscape maskiert die Steuerzeichen von Slack-mrkdwn, damit Kommentare
+// keine Links oder Erwähnungen einschleusen
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackBody ist eine Nachricht für Slack Incoming Webhooks (Block Kit mit
+// text als Fallback für Benachrichtigungen und ältere Clients)
+func slackBody(msg *chatMessage) map[string]interface{} {
+	section := "*" + slackEscape(msg.Title) + "*"
+	if msg.Text != "" {
+		section += "\n>" + strings.ReplaceAll(slackEscape(msg.Text), "\n", "\n>")
+	}
+
+	blocks := []map[string]interface{}{
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": section}},
+	}
+	if msg.Details != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []map[string]string{{"type": "mrkdwn", "text": slackEscape(msg.Details)}},
+		})
+	}
+	blocks = append(blocks, map[string]interface{}{
+		"type": "actions",
+		"elements": []map[string]interface{}{{
+			"type": "button",
+			"text": map[string]string{"type": "plain_text", "text": "Im Admin-Panel öffnen"},
+			"url":  msg.Link,
+		}},
+	})
+
+	return map[string]interface{}{
+		"text":   fmt.Sprintf("%s: %s", slackEscape(msg.Title), msg.Link),
+		"blocks": blocks,
+	}
+}
+
+// matrixBody ist ein m.room.message-Event (m.notice, damit Bots nicht antworten)
+// mit Klartext und HTML
+func matrixBody(msg *chatMessage) map[string]string {
+	var plain, formatted strings.Builder
+	plain.WriteString(msg.Title + "\n")
+	formatted.WriteString("<strong>" + html.EscapeString(msg.Title) + "</strong>")
+	if msg.Text != "" {
+		plain.WriteString("\n" + msg.Text + "\n")
+		formatted.WriteString("<blockquote>" + strings.ReplaceAll(html.EscapeString(msg.Text), "\n", "<br>") + "</blockquote>")
+	}
+	if msg.Details != "" {
+		plain.WriteString("\n" + msg.Details + "\n")
+		formatted.WriteString("<p><em>" + html.EscapeString(msg.Details) + "</em></p>")
+	}
+	plain.WriteString("\nIm Admin-Panel öffnen: " + msg.Link)
+	formatted.WriteString(`<p><a href="` + html.EscapeString(msg.Link) + `">Im Admin-Panel öffnen</a></p>`)
+
+	return map[string]string{
+		"msgtype":        "m.notice",
+		"body":           plain.String(),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted.String(),
+	}
+}
+
+// splitNtfyTopic zerlegt die Topic-URL (https://ntfy.sh/mein-topic) in Server
+// und Topic, da ntfy JSON-Nachrichten nur an der Server-URL annimmt
+func splitNtfyTopic(topicURL string) (string, string, error) {
+	target, err := url.Parse(strings.TrimRight(topicURL, "/"))
+	if err != nil {
+		return "", "", err
+	}
+	topic := path.Base(target.Path)
+	if topic == "" || topic == "/" || topic == "." {
+		return "", "", fmt.Errorf("ntfy-url ohne topic: %s", topicURL)
+	}
+	target.Path = path.Dir(target.Path)
+	return target.String(), topic, nil
+}
+
+// ntfyBody ist eine ntfy-Nachricht im JSON-Format mit Klick- und Button-Link
+func ntfyBody(msg *chatMessage, topic string) map[string]interface{} {
+	priority := 3
+	if msg.Urgent {
+		priority = 4
+	}
+	message := msg.Text
+	if msg.Details != "" {
+		message = strings.TrimSpace(message + "\n\n" + msg.Details)
+	}
+	return map[string]interface{}{
+		"topic":    topic,
+		"title":    msg.Title,
+		"message":  message,
+		"priority": priority,
+		"click":    msg.Link,
+		"actions": []map[string]interface{}{{
+			"action": "view",
+			"label":  "Admin-Panel",
+			"url":    msg.Link,
+			"clear":  true,
+		}},
+	}
+}
+
+// gotifyBody ist eine Gotify-Nachricht (Klartext, Klick öffnet das Admin-Panel)
+func gotifyBody(msg *chatMessage) map[string]interface{} {
+	priority := 5
+	if msg.Urgent {
+		priority = 8
+	}
+	message := msg.Text
+	if msg.Details != "" {
+		message = strings.TrimSpace(message + "\n\n" + msg.Details)
+	}
+	return map[string]interface{}{
+		"title":    msg.Title,
+		"message":  message + "\n\n" + msg.Link,
+		"priority": priority,
+		"extras": map[string]interface{}{
+			"client::display":      map[string]string{"contentType": "text/plain"},
+			"client::notification": map[string]interface{}{"click": map[string]string{"url": msg.Link}},
+		},
+	}
+}
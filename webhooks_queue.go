@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Valkey-Layout der Webhook-Zustellung:
+//
+//	webhook:deliveries    Hash  Delivery-ID -> ausstehende Zustellung (JSON)
+//	webhook:retry         ZSet  Delivery-ID, Score = Zeitpunkt des nächsten Versuchs
+//	webhook:dead:items    Hash  Delivery-ID -> endgültig fehlgeschlagene Zustellung (JSON)
+//	webhook:dead:index    ZSet  Delivery-ID, Score = Zeitpunkt des Fehlschlags (ms)
+//	webhook:log           Liste Zustellversuche aller Webhooks (neueste zuerst, gekürzt auf logSize)
+//	webhook:log:{id}      Liste Zustellversuche eines Webhooks (ebenso gekürzt)
+
+// webhookLogKey liefert das Zustell-Log eines Webhooks (0 = alle)
+func webhookLogKey(webhookID int) string {
+	if webhookID == 0 {
+		return "webhook:log"
+	}
+	return fmt.Sprintf("webhook:log:%d", webhookID)
+}
+
+// deadLetterScript legt eine Zustellung ab und entfernt die ältesten über der
+// Obergrenze samt Inhalt
+var deadLetterScript = redis.NewScript(`
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+local excess = redis.call('ZCARD', KEYS[1]) - tonumber(ARGV[4])
+if excess > 0 then
+	local old = redis.call('ZRANGE', KEYS[1], 0, excess - 1)
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 0, excess - 1)
+	redis.call('HDEL', KEYS[2], unpack(old))
+end
+return 1
+`)
+
+// ValkeyWebhookQueue hält die Zustellungen in Valkey, damit Wiederholungen
+// einen Neustart überstehen und mehrere Replicas sie gemeinsam abarbeiten
+type ValkeyWebhookQueue struct {
+	client   *redis.Client
+	ctx      context.Context
+	logSize  int64
+	deadSize int64
+}
+
+// NewValkeyWebhookQueue erstellt eine ValkeyWebhookQueue
+func NewValkeyWebhookQueue(client *redis.Client) *ValkeyWebhookQueue {
+	return &ValkeyWebhookQueue{
+		client:   client,
+		ctx:      context.Background(),
+		logSize:  int64(getEnvAsInt("WEBHOOK_LOG_SIZE", 1000)),
+		deadSize: int64(getEnvAsInt("WEBHOOK_DEAD_LETTER_SIZE", 1000)),
+	}
+}
+
+// Schedule speichert die Zustellung und trägt sie mit NextAttempt in webhook:retry ein
+func (q *ValkeyWebhookQueue) Schedule(delivery *WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("fehler beim Kodieren der Zustellung: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.HSet(q.ctx, "webhook:deliveries", delivery.ID, data)
+	pipe.ZAdd(q.ctx, "webhook:retry", redis.Z{Score: float64(delivery.NextAttempt), Member: delivery.ID})
+	if _, err := pipe.Exec(q.ctx); err != nil {
+		return fmt.Errorf("fehler beim Einplanen der Zustellung: %w", err)
+	}
+	return nil
+}
+
+// Due entnimmt fällige Zustellungen. ZREM entscheidet, welche Replica eine
+// Zustellung bekommt, so wird keine doppelt verschickt.
+func (q *ValkeyWebhookQueue) Due(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	ids, err := q.client.ZRangeByScore(q.ctx, "webhook:retry", &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Warteschlange: %w", err)
+	}
+
+	var deliveries []*WebhookDelivery
+	for _, id := range ids {
+		claimed, err := q.client.ZRem(q.ctx, "webhook:retry", id).Result()
+		if err != nil {
+			return deliveries, fmt.Errorf("fehler beim Lesen der Warteschlange: %w", err)
+		}
+		if claimed == 0 {
+			continue // von einer anderen Replica übernommen
+		}
+
+		data, err := q.client.HGet(q.ctx, "webhook:deliveries", id).Bytes()
+		q.client.HDel(q.ctx, "webhook:deliveries", id)
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return deliveries, fmt.Errorf("fehler beim Lesen der Zustellung %s: %w", id, err)
+		}
+
+		var delivery WebhookDelivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return deliveries, fmt.Errorf("fehler beim Dekodieren der Zustellung %s: %w", id, err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
+
+// pushCapped hängt einen Eintrag vorne an Listen an und kürzt sie auf size
+func (q *ValkeyWebhookQueue) pushCapped(keys []string, value interface{}, size int64) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	pipe := q.client.TxPipeline()
+	for _, key := range keys {
+		pipe.LPush(q.ctx, key, data)
+		pipe.LTrim(q.ctx, key, 0, size-1)
+	}
+	_, err = pipe.Exec(q.ctx)
+	return err
+}
+
+// DeadLetter legt eine Zustellung in der Dead-Letter-Liste ab
+func (q *ValkeyWebhookQueue) DeadLetter(delivery *WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	keys := []string{"webhook:dead:index", "webhook:dead:items"}
+	score := time.Now().UnixMilli()
+	if err := deadLetterScript.Run(q.ctx, q.client, keys, delivery.ID, data, score, q.deadSize).Err(); err != nil {
+		return fmt.Errorf("fehler beim Speichern in der Dead-Letter-Liste: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters liefert eine Seite der Dead-Letter-Liste (neueste zuerst)
+// und deren Gesamtzahl
+func (q *ValkeyWebhookQueue) ListDeadLetters(offset, limit int) ([]*WebhookDelivery, int, error) {
+	total, err := q.client.ZCard(q.ctx, "webhook:dead:index").Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Laden der Dead-Letter-Liste: %w", err)
+	}
+	ids, err := q.client.ZRevRange(q.ctx, "webhook:dead:index", int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Laden der Dead-Letter-Liste: %w", err)
+	}
+	deliveries := make([]*WebhookDelivery, 0, len(ids))
+	if len(ids) == 0 {
+		return deliveries, int(total), nil
+	}
+
+	values, err := q.client.HMGet(q.ctx, "webhook:dead:items", ids...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fehler beim Laden der Dead-Letter-Liste: %w", err)
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var delivery WebhookDelivery
+		if json.Unmarshal([]byte(data), &delivery) == nil {
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	return deliveries, int(total), nil
+}
+
+// TakeDeadLetter entfernt eine Zustellung aus der Dead-Letter-Liste. ZREM
+// entscheidet, wer sie bekommt, so wird sie nicht doppelt neu eingeplant.
+func (q *ValkeyWebhookQueue) TakeDeadLetter(id string) (*WebhookDelivery, error) {
+	removed, err := q.client.ZRem(q.ctx, "webhook:dead:index", id).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Ändern der Dead-Letter-Liste: %w", err)
+	}
+	if removed == 0 {
+		return nil, fmt.Errorf("zustellung %s: %w", id, ErrDeliveryNotFound)
+	}
+
+	data, err := q.client.HGet(q.ctx, "webhook:dead:items", id).Bytes()
+	q.client.HDel(q.ctx, "webhook:dead:items", id)
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("zustellung %s: %w", id, ErrDeliveryNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Zustellung %s: %w", id, err)
+	}
+
+	var delivery WebhookDelivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return nil, fmt.Errorf("fehler beim Dekodieren der Zustellung %s: %w", id, err)
+	}
+	return &delivery, nil
+}
+
+// AppendLog hängt einen Eintrag an webhook:log und webhook:log:{id} an
+func (q *ValkeyWebhookQueue) AppendLog(entry *WebhookLogEntry) error {
+	keys := []string{webhookLogKey(0), webhookLogKey(entry.WebhookID)}
+	if err := q.pushCapped(keys, entry, q.logSize); err != nil {
+		return fmt.Errorf("fehler beim Speichern im Zustell-Log: %w", err)
+	}
+	return nil
+}
+
+// ListLog liefert die neuesten Einträge aus webhook:log bzw. webhook:log:{id}
+func (q *ValkeyWebhookQueue) ListLog(webhookID, limit int) ([]*WebhookLogEntry, error) {
+	values, err := q.client.LRange(q.ctx, webhookLogKey(webhookID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden des Zustell-Logs: %w", err)
+	}
+
+	entries := make([]*WebhookLogEntry, 0, len(values))
+	for _, value := range values {
+		var entry WebhookLogEntry
+		if json.Unmarshal([]byte(value), &entry) == nil {
+			entries = append(entries, &entry)
+		}
+	}
+	return entries, nil
+}
+
+// MemoryWebhookQueue hält die Zustellungen im Prozessspeicher (nur Einzelinstanz,
+// ausstehende Wiederholungen gehen beim Neustart verloren)
+type MemoryWebhookQueue struct {
+	mutex    sync.Mutex
+	pending  []*WebhookDelivery
+	dead     []*WebhookDelivery // neueste zuerst
+	log      []*WebhookLogEntry // neueste zuerst
+	logSize  int
+	deadSize int
+}
+
+// NewMemoryWebhookQueue erstellt eine leere MemoryWebhookQueue
+func NewMemoryWebhookQueue() *MemoryWebhookQueue {
+	return &MemoryWebhookQueue{
+		logSize:  getEnvAsInt("WEBHOOK_LOG_SIZE", 1000),
+		deadSize: getEnvAsInt("WEBHOOK_DEAD_LETTER_SIZE", 1000),
+	}
+}
+
+// Schedule plant eine Zustellung ein
+func (q *MemoryWebhookQueue) Schedule(delivery *WebhookDelivery) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	clone := *delivery
+	q.pending = append(q.pending, &clone)
+	return nil
+}
+
+// Due entnimmt bis zu limit fällige Zustellungen, älteste zuerst
+func (q *MemoryWebhookQueue) Due(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	sort.SliceStable(q.pending, func(i, j int) bool { return q.pending[i].NextAttempt < q.pending[j].NextAttempt })
+	n := 0
+	for n < len(q.pending) && n < limit && q.pending[n].NextAttempt <= now.Unix() {
+		n++
+	}
+	due := append([]*WebhookDelivery(nil), q.pending[:n]...)
+	q.pending = q.pending[n:]
+	return due, nil
+}
+
+// DeadLetter legt eine Zustellung in der Dead-Letter-Liste ab
+func (q *MemoryWebhookQueue) DeadLetter(delivery *WebhookDelivery) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	clone := *delivery
+	q.dead = append([]*WebhookDelivery{&clone}, q.dead...)
+	if len(q.dead) > q.deadSize {
+		q.dead = q.dead[:q.deadSize]
+	}
+	return nil
+}
+
+// ListDeadLetters liefert eine Seite der Dead-Letter-Liste (neueste zuerst)
+func (q *MemoryWebhookQueue) ListDeadLetters(offset, limit int) ([]*WebhookDelivery, int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	deliveries := make([]*WebhookDelivery, 0)
+	for i := offset; i < len(q.dead) && i < offset+limit; i++ {
+		clone := *q.dead[i]
+		deliveries = append(deliveries, &clone)
+	}
+	return deliveries, len(q.dead), nil
+}
+
+// TakeDeadLetter entfernt eine Zustellung aus der Dead-Letter-Liste
+func (q *MemoryWebhookQueue) TakeDeadLetter(id string) (*WebhookDelivery, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for i, delivery := range q.dead {
+		if delivery.ID == id {
+			q.dead = append(q.dead[:i], q.dead[i+1:]...)
+			return delivery, nil
+		}
+	}
+	return nil, fmt.Errorf("zustellung %s: %w", id, ErrDeliveryNotFound)
+}
+
+// AppendLog hängt einen Eintrag an das Zustell-Log an
+func (q *MemoryWebhookQueue) AppendLog(entry *WebhookLogEntry) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.log = append([]*WebhookLogEntry{entry}, q.log...)
+	if len(q.log) > q.logSize {
+		q.log = q.log[:q.logSize]
+	}
+	return nil
+}
+
+// ListLog liefert die neuesten Einträge, optional nur eines Webhooks
+func (q *MemoryWebhookQueue) ListLog(webhookID, limit int) ([]*WebhookLogEntry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	entries := []*WebhookLogEntry{}
+	for _, entry := range q.log {
+		if webhookID == 0 || entry.WebhookID == webhookID {
+			clone := *entry
+			entries = append(entries, &clone)
+			if len(entries) == limit {
+				break
+			}
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Webhooks werden im selben Backend wie die Kommentare gespeichert,
+// die Ereignisse jeweils kommagetrennt
+
+// webhookKey liefert den Hash-Key eines Webhooks
+func webhookKey(id int) string {
+	return fmt.Sprintf("webhook:%d", id)
+}
+
+// splitEvents zerlegt die mit joinEvents gespeicherte Ereignisliste
+func splitEvents(value string) []WebhookEvent {
+	var events []WebhookEvent
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			events = append(events, WebhookEvent(name))
+		}
+	}
+	return events
+}
+
+// writeWebhook speichert alle Felder eines Webhooks im Hash
+func (s *ValkeyStore) writeWebhook(wh *Webhook) error {
+	pipe := s.client.TxPipeline()
+	pipe.HSet(s.ctx, webhookKey(wh.ID), map[string]interface{}{
+		"url":         wh.URL,
+		"events":      joinEvents(wh.Events),
+		"secret":      wh.Secret,
+		"format":      string(wh.Format),
+		"token":       wh.Token,
+		"description": wh.Description,
+		"active":      strconv.FormatBool(wh.Active),
+		"created_at":  wh.CreatedAt,
+	})
+	pipe.SAdd(s.ctx, "webhooks:index", wh.ID)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Speichern des Webhooks: %w", err)
+	}
+	return nil
+}
+
+// CreateWebhook vergibt eine ID (webhook_counter) und speichert den Webhook
+func (s *ValkeyStore) CreateWebhook(wh *Webhook) error {
+	id, err := s.client.Incr(s.ctx, "webhook_counter").Result()
+	if err != nil {
+		return fmt.Errorf("fehler beim Generieren der ID: %w", err)
+	}
+	wh.ID = int(id)
+	return s.writeWebhook(wh)
+}
+
+// GetWebhook holt einen Webhook anhand der ID
+func (s *ValkeyStore) GetWebhook(id int) (*Webhook, error) {
+	fields, err := s.client.HGetAll(s.ctx, webhookKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden des Webhooks %d: %w", id, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrWebhookNotFound)
+	}
+
+	wh := &Webhook{
+		ID:          id,
+		URL:         fields["url"],
+		Events:      splitEvents(fields["events"]),
+		Secret:      fields["secret"],
+		Format:      WebhookFormat(fields["format"]),
+		Token:       fields["token"],
+		Description: fields["description"],
+		Active:      fields["active"] == "true",
+		CreatedAt:   fields["created_at"],
+	}
+	if wh.Format == "" {
+		wh.Format = FormatJSON // vor Einführung der Formate angelegt
+	}
+	return wh, nil
+}
+
+// UpdateWebhook überschreibt einen bestehenden Webhook
+func (s *ValkeyStore) UpdateWebhook(wh *Webhook) error {
+	if _, err := s.GetWebhook(wh.ID); err != nil {
+		return err
+	}
+	return s.writeWebhook(wh)
+}
+
+// DeleteWebhook entfernt einen Webhook samt Index-Eintrag
+func (s *ValkeyStore) DeleteWebhook(id int) error {
+	pipe := s.client.TxPipeline()
+	deleted := pipe.Del(s.ctx, webhookKey(id))
+	pipe.SRem(s.ctx, "webhooks:index", id)
+	if _, err := pipe.Exec(s.ctx); err != nil {
+		return fmt.Errorf("fehler beim Löschen des Webhooks: %w", err)
+	}
+	if deleted.Val() == 0 {
+		return fmt.Errorf("webhook %d: %w", id, ErrWebhookNotFound)
+	}
+	return nil
+}
+
+// ListWebhooks liefert alle Webhooks aus webhooks:index, sortiert nach ID
+func (s *ValkeyStore) ListWebhooks() ([]*Webhook, error) {
+	ids, err := s.client.SMembers(s.ctx, "webhooks:index").Result()
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Webhooks: %w", err)
+	}
+
+	webhooks := make([]*Webhook, 0, len(ids))
+	for _, value := range ids {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		wh, err := s.GetWebhook(id)
+		if errors.Is(err, ErrWebhookNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, wh)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+// webhookColumns ist die Spaltenliste für scanWebhook
+const webhookColumns = "id, url, events, secret, format, token, description, active, created_at"
+
+// scanWebhook liest eine Zeile mit webhookColumns
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var wh Webhook
+	var events, format string
+	if err := row.Scan(&wh.ID, &wh.URL, &events, &wh.Secret, &format, &wh.Token, &wh.Description, &wh.Active, &wh.CreatedAt); err != nil {
+		return nil, err
+	}
+	wh.Events = splitEvents(events)
+	wh.Format = WebhookFormat(format)
+	return &wh, nil
+}
+
+// CreateWebhook speichert einen neuen Webhook
+func (s *SQLStore) CreateWebhook(wh *Webhook) error {
+	err := s.db.QueryRow(s.rebind(`INSERT INTO webhooks
+		(url, events, secret, format, token, description, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+		wh.URL, joinEvents(wh.Events), wh.Secret, string(wh.Format), wh.Token, wh.Description, wh.Active, wh.CreatedAt,
+	).Scan(&wh.ID)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Webhooks: %w", err)
+	}
+	return nil
+}
+
+// GetWebhook holt einen Webhook anhand der ID
+func (s *SQLStore) GetWebhook(id int) (*Webhook, error) {
+	wh, err := scanWebhook(s.db.QueryRow(s.rebind("SELECT "+webhookColumns+" FROM webhooks WHERE id = ?"), id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrWebhookNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden des Webhooks %d: %w", id, err)
+	}
+	return wh, nil
+}
+
+// UpdateWebhook überschreibt einen bestehenden Webhook
+func (s *SQLStore) UpdateWebhook(wh *Webhook) error {
+	result, err := s.db.Exec(s.rebind(`UPDATE webhooks
+		SET url = ?, events = ?, secret = ?, format = ?, token = ?, description = ?, active = ?
+		WHERE id = ?`),
+		wh.URL, joinEvents(wh.Events), wh.Secret, string(wh.Format), wh.Token, wh.Description, wh.Active, wh.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("fehler beim Speichern des Webhooks: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("webhook %d: %w", wh.ID, ErrWebhookNotFound)
+	}
+	return nil
+}
+
+// DeleteWebhook entfernt einen Webhook
+func (s *SQLStore) DeleteWebhook(id int) error {
+	result, err := s.db.Exec(s.rebind("DELETE FROM webhooks WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("fehler beim Löschen des Webhooks: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("webhook %d: %w", id, ErrWebhookNotFound)
+	}
+	return nil
+}
+
+// ListWebhooks liefert alle Webhooks, sortiert nach ID
+func (s *SQLStore) ListWebhooks() ([]*Webhook, error) {
+	rows, err := s.db.Query("SELECT " + webhookColumns + " FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Laden der Webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen der Webhooks: %w", err)
+		}
+		webhooks = append(webhooks, wh)
+	}
+	return webhooks, rows.Err()
+}
+
+// cloneWebhook kopiert auch die Ereignisliste
+func cloneWebhook(wh *Webhook) *Webhook {
+	clone := *wh
+	clone.Events = append([]WebhookEvent(nil), wh.Events...)
+	return &clone
+}
+
+// CreateWebhook vergibt eine neue ID und speichert den Webhook
+func (s *MemoryStore) CreateWebhook(wh *Webhook) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextWebhookID++
+	wh.ID = s.nextWebhookID
+	s.webhooks[wh.ID] = cloneWebhook(wh)
+	return nil
+}
+
+// GetWebhook holt einen Webhook anhand der ID
+func (s *MemoryStore) GetWebhook(id int) (*Webhook, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	wh, ok := s.webhooks[id]
+	if !ok {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrWebhookNotFound)
+	}
+	return cloneWebhook(wh), nil
+}
+
+// UpdateWebhook überschreibt einen bestehenden Webhook
+func (s *MemoryStore) UpdateWebhook(wh *Webhook) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.webhooks[wh.ID]; !ok {
+		return fmt.Errorf("webhook %d: %w", wh.ID, ErrWebhookNotFound)
+	}
+	s.webhooks[wh.ID] = cloneWebhook(wh)
+	return nil
+}
+
+// DeleteWebhook entfernt einen Webhook
+func (s *MemoryStore) DeleteWebhook(id int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return fmt.Errorf("webhook %d: %w", id, ErrWebhookNotFound)
+	}
+	delete(s.webhooks, id)
+	return nil
+}
+
+// ListWebhooks liefert alle Webhooks, sortiert nach ID
+func (s *MemoryStore) ListWebhooks() ([]*Webhook, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	webhooks := make([]*Webhook, 0, len(s.webhooks))
+	for _, wh := range s.webhooks {
+		webhooks = append(webhooks, cloneWebhook(wh))
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestDispatcher baut einen Dispatcher auf MemoryStore und MemoryWebhookQueue
+func newTestDispatcher(t *testing.T) (*WebhookDispatcher, *MemoryStore, *MemoryWebhookQueue) {
+	t.Helper()
+	store := NewMemoryStore()
+	queue := NewMemoryWebhookQueue()
+	d := NewWebhookDispatcher(store, queue)
+	if d == nil {
+		t.Fatal("MemoryStore unterstützt keine Webhooks")
+	}
+	return d, store, queue
+}
+
+// mustCreateWebhook legt einen aktiven Webhook an
+func mustCreateWebhook(t *testing.T, store WebhookStore, wh *Webhook) *Webhook {
+	t.Helper()
+	wh.Active = true
+	if err := wh.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if err := store.CreateWebhook(wh); err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+	return wh
+}
+
+// scheduled liefert die eingeplanten Ereignisse je Webhook und leert die Warteschlange
+func scheduled(t *testing.T, queue WebhookQueue) map[int][]webhookPayload {
+	t.Helper()
+	due, err := queue.Due(time.Now().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	events := make(map[int][]webhookPayload)
+	for _, delivery := range due {
+		var payload webhookPayload
+		if err := json.Unmarshal(delivery.Payload, &payload); err != nil {
+			t.Fatalf("Payload: %v", err)
+		}
+		events[delivery.WebhookID] = append(events[delivery.WebhookID], payload)
+	}
+	return events
+}
+
+func TestWebhookCreatedSkipsSpam(t *testing.T) {
+	d, store, queue := newTestDispatcher(t)
+	created := mustCreateWebhook(t, store, &Webhook{URL: "https://example.com/a", Events: []WebhookEvent{EventCommentCreated}})
+	spam := mustCreateWebhook(t, store, &Webhook{URL: "https://example.com/b", Events: []WebhookEvent{EventCommentCreated, EventCommentSpam}})
+	cs := &CommentService{store: store, webhooks: d}
+
+	input := CommentInput{PostID: "post-a", Username: "Anna", MailAddress: "anna@example.com", Text: "Hallo"}
+	pending, err := cs.CreateComment(input)
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	for _, action := range []BanAction{BanSpam, BanShadow} {
+		banned := input
+		banned.Ban = &BanRule{ID: 1, Type: BanEmail, Value: input.MailAddress, Action: action}
+		if _, err := cs.CreateComment(banned); err != nil {
+			t.Fatalf("CreateComment (%s): %v", action, err)
+		}
+	}
+
+	events := scheduled(t, queue)
+	if got := events[created.ID]; len(got) != 1 || got[0].Event != EventCommentCreated || got[0].Comment.ID != pending.ID {
+		t.Errorf("comment.created-Abo bekommt %+v, erwartet nur #%d", got, pending.ID)
+	}
+	got := events[spam.ID]
+	if len(got) != 3 || got[1].Event != EventCommentSpam || got[2].Event != EventCommentSpam {
+		t.Fatalf("comment.spam-Abo bekommt %+v, erwartet created und zweimal spam", got)
+	}
+	if !got[2].Comment.Shadowed {
+		t.Error("Shadow-Ban-Kommentar nicht als shadowed markiert")
+	}
+
+	for _, payloads := range events {
+		for _, payload := range payloads {
+			if payload.Comment.MailAddress != "" {
+				t.Errorf("%s enthält die E-Mail-Adresse ohne WEBHOOK_INCLUDE_EMAIL", payload.Event)
+			}
+		}
+	}
+}
+
+func TestWebhookIncludeEmail(t *testing.T) {
+	t.Setenv("WEBHOOK_INCLUDE_EMAIL", "true")
+	d, store, queue := newTestDispatcher(t)
+	wh := mustCreateWebhook(t, store, &Webhook{URL: "https://example.com/a", Events: []WebhookEvent{EventCommentCreated}})
+
+	d.Emit(EventCommentCreated, &Comment{ID: 1, PostID: "post-a", MailAddress: "anna@example.com", IP: "192.0.2.1"}, "")
+
+	got := scheduled(t, queue)[wh.ID]
+	if len(got) != 1 || got[0].Comment.MailAddress != "anna@example.com" || got[0].Comment.IP != "" {
+		t.Fatalf("Payload %+v, erwartet E-Mail-Adresse ohne IP", got)
+	}
+}
+
+func TestChatWebhookDefaultsToPendingAndApproved(t *testing.T) {
+	d, store, queue := newTestDispatcher(t)
+	wh := mustCreateWebhook(t, store, &Webhook{URL: "https://ntfy.sh/kommentare", Format: FormatNtfy})
+	if len(wh.Events) != 1 || wh.Events[0] != EventCommentCreated {
+		t.Fatalf("Chat-Ziel ohne events abonniert %v", wh.Events)
+	}
+	cs := &CommentService{store: store, webhooks: d}
+
+	input := CommentInput{PostID: "post-a", Username: "Anna", MailAddress: "anna@example.com", Text: "Hallo"}
+	if _, err := cs.CreateComment(input); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	input.Ban = &BanRule{ID: 1, Type: BanEmail, Value: input.MailAddress, Action: BanShadow}
+	if _, err := cs.CreateComment(input); err != nil {
+		t.Fatalf("CreateComment (shadow): %v", err)
+	}
+
+	due, err := queue.Due(time.Now().Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("%d Zustellungen, erwartet nur den wartenden Kommentar", len(due))
+	}
+	msg, err := d.newChatMessage(wh, due[0])
+	if err != nil {
+		t.Fatalf("newChatMessage: %v", err)
+	}
+	if !msg.Urgent || !strings.Contains(msg.Details, "Status: pending") {
+		t.Errorf("Nachricht %+v, erwartet dringend und pending", msg)
+	}
+}
+
+func TestChatMessageSpam(t *testing.T) {
+	d, store, _ := newTestDispatcher(t)
+	wh := mustCreateWebhook(t, store, &Webhook{URL: "https://ntfy.sh/kommentare", Format: FormatNtfy, Events: []WebhookEvent{EventCommentSpam}})
+
+	comment := &Comment{ID: 3, PostID: "post-a", Username: "Bot", MailAddress: "bot@example.com", Text: "Casino", Shadowed: true}
+	comment.setStatus(StatusSpam)
+	delivery, err := d.schedule(wh.ID, EventCommentSpam, comment, "")
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	msg, err := d.newChatMessage(wh, delivery)
+	if err != nil {
+		t.Fatalf("newChatMessage: %v", err)
+	}
+	if !strings.Contains(msg.Title, "Spam") || !strings.Contains(msg.Title, "Shadow-Ban") || msg.Urgent {
+		t.Errorf("Nachricht %+v", msg)
+	}
+	if strings.Contains(msg.Title+msg.Details+msg.Text, "bot@example.com") {
+		t.Error("Chat-Nachricht enthält die E-Mail-Adresse")
+	}
+}
+
+// receivedWebhook ist ein beim Testserver eingegangener Zustellversuch
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookServer startet einen Empfänger, der mit status(n) auf den n-ten
+// Versuch antwortet; alle Requests landen in requests
+func newWebhookServer(t *testing.T, status func(attempt int) int) (string, chan receivedWebhook) {
+	t.Helper()
+
+	requests := make(chan receivedWebhook, 20)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Body nicht lesbar: %v", err)
+		}
+		requests <- receivedWebhook{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status(int(attempts.Add(1))))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, requests
+}
+
+// nextWebhookRequest liefert den nächsten Zustellversuch
+func nextWebhookRequest(t *testing.T, requests chan receivedWebhook) receivedWebhook {
+	t.Helper()
+	select {
+	case req := <-requests:
+		return req
+	default:
+		t.Fatal("kein Zustellversuch beim Testserver")
+		return receivedWebhook{}
+	}
+}
+
+// dueDelivery entnimmt die einzige eingeplante Zustellung, egal wann sie fällig ist
+func dueDelivery(t *testing.T, queue WebhookQueue) *WebhookDelivery {
+	t.Helper()
+	due, err := queue.Due(time.Now().Add(24*time.Hour), 100)
+	if err != nil {
+		t.Fatalf("Due: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("%d Zustellungen eingeplant, erwartet 1", len(due))
+	}
+	return due[0]
+}
+
+func TestWebhookDeliverySignature(t *testing.T) {
+	target, requests := newWebhookServer(t, func(int) int { return http.StatusNoContent })
+	d, store, queue := newTestDispatcher(t)
+	wh := mustCreateWebhook(t, store, &Webhook{URL: target, Secret: "whsec_test", Events: []WebhookEvent{EventCommentCreated}})
+
+	d.Emit(EventCommentCreated, &Comment{ID: 1, PostID: "post-a", Username: "Anna", Text: "Hallo"}, "")
+	delivery := dueDelivery(t, queue)
+	d.deliver(delivery)
+
+	req := nextWebhookRequest(t, requests)
+	if got := req.header.Get("X-Webhook-Event"); got != string(EventCommentCreated) {
+		t.Errorf("X-Webhook-Event %q", got)
+	}
+	if got := req.header.Get("X-Webhook-Delivery"); got != delivery.ID {
+		t.Errorf("X-Webhook-Delivery %q, erwartet %s", got, delivery.ID)
+	}
+	if got := req.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type %q", got)
+	}
+
+	// So prüft ein Empfänger: HMAC-SHA256 über "{timestamp}.{body}" mit dem Secret
+	timestamp := req.header.Get("X-Webhook-Timestamp")
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(sent, 0)) > time.Minute {
+		t.Fatalf("X-Webhook-Timestamp %q", timestamp)
+	}
+	verify := func(secret string, body []byte) bool {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		return hmac.Equal([]byte(req.header.Get("X-Webhook-Signature")), []byte("sha256="+hex.EncodeToString(mac.Sum(nil))))
+	}
+	if !verify(wh.Secret, req.body) {
+		t.Errorf("Signatur %q passt nicht zum Body", req.header.Get("X-Webhook-Signature"))
+	}
+	if verify("falsch", req.body) || verify(wh.Secret, append(req.body, ' ')) {
+		t.Error("Signatur gilt auch für fremdes Secret oder veränderten Body")
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil || payload.ID != delivery.ID || payload.Comment.ID != 1 {
+		t.Errorf("Payload %+v, %v", payload, err)
+	}
+
+	entries, _ := queue.ListLog(wh.ID, 10)
+	if len(entries) != 1 || !entries[0].Success || entries[0].StatusCode != http.StatusNoContent || entries[0].NextAttempt != "" {
+		t.Errorf("Zustell-Log %+v, erwartet einen erfolgreichen Versuch", entries)
+	}
+	if due, _ := queue.Due(time.Now().Add(24*time.Hour), 100); len(due) != 0 {
+		t.Errorf("nach Erfolg noch %d Zustellungen eingeplant", len(due))
+	}
+}
+
+func TestWebhookRetryAndDeadLetter(t *testing.T) {
+	healthy := atomic.Bool{}
+	target, requests := newWebhookServer(t, func(int) int {
+		if healthy.Load() {
+			return http.StatusOK
+		}
+		return http.StatusInternalServerError
+	})
+	d, store, queue := newTestDispatcher(t)
+	d.maxAttempts = 4
+	d.baseDelay = 30 * time.Second
+	d.maxDelay = 90 * time.Second
+	wh := mustCreateWebhook(t, store, &Webhook{URL: target, Secret: "whsec_test", Events: []WebhookEvent{EventCommentApproved}})
+
+	d.Emit(EventCommentApproved, &Comment{ID: 1, PostID: "post-a"}, StatusPending)
+
+	// Wartezeit verdoppelt sich nach jedem Fehlschlag bis maxDelay
+	var id string
+	for attempt, wait := range []time.Duration{30 * time.Second, 60 * time.Second, 90 * time.Second} {
+		delivery := dueDelivery(t, queue)
+		id = delivery.ID
+		before := time.Now()
+		d.deliver(delivery)
+		nextWebhookRequest(t, requests)
+
+		retry := dueDelivery(t, queue)
+		if retry.ID != id || retry.Attempts != attempt+1 {
+			t.Fatalf("Versuch %d: neu eingeplant %+v", attempt+1, retry)
+		}
+		if next := time.Unix(retry.NextAttempt, 0); next.Before(before.Add(wait).Truncate(time.Second)) || next.After(time.Now().Add(wait)) {
+			t.Errorf("Versuch %d: nächster Versuch in %v, erwartet %v", attempt+1, next.Sub(before).Round(time.Second), wait)
+		}
+		if err := queue.Schedule(retry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Der letzte Versuch landet in der Dead-Letter-Liste statt in der Warteschlange
+	d.deliver(dueDelivery(t, queue))
+	nextWebhookRequest(t, requests)
+	if due, _ := queue.Due(time.Now().Add(24*time.Hour), 100); len(due) != 0 {
+		t.Fatalf("nach %d Versuchen noch eingeplant: %+v", d.maxAttempts, due[0])
+	}
+	dead, total, err := queue.ListDeadLetters(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || dead[0].ID != id || dead[0].Attempts != 4 || dead[0].LastError != "http 500" || dead[0].FailedAt == "" {
+		t.Fatalf("Dead-Letter-Liste %+v (%d)", dead, total)
+	}
+
+	entries, _ := queue.ListLog(wh.ID, 10)
+	if len(entries) != 4 || entries[0].Success || entries[0].NextAttempt != "" || entries[0].StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Zustell-Log %+v, erwartet 4 Fehlschläge, zuletzt ohne weiteren Versuch", entries)
+	}
+	if entries[1].NextAttempt == "" {
+		t.Error("Zustell-Log ohne nächsten Versuch vor dem letzten Fehlschlag")
+	}
+
+	// Erneut eingeplant beginnt die Zählung von vorn und die Zustellung gelingt
+	healthy.Store(true)
+	retried, err := d.RetryDeadLetter(id)
+	if err != nil {
+		t.Fatalf("RetryDeadLetter: %v", err)
+	}
+	if retried.Attempts != 0 || retried.LastError != "" {
+		t.Errorf("erneut eingeplant %+v", retried)
+	}
+	due, _ := queue.Due(time.Now(), 100)
+	if len(due) != 1 {
+		t.Fatalf("%d sofort fällige Zustellungen, erwartet 1", len(due))
+	}
+	d.deliver(due[0])
+	nextWebhookRequest(t, requests)
+	if _, total, _ := queue.ListDeadLetters(0, 10); total != 0 {
+		t.Errorf("Dead-Letter-Liste enthält noch %d Einträge", total)
+	}
+	if entries, _ := queue.ListLog(wh.ID, 1); len(entries) != 1 || !entries[0].Success || entries[0].Attempt != 1 {
+		t.Errorf("Zustell-Log %+v, erwartet erfolgreichen ersten Versuch", entries)
+	}
+	if _, err := d.RetryDeadLetter(id); err == nil {
+		t.Error("Zustellung zweimal aus der Dead-Letter-Liste geholt")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	d := &WebhookDispatcher{baseDelay: 30 * time.Second, maxDelay: 6 * time.Minute}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 6 * time.Minute, 6 * time.Minute}
+	for i, expected := range want {
+		if got := d.backoff(i + 1); got != expected {
+			t.Errorf("backoff(%d) = %v, erwartet %v", i+1, got, expected)
+		}
+	}
+}